@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"errors"
+	"go_vdot_api/middleware"
+	"go_vdot_api/pkg/logger"
+	"go_vdot_api/pkg/metrics"
+	"go_vdot_api/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type IAdminController interface {
+	GetUsers(c echo.Context) error
+	GetUser(c echo.Context) error
+	DisableUser(c echo.Context) error
+	EnableUser(c echo.Context) error
+	ResetPassword(c echo.Context) error
+	GetAuditLogs(c echo.Context) error
+	GetMetrics(c echo.Context) error
+}
+
+type adminController struct {
+	au usecase.IAdminUsecase
+}
+
+func NewAdminController(au usecase.IAdminUsecase) IAdminController {
+	return &adminController{au}
+}
+
+// GetUsers はユーザーを検索する（例：?q=taro&limit=50&offset=0。q は名前・メールアドレスの部分一致）
+func (ac *adminController) GetUsers(c echo.Context) error {
+	limit, offset := 0, 0
+	var err error
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, "invalid limit format")
+		}
+	}
+	if offsetStr := c.QueryParam("offset"); offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, "invalid offset format")
+		}
+	}
+
+	usersRes, err := ac.au.GetUsers(c.QueryParam("q"), limit, offset)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, usersRes)
+}
+
+// GetUser はユーザー情報とテーブルごとのデータの件数を返す
+func (ac *adminController) GetUser(c echo.Context) error {
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid id")
+	}
+
+	userRes, err := ac.au.GetUser(uint(userId))
+	if err != nil {
+		return adminErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, userRes)
+}
+
+func (ac *adminController) DisableUser(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid id")
+	}
+
+	userRes, err := ac.au.DisableUser(userClaims.UserID, uint(userId), c.RealIP())
+	if err != nil {
+		return adminErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, userRes)
+}
+
+func (ac *adminController) EnableUser(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid id")
+	}
+
+	userRes, err := ac.au.EnableUser(userClaims.UserID, uint(userId), c.RealIP())
+	if err != nil {
+		return adminErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, userRes)
+}
+
+// ResetPassword はユーザーのパスワードを一時パスワードにして返す
+func (ac *adminController) ResetPassword(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+	userId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid id")
+	}
+
+	resetRes, err := ac.au.ResetPassword(userClaims.UserID, uint(userId), c.RealIP())
+	if err != nil {
+		return adminErrorResponse(c, err)
+	}
+	return c.JSON(http.StatusOK, resetRes)
+}
+
+// GetAuditLogs は新しい順に監査ログを返す（例：?user_id=3&action=account_disabled&limit=50）
+func (ac *adminController) GetAuditLogs(c echo.Context) error {
+	userId, limit := 0, 0
+	var err error
+	if userIdStr := c.QueryParam("user_id"); userIdStr != "" {
+		userId, err = strconv.Atoi(userIdStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, "invalid user_id format")
+		}
+	}
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, "invalid limit format")
+		}
+	}
+
+	auditLogsRes, err := ac.au.GetAuditLogs(uint(userId), c.QueryParam("action"), limit)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, auditLogsRes)
+}
+
+// GetMetrics は起動してからのログインの試行・拒否・ロックの回数を返す
+func (ac *adminController) GetMetrics(c echo.Context) error {
+	return c.JSON(http.StatusOK, metrics.Snapshot())
+}
+
+func adminErrorResponse(c echo.Context, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, "user not found")
+	}
+	logger.Error("admin error: %v", err)
+	return c.JSON(http.StatusBadRequest, err.Error())
+}
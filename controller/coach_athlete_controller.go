@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"errors"
+	"go_vdot_api/middleware"
+	"go_vdot_api/model"
+	"go_vdot_api/pkg/logger"
+	"go_vdot_api/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type ICoachAthleteController interface {
+	InviteAthlete(c echo.Context) error
+	GetCoachLinks(c echo.Context) error
+	AcceptInvitation(c echo.Context) error
+	DeleteCoachAthlete(c echo.Context) error
+}
+
+type coachAthleteController struct {
+	cau usecase.ICoachAthleteUsecase
+}
+
+func NewCoachAthleteController(cau usecase.ICoachAthleteUsecase) ICoachAthleteController {
+	return &coachAthleteController{cau}
+}
+
+// InviteAthlete はコーチとして選手をメールアドレスで招待する（{"email": "..."}）
+// メールアドレスが登録済みかどうかに関わらず 202 で承認待ちの招待を返す
+func (cac *coachAthleteController) InviteAthlete(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	req := model.CoachInvitationRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	coachAthleteRes, err := cac.cau.InviteAthlete(userClaims.UserID, req.Email)
+	if err != nil {
+		logger.Error("InviteAthlete error: %v", err)
+		if errors.Is(err, usecase.ErrInviteeEmail) || errors.Is(err, usecase.ErrSelfInvitation) {
+			return c.JSON(http.StatusBadRequest, err.Error())
+		}
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusAccepted, coachAthleteRes)
+}
+
+// GetCoachLinks はコーチとしての選手一覧と、選手としてのコーチ一覧（招待を含む）を返す
+func (cac *coachAthleteController) GetCoachLinks(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	coachLinksRes, err := cac.cau.GetCoachLinks(userClaims.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, coachLinksRes)
+}
+
+// AcceptInvitation は選手としてコーチの招待を承認する
+func (cac *coachAthleteController) AcceptInvitation(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	coachAthleteId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid id")
+	}
+
+	coachAthleteRes, err := cac.cau.AcceptInvitation(userClaims.UserID, uint(coachAthleteId))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, "invitation not found")
+		}
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, coachAthleteRes)
+}
+
+// DeleteCoachAthlete はつながりを解除する（招待の取り消し・辞退を含む）
+func (cac *coachAthleteController) DeleteCoachAthlete(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	coachAthleteId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid id")
+	}
+
+	if err := cac.cau.DeleteCoachAthlete(userClaims.UserID, uint(coachAthleteId)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, "coach link not found")
+		}
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusOK)
+}
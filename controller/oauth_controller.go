@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"errors"
+	"go_vdot_api/middleware"
+	"go_vdot_api/model"
+	"go_vdot_api/pkg/logger"
+	"go_vdot_api/pkg/oauth"
+	"go_vdot_api/usecase"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type IOAuthController interface {
+	LogIn(c echo.Context) error
+	Callback(c echo.Context) error
+	Link(c echo.Context) error
+	GetIdentities(c echo.Context) error
+	Unlink(c echo.Context) error
+}
+
+type oauthController struct {
+	ou usecase.IOAuthUsecase
+}
+
+func NewOAuthController(ou usecase.IOAuthUsecase) IOAuthController {
+	return &oauthController{ou}
+}
+
+// 認可画面に送る前に保存し、コールバックで確認する Cookie
+const (
+	oauthStateCookie     = "oauth_state"
+	oauthStateCookiePath = "/api/auth/oauth"
+)
+
+// LogIn は外部のサービスの認可画面にリダイレクトする（ブラウザで開く）
+func (oc *oauthController) LogIn(c echo.Context) error {
+	return oc.redirectToProvider(c, 0)
+}
+
+// Link はログイン中のユーザーにアカウントを紐づけるため、認可画面にリダイレクトする（プロフィールから開く）
+func (oc *oauthController) Link(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+	return oc.redirectToProvider(c, userClaims.UserID)
+}
+
+func (oc *oauthController) redirectToProvider(c echo.Context, linkUserId uint) error {
+	authURL, stateToken, err := oc.ou.StartLogin(c.Param("provider"), linkUserId)
+	if err != nil {
+		if errors.Is(err, oauth.ErrUnknownProvider) {
+			return c.JSON(http.StatusNotFound, err.Error())
+		}
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	c.SetCookie(newTokenCookie(oauthStateCookie, stateToken, time.Now().Add(usecase.OAuthStateTTL), oauthStateCookiePath))
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback は認可画面から戻ったリクエストを処理し、フロントエンドの /oauth/callback にリダイレクトする
+// 結果は status（logged_in / signed_up / linked / error）と provider、エラーの場合は message のクエリで渡す
+// Apple は form_post のため POST で呼ばれる
+func (oc *oauthController) Callback(c echo.Context) error {
+	provider := c.Param("provider")
+	stateToken := ""
+	if cookie, err := c.Cookie(oauthStateCookie); err == nil {
+		stateToken = cookie.Value
+	}
+	c.SetCookie(newTokenCookie(oauthStateCookie, "", time.Now(), oauthStateCookiePath))
+
+	if errCode := c.FormValue("error"); errCode != "" {
+		// 認可画面でキャンセルした場合など
+		return redirectOAuthResult(c, url.Values{"status": {"error"}, "provider": {provider}, "message": {errCode}})
+	}
+
+	result, err := oc.ou.CompleteLogin(c.Request().Context(), provider, stateToken, c.FormValue("state"), c.FormValue("code"), c.Request().UserAgent(), c.RealIP())
+	if err != nil {
+		logger.Warn("oauth callback failed: provider=%s %v", provider, err)
+		message := err.Error()
+		if !isOAuthUserError(err) {
+			message = "login failed"
+		}
+		return redirectOAuthResult(c, url.Values{"status": {"error"}, "provider": {provider}, "message": {message}})
+	}
+	if result.Status != model.OAuthLinked {
+		setTokenCookies(c, result.Tokens)
+	}
+	return redirectOAuthResult(c, url.Values{"status": {result.Status}, "provider": {provider}})
+}
+
+// isOAuthUserError はフロントエンドにそのまま表示できるエラーか返す（通信のエラーなどは表示しない）
+func isOAuthUserError(err error) bool {
+	for _, target := range []error{oauth.ErrUnknownProvider, usecase.ErrInvalidOAuthState, usecase.ErrIdentityLinked, usecase.ErrProviderLinked, usecase.ErrEmailInUse, usecase.ErrEmailRequired, usecase.ErrAccountDisabled} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func redirectOAuthResult(c echo.Context, query url.Values) error {
+	return c.Redirect(http.StatusFound, strings.TrimRight(os.Getenv("FE_URL"), "/")+"/oauth/callback?"+query.Encode())
+}
+
+// GetIdentities は紐づけたアカウントと、ログインに使えるプロバイダーを返す
+func (oc *oauthController) GetIdentities(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+	identitiesRes, err := oc.ou.GetIdentities(userClaims.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, identitiesRes)
+}
+
+func (oc *oauthController) Unlink(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+	if err := oc.ou.UnlinkIdentity(userClaims.UserID, c.Param("provider")); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, "identity not found")
+		}
+		if errors.Is(err, usecase.ErrLastLoginMethod) {
+			return c.JSON(http.StatusConflict, err.Error())
+		}
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusOK)
+}
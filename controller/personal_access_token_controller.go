@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"errors"
+	"go_vdot_api/middleware"
+	"go_vdot_api/model"
+	"go_vdot_api/usecase"
+	"net/http"
+	"strconv"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+type IPersonalAccessTokenController interface {
+	CreateToken(c echo.Context) error
+	GetTokens(c echo.Context) error
+	RevokeToken(c echo.Context) error
+}
+
+type personalAccessTokenController struct {
+	patu usecase.IPersonalAccessTokenUsecase
+}
+
+func NewPersonalAccessTokenController(patu usecase.IPersonalAccessTokenUsecase) IPersonalAccessTokenController {
+	return &personalAccessTokenController{patu}
+}
+
+// CreateToken はトークンを作る（例：{"name": "watch sync", "scopes": ["read", "write"], "expires_in_days": 90}）
+// token はこのレスポンスでのみ返す
+func (patc *personalAccessTokenController) CreateToken(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	req := model.PersonalAccessTokenRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	tokenRes, err := patc.patu.CreateToken(userClaims.UserID, req)
+	if err != nil {
+		var validationErrs validation.Errors
+		if errors.As(err, &validationErrs) {
+			return c.JSON(http.StatusBadRequest, err.Error())
+		}
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusCreated, tokenRes)
+}
+
+func (patc *personalAccessTokenController) GetTokens(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+	tokensRes, err := patc.patu.GetTokens(userClaims.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, tokensRes)
+}
+
+// RevokeToken はトークンを無効にする（すぐに使えなくなる）
+func (patc *personalAccessTokenController) RevokeToken(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+	tokenId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid id")
+	}
+	if err := patc.patu.RevokeToken(userClaims.UserID, uint(tokenId)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, "access token not found")
+		}
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusOK)
+}
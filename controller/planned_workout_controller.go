@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"go_vdot_api/middleware"
+	"go_vdot_api/model"
+	"go_vdot_api/pkg/logger"
+	"go_vdot_api/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type IPlannedWorkoutController interface {
+	CreatePlannedWorkout(c echo.Context) error
+	GetPlannedWorkoutById(c echo.Context) error
+	UpdatePlannedWorkout(c echo.Context) error
+	DeletePlannedWorkout(c echo.Context) error
+	GetCalendar(c echo.Context) error
+}
+
+type plannedWorkoutController struct {
+	pwu usecase.IPlannedWorkoutUsecase
+}
+
+func NewPlannedWorkoutController(pwu usecase.IPlannedWorkoutUsecase) IPlannedWorkoutController {
+	return &plannedWorkoutController{pwu}
+}
+
+func (pwc *plannedWorkoutController) CreatePlannedWorkout(c echo.Context) error {
+	userId, err := middleware.GetTargetUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	plannedWorkout := model.PlannedWorkout{}
+	if err := c.Bind(&plannedWorkout); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	plannedWorkout.UserId = userId
+
+	plannedWorkoutRes, err := pwc.pwu.CreatePlannedWorkout(plannedWorkout)
+	if err != nil {
+		logger.Error("CreatePlannedWorkout error: %v", err)
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusCreated, plannedWorkoutRes)
+}
+
+// GetPlannedWorkoutById は予定と、対応する練習記録との一致度を返す
+func (pwc *plannedWorkoutController) GetPlannedWorkoutById(c echo.Context) error {
+	userId, err := middleware.GetTargetUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	plannedWorkoutId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid id")
+	}
+
+	plannedWorkoutRes, err := pwc.pwu.GetPlannedWorkoutById(userId, uint(plannedWorkoutId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, plannedWorkoutRes)
+}
+
+func (pwc *plannedWorkoutController) UpdatePlannedWorkout(c echo.Context) error {
+	userId, err := middleware.GetTargetUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	plannedWorkout := model.PlannedWorkout{}
+	if err := c.Bind(&plannedWorkout); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	plannedWorkoutId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid id")
+	}
+
+	plannedWorkoutRes, err := pwc.pwu.UpdatePlannedWorkout(plannedWorkout, userId, uint(plannedWorkoutId))
+	if err != nil {
+		logger.Error("UpdatePlannedWorkout error: %v", err)
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, plannedWorkoutRes)
+}
+
+func (pwc *plannedWorkoutController) DeletePlannedWorkout(c echo.Context) error {
+	userId, err := middleware.GetTargetUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	plannedWorkoutId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid id")
+	}
+
+	if err := pwc.pwu.DeletePlannedWorkout(userId, uint(plannedWorkoutId)); err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// GetCalendar は1か月分の予定と練習記録を返す（例：?year=2024&month=5）
+func (pwc *plannedWorkoutController) GetCalendar(c echo.Context) error {
+	userId, err := middleware.GetTargetUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	year, err := strconv.Atoi(c.QueryParam("year"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid year format")
+	}
+	month, err := strconv.Atoi(c.QueryParam("month"))
+	if err != nil || month < 1 || month > 12 {
+		return c.JSON(http.StatusBadRequest, "invalid month format")
+	}
+
+	calendarRes, err := pwc.pwu.GetCalendar(userId, year, month)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, calendarRes)
+}
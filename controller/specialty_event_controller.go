@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"go_vdot_api/middleware"
 	"go_vdot_api/model"
 	"go_vdot_api/pkg/logger"
@@ -14,7 +15,10 @@ import (
 type ISpecialtyEventController interface {
 	CreateSpecialtyEvent(c echo.Context) error
 	GetSpecialtyEvent(c echo.Context) error
+	GetSpecialtyEventSummary(c echo.Context) error
 	UpdateSpecialtyEvent(c echo.Context) error
+	ImportSpecialtyEvents(c echo.Context) error
+	ExportSpecialtyEvents(c echo.Context) error
 }
 
 type specialtyEventController struct {
@@ -47,13 +51,13 @@ func (sec *specialtyEventController) CreateSpecialtyEvent(c echo.Context) error
 }
 
 func (sec *specialtyEventController) GetSpecialtyEvent(c echo.Context) error {
-	userClaims, err := middleware.GetUserClaims(c)
+	userId, err := middleware.GetTargetUserID(c)
 	if err != nil {
 		logger.Error("GetUserClaims error: %v", err)
 		return c.JSON(http.StatusUnauthorized, err.Error())
 	}
 
-	specialtyEvents, err := sec.seu.GetSpecialtyEvent(userClaims.UserID)
+	specialtyEvents, err := sec.seu.GetSpecialtyEvent(userId)
 	if err != nil {
 		logger.Error("GetSpecialtyEvent error: %v", err)
 		return c.JSON(http.StatusInternalServerError, err.Error())
@@ -61,6 +65,24 @@ func (sec *specialtyEventController) GetSpecialtyEvent(c echo.Context) error {
 	return c.JSON(http.StatusOK, specialtyEvents)
 }
 
+func (sec *specialtyEventController) GetSpecialtyEventSummary(c echo.Context) error {
+	userId, err := middleware.GetTargetUserID(c)
+	if err != nil {
+		logger.Error("GetUserClaims error: %v", err)
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	summary, err := sec.seu.GetSpecialtyEventSummary(userId)
+	if err != nil {
+		if errors.Is(err, usecase.ErrNoSpecialtyEventVdot) {
+			return c.JSON(http.StatusNotFound, err.Error())
+		}
+		logger.Error("GetSpecialtyEventSummary error: %v", err)
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, summary)
+}
+
 func (sec *specialtyEventController) UpdateSpecialtyEvent(c echo.Context) error {
 	userClaims, err := middleware.GetUserClaims(c)
 	if err != nil {
@@ -87,3 +109,45 @@ func (sec *specialtyEventController) UpdateSpecialtyEvent(c echo.Context) error
 	}
 	return c.JSON(http.StatusOK, specialtyEventRes)
 }
+
+// ImportSpecialtyEvents は専門種目を CSV（multipart の file または Content-Type: text/csv の本文）から一括で取り込む
+// all_or_nothing=true の場合は1行でも失敗すると何も保存しない
+func (sec *specialtyEventController) ImportSpecialtyEvents(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	_, data, err := readUpload(c)
+	if err != nil {
+		return c.JSON(uploadErrorStatus(err), err.Error())
+	}
+	allOrNothing, err := parseAllOrNothing(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	importRes, err := sec.seu.ImportSpecialtyEventsCSV(userClaims.UserID, data, allOrNothing)
+	if err != nil {
+		logger.Error("ImportSpecialtyEventsCSV error: %v", err)
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(csvImportStatus(importRes), importRes)
+}
+
+// ExportSpecialtyEvents は専門種目を CSV で返す（?format=csv）
+func (sec *specialtyEventController) ExportSpecialtyEvents(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+	if err := checkExportFormat(c); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	data, err := sec.seu.ExportSpecialtyEventsCSV(userClaims.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return sendCSV(c, "specialty_events.csv", data)
+}
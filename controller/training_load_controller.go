@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"fmt"
+	"go_vdot_api/middleware"
+	"go_vdot_api/pkg/logger"
+	"go_vdot_api/usecase"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ITrainingLoadController interface {
+	GetTrainingLoad(c echo.Context) error
+}
+
+type trainingLoadController struct {
+	tlu usecase.ITrainingLoadUsecase
+}
+
+func NewTrainingLoadController(tlu usecase.ITrainingLoadUsecase) ITrainingLoadController {
+	return &trainingLoadController{tlu}
+}
+
+// GetTrainingLoad は日ごとのトレーニング負荷を返す（例：?from=2024-01-01&to=2024-03-31）
+// to を省略した場合は今日まで、from を省略した場合は to の27日前から。
+// VDOTの選び方（rule, days）とゾーン定義（profile）は /api/vdots/value と同じ。
+// acwr_high, acwr_low, monotony_high, strain_high で警告のしきい値を変更できる。
+func (tlc *trainingLoadController) GetTrainingLoad(c echo.Context) error {
+	userId, err := middleware.GetTargetUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	to := time.Now().Truncate(24 * time.Hour)
+	if toStr := c.QueryParam("to"); toStr != "" {
+		to, err = time.Parse("2006-01-02", toStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, "invalid to format")
+		}
+	}
+	from := to.AddDate(0, 0, -(usecase.ChronicLoadDays - 1))
+	if fromStr := c.QueryParam("from"); fromStr != "" {
+		from, err = time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, "invalid from format")
+		}
+	}
+
+	rule, days, err := bindVdotRule(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	thresholds := usecase.TrainingLoadThresholds{}
+	for name, field := range map[string]*float64{
+		"acwr_high":     &thresholds.AcwrHigh,
+		"acwr_low":      &thresholds.AcwrLow,
+		"monotony_high": &thresholds.MonotonyHigh,
+		"strain_high":   &thresholds.StrainHigh,
+	} {
+		str := c.QueryParam(name)
+		if str == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(str, 64)
+		if err != nil || value <= 0 {
+			return c.JSON(http.StatusBadRequest, fmt.Sprintf("invalid %s format", name))
+		}
+		*field = value
+	}
+
+	loadRes, err := tlc.tlu.GetTrainingLoad(userId, from, to, rule, days, c.QueryParam("profile"), thresholds)
+	if err != nil {
+		logger.Error("GetTrainingLoad error: %v", err)
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, loadRes)
+}
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"go_vdot_api/middleware"
+	"go_vdot_api/model"
+	"go_vdot_api/pkg/logger"
+	"go_vdot_api/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ITrainingPlanController interface {
+	CreateTrainingPlan(c echo.Context) error
+	GetTrainingPlans(c echo.Context) error
+	GetTrainingPlanById(c echo.Context) error
+	RegenerateTrainingPlan(c echo.Context) error
+	DeleteTrainingPlan(c echo.Context) error
+}
+
+type trainingPlanController struct {
+	tpu usecase.ITrainingPlanUsecase
+}
+
+func NewTrainingPlanController(tpu usecase.ITrainingPlanUsecase) ITrainingPlanController {
+	return &trainingPlanController{tpu}
+}
+
+// CreateTrainingPlan は目標レースと現在の走行距離から練習計画を生成して保存する
+func (tpc *trainingPlanController) CreateTrainingPlan(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	trainingPlan := model.TrainingPlan{}
+	if err := c.Bind(&trainingPlan); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	trainingPlan.UserId = userClaims.UserID
+
+	trainingPlanRes, err := tpc.tpu.CreateTrainingPlan(trainingPlan)
+	if err != nil {
+		logger.Error("CreateTrainingPlan error: %v", err)
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusCreated, trainingPlanRes)
+}
+
+func (tpc *trainingPlanController) GetTrainingPlans(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	trainingPlansRes, err := tpc.tpu.GetTrainingPlans(userClaims.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, trainingPlansRes)
+}
+
+func (tpc *trainingPlanController) GetTrainingPlanById(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	trainingPlanId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid id")
+	}
+
+	trainingPlanRes, err := tpc.tpu.GetTrainingPlanById(userClaims.UserID, uint(trainingPlanId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, trainingPlanRes)
+}
+
+// RegenerateTrainingPlan は現在のVDOTで練習計画を作り直す
+func (tpc *trainingPlanController) RegenerateTrainingPlan(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	trainingPlanId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid id")
+	}
+
+	trainingPlanRes, err := tpc.tpu.RegenerateTrainingPlan(userClaims.UserID, uint(trainingPlanId))
+	if err != nil {
+		logger.Error("RegenerateTrainingPlan error: %v", err)
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, trainingPlanRes)
+}
+
+func (tpc *trainingPlanController) DeleteTrainingPlan(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	trainingPlanId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid id")
+	}
+
+	if err := tpc.tpu.DeleteTrainingPlan(userClaims.UserID, uint(trainingPlanId)); err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusOK)
+}
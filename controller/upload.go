@@ -0,0 +1,100 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"go_vdot_api/model"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// 取り込むファイルの最大サイズ
+const maxUploadFileSize = 20 << 20
+
+var errUploadTooLarge = errors.New("file is too large")
+
+// readUpload は multipart の file、または本文（Content-Type: text/csv）のファイル名と中身を返す
+func readUpload(c echo.Context) (string, []byte, error) {
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), "text/csv") {
+		data, err := io.ReadAll(io.LimitReader(c.Request().Body, maxUploadFileSize+1))
+		if err != nil {
+			return "", nil, err
+		}
+		if len(data) > maxUploadFileSize {
+			return "", nil, errUploadTooLarge
+		}
+		return "upload.csv", data, nil
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return "", nil, errors.New("file is required")
+	}
+	if fileHeader.Size > maxUploadFileSize {
+		return "", nil, errUploadTooLarge
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxUploadFileSize+1))
+	if err != nil {
+		return "", nil, err
+	}
+	if len(data) > maxUploadFileSize {
+		return "", nil, errUploadTooLarge
+	}
+	return fileHeader.Filename, data, nil
+}
+
+func uploadErrorStatus(err error) int {
+	if errors.Is(err, errUploadTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+func isCSVFile(filename string) bool {
+	return strings.ToLower(filepath.Ext(filename)) == ".csv"
+}
+
+// parseAllOrNothing は all_or_nothing（フォームまたはクエリ）を読む。未指定の場合は false
+func parseAllOrNothing(c echo.Context) (bool, error) {
+	s := c.FormValue("all_or_nothing")
+	if s == "" {
+		return false, nil
+	}
+	allOrNothing, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, errors.New("invalid all_or_nothing format")
+	}
+	return allOrNothing, nil
+}
+
+// csvImportStatus は取り込みの結果に応じたステータスを返す
+// all_or_nothing で失敗した行があり、1行も保存しなかった場合は 422
+func csvImportStatus(res model.CSVImportResponse) int {
+	if res.AllOrNothing && res.Failed > 0 {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusOK
+}
+
+// checkExportFormat は format（未指定の場合は csv）が対応しているかチェックする
+func checkExportFormat(c echo.Context) error {
+	if format := c.QueryParam("format"); format != "" && format != "csv" {
+		return errors.New("unsupported format. Use csv")
+	}
+	return nil
+}
+
+func sendCSV(c echo.Context, filename string, data []byte) error {
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	return c.Blob(http.StatusOK, "text/csv; charset=utf-8", data)
+}
@@ -1,25 +1,39 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"go_vdot_api/middleware"
 	"go_vdot_api/model"
 	"go_vdot_api/pkg/logger"
 	"go_vdot_api/usecase"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
+	validation "github.com/go-ozzo/ozzo-validation/v4"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 type IUserController interface {
 	SignUp(c echo.Context) error
 	LogIn(c echo.Context) error
 	LogOut(c echo.Context) error
+	Refresh(c echo.Context) error
 	CsrfToken(c echo.Context) error
+	GetSessions(c echo.Context) error
+	RevokeSession(c echo.Context) error
+	VerifyEmail(c echo.Context) error
+	ResendVerificationEmail(c echo.Context) error
+	ForgotPassword(c echo.Context) error
+	ResetPassword(c echo.Context) error
 
 	UpdateUser(c echo.Context) error
+	SendAccountDeletionEmail(c echo.Context) error
 	DeleteUser(c echo.Context) error
+	ExportAccount(c echo.Context) error
 }
 
 type userController struct {
@@ -48,34 +62,116 @@ func (uc *userController) LogIn(c echo.Context) error {
 	if err := c.Bind(&user); err != nil {
 		return c.JSON(http.StatusBadRequest, err.Error())
 	}
-	tokenString, err := uc.uu.LogIn(user)
+	tokens, err := uc.uu.LogIn(user, c.Request().UserAgent(), c.RealIP())
 	if err != nil {
+		var validationErrs validation.Errors
+		switch {
+		case errors.Is(err, usecase.ErrInvalidCredentials):
+			return c.JSON(http.StatusUnauthorized, err.Error())
+		case errors.Is(err, usecase.ErrAccountDisabled):
+			return c.JSON(http.StatusForbidden, err.Error())
+		case errors.As(err, &validationErrs):
+			return c.JSON(http.StatusBadRequest, err.Error())
+		}
+		logger.Error("LogIn error: %v", err)
+		return c.JSON(http.StatusInternalServerError, "login failed")
+	}
+	setTokenCookies(c, tokens)
+	return c.NoContent(http.StatusOK)
+}
+
+// Refresh は refresh_token の Cookie を新しいものに交換し、アクセストークンを発行し直す
+func (uc *userController) Refresh(c echo.Context) error {
+	refreshToken := ""
+	if cookie, err := c.Cookie(refreshTokenCookie); err == nil {
+		refreshToken = cookie.Value
+	}
+	tokens, err := uc.uu.Refresh(refreshToken, c.Request().UserAgent(), c.RealIP())
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidRefreshToken) || errors.Is(err, usecase.ErrAccountDisabled) {
+			clearTokenCookie(c)
+			return c.JSON(http.StatusUnauthorized, err.Error())
+		}
+		logger.Error("Refresh error: %v", err)
 		return c.JSON(http.StatusInternalServerError, err.Error())
 	}
-	cookie := new(http.Cookie)
-	cookie.Name = "token"
-	cookie.Value = tokenString
-	cookie.Expires = time.Now().Add(24 * time.Hour)
-	cookie.Path = "/"
-	cookie.Domain = os.Getenv("API_DOMAIN")
-	cookie.Secure = true
-	cookie.HttpOnly = true
-	cookie.SameSite = http.SameSiteNoneMode
-	c.SetCookie(cookie)
+	setTokenCookies(c, tokens)
 	return c.NoContent(http.StatusOK)
 }
 
+// LogOut は refresh_token のセッションを無効にして Cookie を削除する
 func (uc *userController) LogOut(c echo.Context) error {
+	if cookie, err := c.Cookie(refreshTokenCookie); err == nil {
+		if err := uc.uu.LogOut(cookie.Value); err != nil {
+			logger.Error("LogOut error: %v", err)
+			return c.JSON(http.StatusInternalServerError, err.Error())
+		}
+	}
+	clearTokenCookie(c)
+	return c.NoContent(http.StatusOK)
+}
+
+// リフレッシュトークンの Cookie は更新・ログアウトのエンドポイントにのみ送る
+const (
+	refreshTokenCookie     = "refresh_token"
+	refreshTokenCookiePath = "/api/auth"
+)
+
+func setTokenCookies(c echo.Context, tokens model.AuthTokens) {
+	c.SetCookie(newTokenCookie("token", tokens.AccessToken, tokens.AccessExpiresAt, "/"))
+	c.SetCookie(newTokenCookie(refreshTokenCookie, tokens.RefreshToken, tokens.RefreshExpiresAt, refreshTokenCookiePath))
+}
+
+func clearTokenCookie(c echo.Context) {
+	c.SetCookie(newTokenCookie("token", "", time.Now(), "/"))
+	c.SetCookie(newTokenCookie(refreshTokenCookie, "", time.Now(), refreshTokenCookiePath))
+}
+
+func newTokenCookie(name string, value string, expires time.Time, path string) *http.Cookie {
 	cookie := new(http.Cookie)
-	cookie.Name = "token"
-	cookie.Value = ""
-	cookie.Expires = time.Now()
-	cookie.Path = "/"
+	cookie.Name = name
+	cookie.Value = value
+	cookie.Expires = expires
+	cookie.Path = path
 	cookie.Domain = os.Getenv("API_DOMAIN")
 	cookie.Secure = true
 	cookie.HttpOnly = true
 	cookie.SameSite = http.SameSiteNoneMode
-	c.SetCookie(cookie)
+	return cookie
+}
+
+// GetSessions はログイン中のセッション（端末・IP・最後に使われた日時）の一覧を返す
+func (uc *userController) GetSessions(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+	sessionsRes, err := uc.uu.GetSessions(userClaims.UserID, userClaims.SessionID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, sessionsRes)
+}
+
+// RevokeSession はセッションを無効にする（そのセッションのトークンはすぐに使えなくなる）
+func (uc *userController) RevokeSession(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+	sessionId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid id")
+	}
+	if err := uc.uu.RevokeSession(userClaims.UserID, uint(sessionId)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, "session not found")
+		}
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	if uint(sessionId) == userClaims.SessionID {
+		clearTokenCookie(c)
+	}
 	return c.NoContent(http.StatusOK)
 }
 
@@ -86,6 +182,72 @@ func (uc *userController) CsrfToken(c echo.Context) error {
 	})
 }
 
+// VerifyEmail は確認メールのトークン（{"token": "..."}）でメールアドレスを確認済みにする
+func (uc *userController) VerifyEmail(c echo.Context) error {
+	req := model.EmailVerificationRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if err := uc.uu.VerifyEmail(req.Token); err != nil {
+		if errors.Is(err, usecase.ErrInvalidToken) {
+			return c.JSON(http.StatusBadRequest, err.Error())
+		}
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// ResendVerificationEmail はログイン中のユーザーに確認メールを送り直す
+func (uc *userController) ResendVerificationEmail(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+	if err := uc.uu.SendVerificationEmail(userClaims.UserID); err != nil {
+		if errors.Is(err, usecase.ErrEmailAlreadyVerified) {
+			return c.JSON(http.StatusConflict, err.Error())
+		}
+		logger.Error("SendVerificationEmail error: %v", err)
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusAccepted)
+}
+
+// ForgotPassword はパスワードの再設定のメールを送る
+// 登録されていないメールアドレスでも同じレスポンスを返す
+func (uc *userController) ForgotPassword(c echo.Context) error {
+	req := model.PasswordForgotRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if req.Email == "" {
+		return c.JSON(http.StatusBadRequest, "email is required")
+	}
+	if err := uc.uu.ForgotPassword(req.Email); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	return c.NoContent(http.StatusAccepted)
+}
+
+// ResetPassword は再設定のメールのトークンで新しいパスワード（{"token": "...", "password": "..."}）を設定する
+// すべてのセッションが無効になるため、新しいパスワードでログインし直してもらう
+func (uc *userController) ResetPassword(c echo.Context) error {
+	req := model.PasswordResetRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if err := uc.uu.ResetPassword(req.Token, req.Password); err != nil {
+		var validationErr validation.Error
+		if errors.Is(err, usecase.ErrInvalidToken) || errors.As(err, &validationErr) {
+			return c.JSON(http.StatusBadRequest, err.Error())
+		}
+		logger.Error("ResetPassword error: %v", err)
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	clearTokenCookie(c)
+	return c.NoContent(http.StatusOK)
+}
+
 func (uc *userController) UpdateUser(c echo.Context) error {
 	logger.Info("テスト")
 	userClaims, err := middleware.GetUserClaims(c)
@@ -99,22 +261,87 @@ func (uc *userController) UpdateUser(c echo.Context) error {
 	}
 
 	userData.ID = userClaims.UserID
-	userRes, err := uc.uu.UpdateUser(userData)
+	userRes, err := uc.uu.UpdateUser(userData, userClaims.SessionID)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, err.Error())
 	}
-	logger.Info("User updated successfully", userData)
+	logger.Info("User updated successfully: user_id=%d", userData.ID)
 	return c.JSON(http.StatusOK, userRes)
 }
 
+// SendAccountDeletionEmail はアカウント削除の確認メールを送る（パスワードのないユーザーの本人確認に使う）
+func (uc *userController) SendAccountDeletionEmail(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+	if err := uc.uu.SendAccountDeletionEmail(userClaims.UserID); err != nil {
+		logger.Error("SendAccountDeletionEmail error: %v", err)
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusAccepted)
+}
+
+// DeleteUser は本人のパスワード（{"password": "..."}）または確認メールのトークン（{"token": "..."}）を確認して
+// アカウントとすべてのデータを削除し、削除した件数を返す
 func (uc *userController) DeleteUser(c echo.Context) error {
 	userClaims, err := middleware.GetUserClaims(c)
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, err.Error())
 	}
 
-	if err := uc.uu.DeleteUser(userClaims.UserID); err != nil {
+	req := model.AccountDeletionRequest{}
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if req.Password == "" && req.Token == "" {
+		return c.JSON(http.StatusBadRequest, "password or token is required")
+	}
+
+	deletionRes, err := uc.uu.DeleteUser(userClaims.UserID, req.Password, req.Token, c.RealIP())
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidPassword) || errors.Is(err, usecase.ErrPasswordNotSet) || errors.Is(err, usecase.ErrInvalidToken) {
+			return c.JSON(http.StatusForbidden, err.Error())
+		}
+		logger.Error("DeleteUser error: %v", err)
 		return c.JSON(http.StatusInternalServerError, err.Error())
 	}
-	return c.NoContent(http.StatusOK)
+	clearTokenCookie(c)
+	return c.JSON(http.StatusOK, deletionRes)
+}
+
+// ExportAccount はプロフィールとユーザーのすべてのデータを返す
+// format=zip（既定）は account.json と CSV をまとめた ZIP、format=json は JSON のみ
+func (uc *userController) ExportAccount(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		format = "zip"
+	}
+	if format != "zip" && format != "json" {
+		return c.JSON(http.StatusBadRequest, "unsupported format. Use zip or json")
+	}
+
+	exportRes, err := uc.uu.ExportAccount(userClaims.UserID)
+	if err != nil {
+		logger.Error("ExportAccount error: %v", err)
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+
+	filename := fmt.Sprintf("account-%d-%s.%s", userClaims.UserID, exportRes.ExportedAt.Format("20060102"), format)
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	if format == "json" {
+		return c.JSON(http.StatusOK, exportRes)
+	}
+	c.Response().Header().Set(echo.HeaderContentType, "application/zip")
+	c.Response().WriteHeader(http.StatusOK)
+	// ヘッダーを送った後のためエラーはログにのみ残す
+	if err := usecase.WriteAccountArchive(c.Response(), exportRes); err != nil {
+		logger.Error("WriteAccountArchive error: %v", err)
+	}
+	return nil
 }
@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"errors"
 	"go_vdot_api/middleware"
 	"go_vdot_api/model"
 	"go_vdot_api/pkg/logger"
@@ -11,13 +12,23 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-
-
 type IVdotController interface {
 	CreateVdot(c echo.Context) error
 	GetVdot(c echo.Context) error
+	GetVdotHistory(c echo.Context) error
 	UpdateVdot(c echo.Context) error
+	PinVdot(c echo.Context) error
+	UnpinVdot(c echo.Context) error
 	GetUserVdotValue(c echo.Context) error
+	CalculateVdot(c echo.Context) error
+	GetPaceZonesByVdot(c echo.Context) error
+	ImportVdots(c echo.Context) error
+	ExportVdots(c echo.Context) error
+
+	// /api/v2 向け（型付きのレスポンスを返す）
+	GetUserVdotValueV2(c echo.Context) error
+	CalculateVdotV2(c echo.Context) error
+	GetPaceZonesByVdotV2(c echo.Context) error
 }
 
 type vdotController struct {
@@ -48,19 +59,32 @@ func (vc *vdotController) CreateVdot(c echo.Context) error {
 }
 
 func (vc *vdotController) GetVdot(c echo.Context) error {
-	userClaims, err := middleware.GetUserClaims(c)
+	userId, err := middleware.GetTargetUserID(c)
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, err.Error())
 	}
 
-	vdotRes, err := vc.vu.GetVdot(userClaims.UserID)
+	vdotRes, err := vc.vu.GetVdot(userId)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, err.Error())
 	}
-	logger.Info("vdotRes", "vdotRes", vdotRes)
+	logger.Info("vdotRes: %+v", vdotRes)
 	return c.JSON(http.StatusOK, vdotRes)
 }
 
+func (vc *vdotController) GetVdotHistory(c echo.Context) error {
+	userId, err := middleware.GetTargetUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	vdotsRes, err := vc.vu.GetVdotHistory(userId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, vdotsRes)
+}
+
 func (vc *vdotController) UpdateVdot(c echo.Context) error {
 	logger.Info("UpdateVdot")
 	userClaims, err := middleware.GetUserClaims(c)
@@ -69,7 +93,7 @@ func (vc *vdotController) UpdateVdot(c echo.Context) error {
 	}
 
 	id := c.Param("id")
-	logger.Info("id", "id", id)
+	logger.Info("id: %s", id)
 	vdotId, err := strconv.Atoi(id)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, "Invalid ID")
@@ -80,27 +104,232 @@ func (vc *vdotController) UpdateVdot(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, err.Error())
 	}
 	vdot.UserId = userClaims.UserID
-	logger.Info("vdot", "vdot", vdot)
-	logger.Info("userClaims.UserID", "userClaims.UserID", userClaims.UserID)
-	
-	vdotRes, err := vc.vu.UpdateVdot(vdot , userClaims.UserID, uint(vdotId))
+	logger.Info("vdot: %+v", vdot)
+	logger.Info("userClaims.UserID: %d", userClaims.UserID)
+
+	vdotRes, err := vc.vu.UpdateVdot(vdot, userClaims.UserID, uint(vdotId))
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, err.Error())
 	}
-	logger.Info("vdotRes", "vdotRes", vdotRes)
+	logger.Info("vdotRes: %+v", vdotRes)
 	return c.JSON(http.StatusOK, vdotRes)
 }
 
-func (vc *vdotController) GetUserVdotValue(c echo.Context) error {
+func (vc *vdotController) PinVdot(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	vdotId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "Invalid ID")
+	}
+
+	if err := vc.vu.PinVdot(userClaims.UserID, uint(vdotId)); err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (vc *vdotController) UnpinVdot(c echo.Context) error {
 	userClaims, err := middleware.GetUserClaims(c)
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, err.Error())
 	}
 
-	result, err := vc.vu.GetUserVdotValue(userClaims.UserID)
+	if err := vc.vu.UnpinVdot(userClaims.UserID); err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+func (vc *vdotController) GetUserVdotValue(c echo.Context) error {
+	return vc.getUserVdotValue(c, false)
+}
+
+func (vc *vdotController) GetUserVdotValueV2(c echo.Context) error {
+	return vc.getUserVdotValue(c, true)
+}
+
+// CalculateVdot は記録を保存せずにVDOTを試算する（コーチの「もしも」計算用）
+func (vc *vdotController) CalculateVdot(c echo.Context) error {
+	return vc.calculateVdot(c, false)
+}
+
+func (vc *vdotController) CalculateVdotV2(c echo.Context) error {
+	return vc.calculateVdot(c, true)
+}
+
+// GetPaceZonesByVdot はVDOTの値を直接指定してペースゾーンを取得する（例：?vdot=52.5）
+func (vc *vdotController) GetPaceZonesByVdot(c echo.Context) error {
+	return vc.getPaceZonesByVdot(c, false)
+}
+
+func (vc *vdotController) GetPaceZonesByVdotV2(c echo.Context) error {
+	return vc.getPaceZonesByVdot(c, true)
+}
+
+func (vc *vdotController) getUserVdotValue(c echo.Context, v2 bool) error {
+	userId, err := middleware.GetTargetUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	rule, days, err := bindVdotRule(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	opts, err := bindVdotOptions(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	result, err := vc.vu.GetUserVdotResult(userId, rule, days, opts)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, err.Error())
 	}
 
-	return c.JSON(http.StatusOK, result)
+	return respondVdotValue(c, result, v2)
+}
+
+func (vc *vdotController) calculateVdot(c echo.Context, v2 bool) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	vdot := model.Vdot{}
+	if err := c.Bind(&vdot); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	vdot.UserId = userClaims.UserID
+
+	opts, err := bindVdotOptions(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	result, err := vc.vu.CalculateVdotResult(vdot, opts)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	return respondVdotValue(c, result, v2)
+}
+
+func (vc *vdotController) getPaceZonesByVdot(c echo.Context, v2 bool) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	vdotValue, err := strconv.ParseFloat(c.QueryParam("vdot"), 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid vdot format")
+	}
+
+	opts, err := bindVdotOptions(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	result, err := vc.vu.CalculateResultByVdotValue(userClaims.UserID, vdotValue, opts)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	return respondVdotValue(c, result, v2)
+}
+
+// respondVdotValue は v2 なら型付きのレスポンスを、v1 なら既存クライアント向けの旧形式を返す
+func respondVdotValue(c echo.Context, result model.VdotValueResponse, v2 bool) error {
+	if v2 {
+		return c.JSON(http.StatusOK, result)
+	}
+	return c.JSON(http.StatusOK, usecase.LegacyVdotValue(result))
+}
+
+// bindVdotRule はVDOT算出に使う記録の選び方を読み取る
+// （rule: latest（デフォルト）, best, pinned / days: rule=best の対象期間（日数））
+func bindVdotRule(c echo.Context) (string, int, error) {
+	days := 0
+	if daysStr := c.QueryParam("days"); daysStr != "" {
+		var err error
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days <= 0 {
+			return "", 0, errors.New("invalid days format")
+		}
+	}
+	return c.QueryParam("rule"), days, nil
+}
+
+// bindVdotOptions はペースゾーンの出し方のクエリパラメータ（profile, training_temperature, training_elevation）を読み取る
+func bindVdotOptions(c echo.Context) (usecase.VdotOptions, error) {
+	training, err := bindTrainingConditions(c)
+	if err != nil {
+		return usecase.VdotOptions{}, err
+	}
+	return usecase.VdotOptions{Training: training, Profile: c.QueryParam("profile")}, nil
+}
+
+// bindTrainingConditions は練習環境のクエリパラメータ（training_temperature, training_elevation）を読み取る
+func bindTrainingConditions(c echo.Context) (usecase.Conditions, error) {
+	training := usecase.Conditions{}
+	if str := c.QueryParam("training_temperature"); str != "" {
+		temperature, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return usecase.Conditions{}, errors.New("invalid training_temperature format")
+		}
+		training.Temperature = &temperature
+	}
+	if str := c.QueryParam("training_elevation"); str != "" {
+		elevation, err := strconv.ParseFloat(str, 64)
+		if err != nil {
+			return usecase.Conditions{}, errors.New("invalid training_elevation format")
+		}
+		training.Elevation = &elevation
+	}
+	return training, nil
+}
+
+// ImportVdots は記録を CSV（multipart の file または Content-Type: text/csv の本文）から一括で取り込む
+// all_or_nothing=true の場合は1行でも失敗すると何も保存しない
+func (vc *vdotController) ImportVdots(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	_, data, err := readUpload(c)
+	if err != nil {
+		return c.JSON(uploadErrorStatus(err), err.Error())
+	}
+	allOrNothing, err := parseAllOrNothing(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	importRes, err := vc.vu.ImportVdotsCSV(userClaims.UserID, data, allOrNothing)
+	if err != nil {
+		logger.Error("ImportVdotsCSV error: %v", err)
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(csvImportStatus(importRes), importRes)
+}
+
+// ExportVdots は記録を CSV で返す（?format=csv）
+func (vc *vdotController) ExportVdots(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+	if err := checkExportFormat(c); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	data, err := vc.vu.ExportVdotsCSV(userClaims.UserID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return sendCSV(c, "vdots.csv", data)
 }
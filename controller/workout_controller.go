@@ -1,20 +1,30 @@
 package controller
 
 import (
+	"errors"
 	"go_vdot_api/middleware"
 	"go_vdot_api/model"
 	"go_vdot_api/pkg/logger"
 	"go_vdot_api/usecase"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 type IWorkoutController interface {
 	CreateWorkout(c echo.Context) error
+	GetWorkouts(c echo.Context) error
 	GetWorkoutPerMonth(c echo.Context) error
+	GetWorkoutById(c echo.Context) error
 	UpdateWorkout(c echo.Context) error
+	DeleteWorkout(c echo.Context) error
+	AnalyzeWorkout(c echo.Context) error
+	GetWorkoutStats(c echo.Context) error
+	ImportWorkout(c echo.Context) error
+	ExportWorkouts(c echo.Context) error
 }
 
 type workoutController struct {
@@ -29,29 +39,70 @@ func (wc *workoutController) CreateWorkout(c echo.Context) error {
 	logger.Info("CreateWorkout called")
 	userClaims, err := middleware.GetUserClaims(c)
 	if err != nil {
-		logger.Error("GetUserClaims error", err)
+		logger.Error("GetUserClaims error: %v", err)
 		return c.JSON(http.StatusUnauthorized, err.Error())
 	}
 
 	workout := model.Workout{}
 	if err := c.Bind(&workout); err != nil {
-		logger.Error("Bind error", err)
+		logger.Error("Bind error: %v", err)
 		return c.JSON(http.StatusBadRequest, err.Error())
 	}
-	logger.Info("workout", workout)
+	logger.Info("workout: %+v", workout)
 
 	workout.UserId = userClaims.UserID
 	workoutRes, err := wc.wu.CreateWorkout(workout)
 	if err != nil {
-		logger.Error("CreateWorkout error", err)
+		logger.Error("CreateWorkout error: %v", err)
 		return c.JSON(http.StatusInternalServerError, err.Error())
 	}
-	logger.Info("workoutRes", workoutRes)
+	logger.Info("workoutRes: %+v", workoutRes)
 	return c.JSON(http.StatusCreated, workoutRes)
 }
 
+// GetWorkouts は練習一覧を返す
+// year, month を指定した場合はその月の練習を、それ以外は from〜to の練習をページ単位で返す
+// （例：?from=2024-01-01&to=2024-03-31&sort=desc&limit=50&cursor=...）
+func (wc *workoutController) GetWorkouts(c echo.Context) error {
+	if c.QueryParam("year") != "" || c.QueryParam("month") != "" {
+		return wc.GetWorkoutPerMonth(c)
+	}
+
+	userId, err := middleware.GetTargetUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	var from, to time.Time
+	if fromStr := c.QueryParam("from"); fromStr != "" {
+		from, err = time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, "invalid from format")
+		}
+	}
+	if toStr := c.QueryParam("to"); toStr != "" {
+		to, err = time.Parse("2006-01-02", toStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, "invalid to format")
+		}
+	}
+	limit := 0
+	if limitStr := c.QueryParam("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return c.JSON(http.StatusBadRequest, "invalid limit")
+		}
+	}
+
+	workoutRes, err := wc.wu.GetWorkouts(userId, from, to, c.QueryParam("cursor"), c.QueryParam("sort"), limit)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, workoutRes)
+}
+
 func (wc *workoutController) GetWorkoutPerMonth(c echo.Context) error {
-	userClaims, err := middleware.GetUserClaims(c)
+	userId, err := middleware.GetTargetUserID(c)
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, err.Error())
 	}
@@ -68,8 +119,29 @@ func (wc *workoutController) GetWorkoutPerMonth(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, "invalid month format")
 	}	
 
-	workoutRes, err := wc.wu.GetWorkoutPerMonth(userClaims.UserID, year, month)
+	workoutRes, err := wc.wu.GetWorkoutPerMonth(userId, year, month)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, workoutRes)
+}
+
+func (wc *workoutController) GetWorkoutById(c echo.Context) error {
+	userId, err := middleware.GetTargetUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	workoutId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid workout ID")
+	}
+
+	workoutRes, err := wc.wu.GetWorkoutById(userId, uint(workoutId))
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, err.Error())
+		}
 		return c.JSON(http.StatusInternalServerError, err.Error())
 	}
 	return c.JSON(http.StatusOK, workoutRes)
@@ -96,4 +168,171 @@ func (wc *workoutController) UpdateWorkout(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, err.Error())
 	}
 	return c.JSON(http.StatusOK, workoutRes)
-}
\ No newline at end of file
+}
+
+func (wc *workoutController) DeleteWorkout(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	workoutId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid workout ID")
+	}
+
+	if err := wc.wu.DeleteWorkout(userClaims.UserID, uint(workoutId)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.JSON(http.StatusNotFound, err.Error())
+		}
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// AnalyzeWorkout はラップタイムを現在のVDOTのペースゾーンと比較する
+// （VDOTの選び方とゾーン定義は /api/vdots/value と同じクエリパラメータで指定できる）
+func (wc *workoutController) AnalyzeWorkout(c echo.Context) error {
+	userId, err := middleware.GetTargetUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	workoutId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid workout ID")
+	}
+
+	rule, days, err := bindVdotRule(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	opts, err := bindVdotOptions(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	analysisRes, err := wc.wu.AnalyzeWorkout(userId, uint(workoutId), rule, days, opts)
+	if err != nil {
+		logger.Error("AnalyzeWorkout error: %v", err)
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, analysisRes)
+}
+
+// GetWorkoutStats は週・月・年ごとの走行距離を集計する（例：?from=2024-01-01&to=2024-12-31&unit=km）
+// from を省略した場合は to の1年前から、to を省略した場合は今日までを集計する
+func (wc *workoutController) GetWorkoutStats(c echo.Context) error {
+	userId, err := middleware.GetTargetUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	to := time.Now()
+	if toStr := c.QueryParam("to"); toStr != "" {
+		to, err = time.Parse("2006-01-02", toStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, "invalid to format")
+		}
+	}
+	from := to.AddDate(-1, 0, 0)
+	if fromStr := c.QueryParam("from"); fromStr != "" {
+		from, err = time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, "invalid from format")
+		}
+	}
+
+	statsRes, err := wc.wu.GetWorkoutStats(userId, from, to, c.QueryParam("unit"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	return c.JSON(http.StatusOK, statsRes)
+}
+
+// ImportWorkout は練習をファイル（multipart の file）から取り込む
+// CSV（拡張子 .csv または Content-Type: text/csv）の場合は複数の練習を一括で取り込む（all_or_nothing=true で1行でも失敗すると何も保存しない）
+// GPX, TCX, FIT の場合は confirm=true で保存し、それ以外は保存せずに確認用の内容を返す
+// ファイルにない項目は workout, weather で、練習日・開始時刻のタイムゾーンは timezone（例：Asia/Tokyo）で指定する
+func (wc *workoutController) ImportWorkout(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	filename, data, err := readUpload(c)
+	if err != nil {
+		return c.JSON(uploadErrorStatus(err), err.Error())
+	}
+
+	if isCSVFile(filename) {
+		allOrNothing, err := parseAllOrNothing(c)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, err.Error())
+		}
+		importRes, err := wc.wu.ImportWorkoutsCSV(userClaims.UserID, data, allOrNothing)
+		if err != nil {
+			logger.Error("ImportWorkoutsCSV error: %v", err)
+			return c.JSON(http.StatusBadRequest, err.Error())
+		}
+		return c.JSON(csvImportStatus(importRes), importRes)
+	}
+
+	opts := usecase.WorkoutImportOptions{
+		Workout: c.FormValue("workout"),
+		Weather: c.FormValue("weather"),
+	}
+	if confirmStr := c.FormValue("confirm"); confirmStr != "" {
+		opts.Confirm, err = strconv.ParseBool(confirmStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, "invalid confirm format")
+		}
+	}
+	if tz := c.FormValue("timezone"); tz != "" {
+		opts.Location, err = time.LoadLocation(tz)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, "invalid timezone")
+		}
+	}
+
+	importRes, err := wc.wu.ImportWorkout(userClaims.UserID, filename, data, opts)
+	if err != nil {
+		logger.Error("ImportWorkout error: %v", err)
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	if importRes.Saved {
+		return c.JSON(http.StatusCreated, importRes)
+	}
+	return c.JSON(http.StatusOK, importRes)
+}
+
+// ExportWorkouts は練習を CSV で返す（例：?format=csv&from=2024-01-01&to=2024-12-31。from, to は省略可）
+func (wc *workoutController) ExportWorkouts(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+	if err := checkExportFormat(c); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	var from, to time.Time
+	if fromStr := c.QueryParam("from"); fromStr != "" {
+		from, err = time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, "invalid from format")
+		}
+	}
+	if toStr := c.QueryParam("to"); toStr != "" {
+		to, err = time.Parse("2006-01-02", toStr)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, "invalid to format")
+		}
+	}
+
+	data, err := wc.wu.ExportWorkoutsCSV(userClaims.UserID, from, to)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return sendCSV(c, "workouts.csv", data)
+}
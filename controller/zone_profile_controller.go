@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"go_vdot_api/middleware"
+	"go_vdot_api/model"
+	"go_vdot_api/pkg/logger"
+	"go_vdot_api/usecase"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+type IZoneProfileController interface {
+	CreateZoneProfile(c echo.Context) error
+	GetZoneProfiles(c echo.Context) error
+	GetZoneProfileById(c echo.Context) error
+	UpdateZoneProfile(c echo.Context) error
+	DeleteZoneProfile(c echo.Context) error
+}
+
+type zoneProfileController struct {
+	zpu usecase.IZoneProfileUsecase
+}
+
+func NewZoneProfileController(zpu usecase.IZoneProfileUsecase) IZoneProfileController {
+	return &zoneProfileController{zpu}
+}
+
+func (zpc *zoneProfileController) CreateZoneProfile(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	zoneProfile := model.ZoneProfile{}
+	if err := c.Bind(&zoneProfile); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+	zoneProfile.UserId = userClaims.UserID
+
+	zoneProfileRes, err := zpc.zpu.CreateZoneProfile(zoneProfile)
+	if err != nil {
+		logger.Error("CreateZoneProfile error: %v", err)
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusCreated, zoneProfileRes)
+}
+
+// GetZoneProfiles は組み込み・本人・承認したコーチのゾーン定義を返す
+func (zpc *zoneProfileController) GetZoneProfiles(c echo.Context) error {
+	userId, err := middleware.GetTargetUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	zoneProfilesRes, err := zpc.zpu.GetZoneProfiles(userId)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, zoneProfilesRes)
+}
+
+func (zpc *zoneProfileController) GetZoneProfileById(c echo.Context) error {
+	userId, err := middleware.GetTargetUserID(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	zoneProfileId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid id")
+	}
+
+	zoneProfileRes, err := zpc.zpu.GetZoneProfileById(userId, uint(zoneProfileId))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, zoneProfileRes)
+}
+
+func (zpc *zoneProfileController) UpdateZoneProfile(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	zoneProfile := model.ZoneProfile{}
+	if err := c.Bind(&zoneProfile); err != nil {
+		return c.JSON(http.StatusBadRequest, err.Error())
+	}
+
+	zoneProfileId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid id")
+	}
+
+	zoneProfileRes, err := zpc.zpu.UpdateZoneProfile(zoneProfile, userClaims.UserID, uint(zoneProfileId))
+	if err != nil {
+		logger.Error("UpdateZoneProfile error: %v", err)
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.JSON(http.StatusOK, zoneProfileRes)
+}
+
+func (zpc *zoneProfileController) DeleteZoneProfile(c echo.Context) error {
+	userClaims, err := middleware.GetUserClaims(c)
+	if err != nil {
+		return c.JSON(http.StatusUnauthorized, err.Error())
+	}
+
+	zoneProfileId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, "invalid id")
+	}
+
+	if err := zpc.zpu.DeleteZoneProfile(userClaims.UserID, uint(zoneProfileId)); err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	}
+	return c.NoContent(http.StatusOK)
+}
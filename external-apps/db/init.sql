@@ -8,6 +8,10 @@ CREATE TABLE IF NOT EXISTS users (
   email VARCHAR(255) NOT NULL UNIQUE,
   password VARCHAR(128) NOT NULL,
   is_admin BOOLEAN DEFAULT FALSE,
+  disabled_at TIMESTAMP NULL DEFAULT NULL, -- 管理者が無効にした日時（無効な間はログインできない）
+  email_verified_at TIMESTAMP NULL DEFAULT NULL, -- メールアドレスを確認した日時
+  failed_login_attempts INT NOT NULL DEFAULT 0, -- 続けてパスワードを間違えた回数
+  locked_until TIMESTAMP NULL DEFAULT NULL, -- ログインの失敗が続いてロックしている期限
   created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
   updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
 );
@@ -20,8 +24,11 @@ CREATE TABLE IF NOT EXISTS vdots (
   time TIME NOT NULL,
   elevation INT,
   temperature INT,
+  recorded_at DATE NOT NULL DEFAULT (CURRENT_DATE),
+  is_pinned BOOLEAN NOT NULL DEFAULT FALSE,
   created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
   updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  INDEX idx_vdots_user_recorded_at (user_id, recorded_at),
   FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
@@ -35,8 +42,12 @@ CREATE TABLE IF NOT EXISTS workouts (
   mileage DOUBLE NOT NULL,
   mileage_unit VARCHAR(10) NOT NULL,
   weather VARCHAR(20) NOT NULL,
+  elevation_gain DOUBLE, -- NULL 許容（記録ファイルから取り込んだ場合のみ）
   created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
   updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  deleted_at TIMESTAMP NULL DEFAULT NULL,
+  INDEX idx_workouts_user_date (user_id, date, start_time),
+  INDEX idx_workouts_deleted_at (deleted_at),
   FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
@@ -52,3 +63,142 @@ CREATE TABLE IF NOT EXISTS specialty_events (
   UNIQUE KEY unique_user_event (user_id, event_name),
   FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
+
+CREATE TABLE IF NOT EXISTS zone_profiles (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NOT NULL,
+  name VARCHAR(50) NOT NULL,
+  zones JSON NOT NULL, -- 例：[{"zone": "E", "lower": 70, "upper": 77}, ...]
+  distances JSON NOT NULL, -- 例：[{"label": "1Km", "meters": 1000}, ...]
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS training_plans (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NOT NULL,
+  name VARCHAR(50) NOT NULL,
+  goal_distance DOUBLE NOT NULL, -- 目標レースの距離（m）
+  goal_date DATE NOT NULL,
+  start_date DATE NOT NULL,
+  weekly_mileage DOUBLE NOT NULL,
+  mileage_unit VARCHAR(10) NOT NULL,
+  available_days JSON NOT NULL, -- 例：["tue", "thu", "sat", "sun"]
+  profile VARCHAR(20) NOT NULL DEFAULT 'daniels',
+  vdot DOUBLE NOT NULL,
+  weeks JSON NOT NULL, -- 週ごとの練習（例：[{"week": 1, "phase": "base", "sessions": [...]}, ...]）
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS planned_workouts (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NOT NULL,
+  training_plan_id INT NULL, -- 練習計画から作られた場合のみ
+  date DATE NOT NULL,
+  workout TEXT NOT NULL,
+  mileage DOUBLE NOT NULL,
+  mileage_unit VARCHAR(10) NOT NULL,
+  note TEXT, -- NULL 許容
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  INDEX idx_planned_workouts_user_date (user_id, date),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (training_plan_id) REFERENCES training_plans(id) ON DELETE CASCADE
+);
+
+-- メールアドレスの確認・パスワードの再設定・アカウント削除の確認に使う1回限りのトークン（SHA-256 のハッシュのみ保存する）
+CREATE TABLE IF NOT EXISTS user_tokens (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NOT NULL,
+  purpose VARCHAR(30) NOT NULL, -- email_verification / password_reset / account_deletion
+  token_hash CHAR(64) NOT NULL,
+  email VARCHAR(255) NOT NULL DEFAULT '', -- 確認するメールアドレス（確認までに変更された場合は無効）
+  expires_at TIMESTAMP NOT NULL,
+  used_at TIMESTAMP NULL DEFAULT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY unique_user_tokens_hash (token_hash),
+  INDEX idx_user_tokens_user (user_id, purpose),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- ユーザーに紐づけた外部のサービスのアカウント（Google・Apple・Strava でのログイン）
+CREATE TABLE IF NOT EXISTS user_identities (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NOT NULL,
+  provider VARCHAR(30) NOT NULL,
+  subject VARCHAR(255) NOT NULL, -- サービス内で変わらないアカウントの ID
+  email VARCHAR(255) NOT NULL DEFAULT '',
+  last_login_at TIMESTAMP NULL DEFAULT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY unique_user_identities_subject (provider, subject),
+  UNIQUE KEY unique_user_identities_user (user_id, provider),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- ログインごとのセッション（リフレッシュトークンは SHA-256 のハッシュのみ保存する）
+CREATE TABLE IF NOT EXISTS sessions (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NOT NULL,
+  refresh_token_hash CHAR(64) NOT NULL,
+  previous_token_hash CHAR(64) NOT NULL DEFAULT '',
+  user_agent VARCHAR(255) NOT NULL DEFAULT '',
+  ip_address VARCHAR(45) NOT NULL DEFAULT '',
+  last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  expires_at TIMESTAMP NOT NULL,
+  revoked_at TIMESTAMP NULL DEFAULT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY unique_sessions_refresh_token (refresh_token_hash),
+  INDEX idx_sessions_previous_token (previous_token_hash),
+  INDEX idx_sessions_user (user_id, revoked_at),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- スクリプト・連携サービス用の個人用アクセストークン（トークンは SHA-256 のハッシュのみ保存する）
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NOT NULL,
+  name VARCHAR(50) NOT NULL,
+  token_hash CHAR(64) NOT NULL,
+  prefix VARCHAR(20) NOT NULL, -- 一覧で見分けるためのトークンの先頭
+  scopes JSON NOT NULL, -- 例：["read", "write"]
+  last_used_at TIMESTAMP NULL DEFAULT NULL,
+  expires_at TIMESTAMP NULL DEFAULT NULL, -- NULL の場合は無期限
+  revoked_at TIMESTAMP NULL DEFAULT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY unique_personal_access_tokens_hash (token_hash),
+  INDEX idx_personal_access_tokens_user (user_id, revoked_at),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- コーチと選手のつながり（選手が承認するとコーチは選手のデータを閲覧・予定を作成できる）
+CREATE TABLE IF NOT EXISTS coach_athletes (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  coach_id INT NOT NULL,
+  athlete_id INT NULL DEFAULT NULL, -- 選手が承認するまでは NULL
+  invited_email VARCHAR(255) NOT NULL, -- 招待したメールアドレス（確認済みのメールアドレスが一致する選手が承認できる）
+  status VARCHAR(10) NOT NULL DEFAULT 'pending', -- pending, accepted
+  accepted_at TIMESTAMP NULL DEFAULT NULL,
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY unique_coach_athlete (coach_id, athlete_id),
+  UNIQUE KEY unique_coach_invited_email (coach_id, invited_email),
+  INDEX idx_coach_athletes_athlete (athlete_id),
+  INDEX idx_coach_athletes_invited_email (invited_email),
+  FOREIGN KEY (coach_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (athlete_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- アカウントに対する操作の記録（ユーザーの削除後も残すため外部キーは付けない）
+CREATE TABLE IF NOT EXISTS audit_logs (
+  id INT AUTO_INCREMENT PRIMARY KEY,
+  user_id INT NOT NULL,
+  actor_id INT NOT NULL,
+  action VARCHAR(50) NOT NULL,
+  detail JSON NOT NULL,
+  ip_address VARCHAR(45) NOT NULL DEFAULT '',
+  created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+  INDEX idx_audit_logs_user (user_id, created_at)
+);
@@ -3,10 +3,13 @@ package main
 import (
 	"go_vdot_api/controller"
 	"go_vdot_api/model"
+	"go_vdot_api/pkg/mailer"
+	"go_vdot_api/pkg/oauth"
 	"go_vdot_api/repository"
 	"go_vdot_api/router"
 	"go_vdot_api/usecase"
 	"go_vdot_api/validator"
+	"log"
 )
 
 func main() {
@@ -15,22 +18,62 @@ func main() {
 	vdotValidator := validator.NewVdotValidator()
 	workoutValidator := validator.NewWorkoutValidator()
 	SpecialtyEventValidator := validator.NewSpecialtyEventValidator()
+	zoneProfileValidator := validator.NewZoneProfileValidator()
+	trainingPlanValidator := validator.NewTrainingPlanValidator()
+	plannedWorkoutValidator := validator.NewPlannedWorkoutValidator()
+	personalAccessTokenValidator := validator.NewPersonalAccessTokenValidator()
 
 	userRepository := repository.NewUserRepository(db)
 	vdotRepository := repository.NewVdotRepository(db)
 	workoutRepository := repository.NewWorkoutRepository(db)
 	specialtyEventRepository := repository.NewSpecialtyEventRepository(db)
+	zoneProfileRepository := repository.NewZoneProfileRepository(db)
+	trainingPlanRepository := repository.NewTrainingPlanRepository(db)
+	plannedWorkoutRepository := repository.NewPlannedWorkoutRepository(db)
+	coachAthleteRepository := repository.NewCoachAthleteRepository(db)
+	auditLogRepository := repository.NewAuditLogRepository(db)
+	sessionRepository := repository.NewSessionRepository(db)
+	userTokenRepository := repository.NewUserTokenRepository(db)
+	userIdentityRepository := repository.NewUserIdentityRepository(db)
+	personalAccessTokenRepository := repository.NewPersonalAccessTokenRepository(db)
 
-	userUsecase := usecase.NewUserUsecase(userRepository, userValidator)
-	vdotUsecase := usecase.NewVdotUsecase(vdotRepository, vdotValidator)
-	workoutUsecase := usecase.NewWorkoutUsecase(workoutRepository, workoutValidator)
+	mailSender, err := mailer.NewFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	userUsecase := usecase.NewUserUsecase(userRepository, vdotRepository, workoutRepository, specialtyEventRepository, zoneProfileRepository, trainingPlanRepository, plannedWorkoutRepository, coachAthleteRepository, sessionRepository, userTokenRepository, userIdentityRepository, personalAccessTokenRepository, userValidator, mailSender)
+	vdotUsecase := usecase.NewVdotUsecase(vdotRepository, zoneProfileRepository, vdotValidator)
+	workoutUsecase := usecase.NewWorkoutUsecase(workoutRepository, vdotRepository, zoneProfileRepository, workoutValidator)
 	specialtyEventUsecase := usecase.NewSpecialtyEventUsecase(specialtyEventRepository, SpecialtyEventValidator)
+	zoneProfileUsecase := usecase.NewZoneProfileUsecase(zoneProfileRepository, zoneProfileValidator)
+	trainingLoadUsecase := usecase.NewTrainingLoadUsecase(workoutRepository, vdotRepository, zoneProfileRepository, usecase.DefaultTrainingLoadThresholds())
+	trainingPlanUsecase := usecase.NewTrainingPlanUsecase(trainingPlanRepository, vdotRepository, zoneProfileRepository, trainingPlanValidator)
+	plannedWorkoutUsecase := usecase.NewPlannedWorkoutUsecase(plannedWorkoutRepository, workoutRepository, vdotRepository, trainingPlanRepository, zoneProfileRepository, plannedWorkoutValidator)
+	coachAthleteUsecase := usecase.NewCoachAthleteUsecase(coachAthleteRepository, userRepository)
+	oauthUsecase := usecase.NewOAuthUsecase(userRepository, userIdentityRepository, sessionRepository, oauth.ProvidersFromEnv())
+	personalAccessTokenUsecase := usecase.NewPersonalAccessTokenUsecase(personalAccessTokenRepository, personalAccessTokenValidator)
+	adminUsecase := usecase.NewAdminUsecase(userRepository, auditLogRepository, sessionRepository)
 
 	userController := controller.NewUserController(userUsecase)
 	vdotController := controller.NewVdotController(vdotUsecase)
 	workoutController := controller.NewWorkoutController(workoutUsecase)
 	specialtyEventController := controller.NewSpecialtyEventController(specialtyEventUsecase)
+	zoneProfileController := controller.NewZoneProfileController(zoneProfileUsecase)
+	trainingLoadController := controller.NewTrainingLoadController(trainingLoadUsecase)
+	trainingPlanController := controller.NewTrainingPlanController(trainingPlanUsecase)
+	plannedWorkoutController := controller.NewPlannedWorkoutController(plannedWorkoutUsecase)
+	coachAthleteController := controller.NewCoachAthleteController(coachAthleteUsecase)
+	adminController := controller.NewAdminController(adminUsecase)
+	oauthController := controller.NewOAuthController(oauthUsecase)
+	personalAccessTokenController := controller.NewPersonalAccessTokenController(personalAccessTokenUsecase)
+
+	ipExtractor, err := router.IPExtractorFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
 
-	e := router.NewRouter(userController, vdotController, workoutController, specialtyEventController)
+	e := router.NewRouter(userController, vdotController, workoutController, specialtyEventController, zoneProfileController, trainingLoadController, trainingPlanController, plannedWorkoutController, coachAthleteController, adminController, oauthController, personalAccessTokenController, coachAthleteUsecase, userUsecase, personalAccessTokenUsecase)
+	e.IPExtractor = ipExtractor
 	e.Logger.Fatal(e.Start(":8080"))
 }
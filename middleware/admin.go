@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AdminOnly は JWT の is_admin が true のユーザーのみ通す（JWTMiddleware の後に使う）
+func AdminOnly() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userClaims, err := GetUserClaims(c)
+			if err != nil {
+				return c.JSON(http.StatusUnauthorized, err.Error())
+			}
+			if !userClaims.IsAdmin {
+				return c.JSON(http.StatusForbidden, echo.Map{"message": "admin only"})
+			}
+			return next(c)
+		}
+	}
+}
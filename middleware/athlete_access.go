@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AccessChecker は操作するユーザーが選手のデータにアクセスできるかチェックする
+// （usecase.ICoachAthleteUsecase が実装する）
+type AccessChecker interface {
+	CheckAccess(actorId uint, athleteId uint, permission string) error
+}
+
+// 操作の対象の選手の ID を保存するコンテキストのキー
+const targetUserIDKey = "target_user_id"
+
+// AthleteAccess はパスの :athlete_id の選手のデータに permission の操作ができるかチェックする
+// JWTMiddleware の後に使い、許可された場合は対象の選手の ID をコンテキストに保存する
+func AthleteAccess(checker AccessChecker, permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			userClaims, err := GetUserClaims(c)
+			if err != nil {
+				return c.JSON(http.StatusUnauthorized, err.Error())
+			}
+			athleteId, err := strconv.Atoi(c.Param("athlete_id"))
+			if err != nil || athleteId <= 0 {
+				return c.JSON(http.StatusBadRequest, "invalid athlete_id")
+			}
+			if err := checker.CheckAccess(userClaims.UserID, uint(athleteId), permission); err != nil {
+				return c.JSON(http.StatusForbidden, err.Error())
+			}
+			c.Set(targetUserIDKey, uint(athleteId))
+			return next(c)
+		}
+	}
+}
+
+// GetTargetUserID は操作の対象のユーザーの ID を返す
+// AthleteAccess を通った場合はその選手、それ以外はログインしているユーザー本人
+func GetTargetUserID(c echo.Context) (uint, error) {
+	if athleteId, ok := c.Get(targetUserIDKey).(uint); ok {
+		return athleteId, nil
+	}
+	userClaims, err := GetUserClaims(c)
+	if err != nil {
+		return 0, err
+	}
+	return userClaims.UserID, nil
+}
@@ -8,9 +8,11 @@ import (
 
 // UserClaims は JWT から取得するユーザー情報の構造体
 type UserClaims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
-	Name   string `json:"name"`
+	UserID    uint   `json:"user_id"`
+	Email     string `json:"email"`
+	Name      string `json:"name"`
+	IsAdmin   bool   `json:"is_admin"`
+	SessionID uint   `json:"sid"`
 }
 
 // GetUserClaims は echo.Context から JWT クレームを取得して UserClaims に変換する
@@ -30,9 +32,15 @@ func GetUserClaims(c echo.Context) (*UserClaims, error) {
 		return nil, errors.New("user_id not found in token")
 	}
 
+	// is_admin を含まない（追加前に発行された）トークンは管理者ではないとみなす
+	isAdmin, _ := claims["is_admin"].(bool)
+	sessionIdFloat, _ := claims["sid"].(float64)
+
 	return &UserClaims{
-		UserID: uint(userIdFloat),
-		Email:  claims["email"].(string),
-		Name:   claims["name"].(string),
+		UserID:    uint(userIdFloat),
+		Email:     claims["email"].(string),
+		Name:      claims["name"].(string),
+		IsAdmin:   isAdmin,
+		SessionID: uint(sessionIdFloat),
 	}, nil
 }
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"go_vdot_api/model"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/labstack/echo/v4"
+)
+
+// TokenAuthenticator は Authorization: Bearer の個人用アクセストークンを検証する
+// （usecase.IPersonalAccessTokenUsecase が実装する）
+type TokenAuthenticator interface {
+	AuthenticateToken(token string) (model.User, model.TokenScopes, error)
+}
+
+// 個人用アクセストークンで認証したリクエストのコンテキストのキー
+const (
+	bearerUserKey  = "bearer_user"
+	bearerErrorKey = "bearer_error"
+)
+
+// bearerToken は Authorization ヘッダーのトークンを返す
+func bearerToken(c echo.Context) (string, bool) {
+	auth := c.Request().Header.Get(echo.HeaderAuthorization)
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		return "", false
+	}
+	return strings.TrimSpace(auth[7:]), true
+}
+
+// authenticateBearer はトークンを検証し、JWT と同じ形のクレームにしてコンテキストに保存する
+// CSRF の Skipper と JWTMiddleware の両方から呼ばれるため、結果を保存して1回だけ検証する
+func authenticateBearer(c echo.Context, authenticator TokenAuthenticator) (*jwt.Token, error) {
+	if token, ok := c.Get(bearerUserKey).(*jwt.Token); ok {
+		return token, nil
+	}
+	if err, ok := c.Get(bearerErrorKey).(error); ok {
+		return nil, err
+	}
+	plain, _ := bearerToken(c)
+	user, scopes, err := authenticator.AuthenticateToken(plain)
+	if err != nil {
+		c.Set(bearerErrorKey, err)
+		return nil, err
+	}
+	// 管理者の操作はトークンではできない
+	token := &jwt.Token{
+		Valid: true,
+		Claims: jwt.MapClaims{
+			"user_id":  float64(user.ID),
+			"name":     user.Name,
+			"email":    user.Email,
+			"is_admin": false,
+			"scopes":   []string(scopes),
+		},
+	}
+	c.Set(bearerUserKey, token)
+	return token, nil
+}
+
+// BearerAuthenticated は有効な個人用アクセストークンで認証されたリクエストか返す
+// Cookie を使わないリクエストのため、CSRF の Skipper に使う
+func BearerAuthenticated(authenticator TokenAuthenticator) func(c echo.Context) bool {
+	return func(c echo.Context) bool {
+		if _, ok := bearerToken(c); !ok {
+			return false
+		}
+		_, err := authenticateBearer(c, authenticator)
+		return err == nil
+	}
+}
+
+// IsBearerRequest は個人用アクセストークンで認証したリクエストか返す
+func IsBearerRequest(c echo.Context) bool {
+	_, ok := c.Get(bearerUserKey).(*jwt.Token)
+	return ok
+}
+
+// requiredScope はメソッドに必要な権限（GET は read、それ以外は write）
+func requiredScope(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return model.TokenScopeRead
+	}
+	return model.TokenScopeWrite
+}
+
+// hasScope は write が read を含むとして権限をチェックする
+func hasScope(scopes model.TokenScopes, scope string) bool {
+	return scopes.Has(scope) || (scope == model.TokenScopeRead && scopes.Has(model.TokenScopeWrite))
+}
+
+// CookieOnly は個人用アクセストークンでは使えないエンドポイント（アカウント・セッション・トークンの管理）に使う
+// JWTMiddleware の後に使う
+func CookieOnly() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if IsBearerRequest(c) {
+				return c.JSON(http.StatusForbidden, echo.Map{"message": "this endpoint cannot be used with an access token"})
+			}
+			return next(c)
+		}
+	}
+}
@@ -9,9 +9,30 @@ import (
 	"go_vdot_api/pkg/logger"
 )
 
-func JWTMiddleware() echo.MiddlewareFunc {
+// SessionChecker はアクセストークンのセッションが有効かチェックする
+// （usecase.IUserUsecase が実装する）
+type SessionChecker interface {
+	ValidateSession(userId uint, sessionId uint) error
+}
+
+// JWTMiddleware はアクセストークンを検証し、無効にされたセッションのトークンを拒否する
+// Authorization: Bearer がある場合は Cookie を使わず、個人用アクセストークンとその権限で認証する
+func JWTMiddleware(checker SessionChecker, authenticator TokenAuthenticator) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
+			if _, ok := bearerToken(c); ok {
+				token, err := authenticateBearer(c, authenticator)
+				if err != nil {
+					return c.JSON(http.StatusUnauthorized, echo.Map{"message": err.Error()})
+				}
+				scopes, _ := token.Claims.(jwt.MapClaims)["scopes"].([]string)
+				if scope := requiredScope(c.Request().Method); !hasScope(scopes, scope) {
+					return c.JSON(http.StatusForbidden, echo.Map{"message": "access token requires the " + scope + " scope"})
+				}
+				c.Set("user", token)
+				return next(c)
+			}
+
 			// Cookieから"auth_token"を取得
 			// クライアントから送信されたリクエスト内のCookieを調べ、"auth_token"を取得する。
 			cookie, err := c.Cookie("token")
@@ -42,6 +63,16 @@ func JWTMiddleware() echo.MiddlewareFunc {
 				return c.JSON(http.StatusUnauthorized, "Invalid Claims")
 			}
 
+			// ログアウト・パスワード変更などで無効にされたセッションのトークンは使えない
+			// （sid を含まない以前のトークンも再ログインしてもらう）
+			userClaims, err := GetUserClaims(c)
+			if err != nil || userClaims.SessionID == 0 {
+				return c.JSON(http.StatusUnauthorized, echo.Map{"message": "Invalid token"})
+			}
+			if err := checker.ValidateSession(userClaims.UserID, userClaims.SessionID); err != nil {
+				return c.JSON(http.StatusUnauthorized, echo.Map{"message": err.Error()})
+			}
+
 			return next(c)
 		}
 	}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"go_vdot_api/pkg/logger"
+	"go_vdot_api/pkg/metrics"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RateLimiter はキーごとに window の間の回数を limit までに制限する（プロセス内のメモリで数える）
+type RateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	hits      map[string]*rateWindow
+	nextSweep time.Time
+}
+
+type rateWindow struct {
+	count   int
+	resetAt time.Time
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{limit: limit, window: window, hits: map[string]*rateWindow{}}
+}
+
+// Allow はキーの回数を1つ増やし、制限内か返す。制限を超えた場合は次に試せるまでの時間も返す
+func (rl *RateLimiter) Allow(key string) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+	rl.sweep(now)
+
+	w, ok := rl.hits[key]
+	if !ok || !now.Before(w.resetAt) {
+		w = &rateWindow{resetAt: now.Add(rl.window)}
+		rl.hits[key] = w
+	}
+	w.count++
+	if w.count > rl.limit {
+		return false, w.resetAt.Sub(now)
+	}
+	return true, 0
+}
+
+// sweep は期限の切れたキーを削除する（メモリが増え続けないよう window ごとに1回）
+func (rl *RateLimiter) sweep(now time.Time) {
+	if now.Before(rl.nextSweep) {
+		return
+	}
+	for key, w := range rl.hits {
+		if !now.Before(w.resetAt) {
+			delete(rl.hits, key)
+		}
+	}
+	rl.nextSweep = now.Add(rl.window)
+}
+
+// LoginRateLimit はログインの試行を IP アドレスごと・メールアドレスごとに制限する
+// 制限を超えた場合はパスワードを確認せずに 429 を返す
+func LoginRateLimit(ipLimiter *RateLimiter, accountLimiter *RateLimiter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ip := c.RealIP()
+			if ok, retryAfter := ipLimiter.Allow(ip); !ok {
+				return tooManyLoginAttempts(c, "ip_rate_limit", retryAfter)
+			}
+			if email := loginEmail(c); email != "" {
+				if ok, retryAfter := accountLimiter.Allow(email); !ok {
+					return tooManyLoginAttempts(c, "account_rate_limit", retryAfter)
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+func tooManyLoginAttempts(c echo.Context, reason string, retryAfter time.Duration) error {
+	metrics.LoginBlocked.Inc(reason)
+	logger.Warn("login blocked: reason=%s ip=%s", reason, c.RealIP())
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	return c.JSON(http.StatusTooManyRequests, echo.Map{"message": "too many login attempts"})
+}
+
+// loginEmail はリクエストの body からメールアドレスを取り出す（大文字・小文字は区別しない）
+// JSON の場合はハンドラーが Bind できるよう body を戻す
+func loginEmail(c echo.Context) string {
+	req := c.Request()
+	if strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		body, err := io.ReadAll(io.LimitReader(req.Body, 1<<20))
+		if err != nil {
+			return ""
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		var user struct {
+			Email string `json:"email"`
+		}
+		if err := json.Unmarshal(body, &user); err != nil {
+			return ""
+		}
+		return strings.ToLower(strings.TrimSpace(user.Email))
+	}
+	return strings.ToLower(strings.TrimSpace(c.FormValue("email")))
+}
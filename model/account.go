@@ -0,0 +1,70 @@
+package model
+
+import (
+	"go_vdot_api/pkg"
+	"time"
+)
+
+// AccountExportResponse はユーザーのすべてのデータ（/api/user/export）
+type AccountExportResponse struct {
+	ExportedAt      time.Time                `json:"exported_at"`
+	Profile         AccountProfile           `json:"profile"`
+	Vdots           []VdotResponse           `json:"vdots"`    // 記録日の古い順
+	Workouts        []WorkoutResponse        `json:"workouts"` // 日付・開始時刻の古い順
+	SpecialtyEvents []SpecialtyEventResponse `json:"specialty_events"`
+	ZoneProfiles    []ZoneProfileResponse    `json:"zone_profiles"`    // 組み込みの Daniels のゾーン定義は含まない
+	TrainingPlans   []TrainingPlanResponse   `json:"training_plans"`   // 作成した時点のVDOTで生成した計画
+	PlannedWorkouts []AccountPlannedWorkout  `json:"planned_workouts"` // 日付順
+	CoachLinks      CoachLinksResponse       `json:"coach_links"`
+	Sessions        []Session                `json:"sessions"`               // リフレッシュトークンのハッシュは含まない
+	UserTokens      []UserToken              `json:"user_tokens"`            // メールで送ったトークンの履歴（トークンのハッシュは含まない）
+	Identities      []UserIdentity           `json:"identities"`             // 紐づけた外部のアカウント
+	AccessTokens    []PersonalAccessToken    `json:"personal_access_tokens"` // 無効にしたものを含む（トークンのハッシュは含まない）
+}
+
+type AccountProfile struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AccountPlannedWorkout はエクスポートする予定（練習記録との対応・一致度はカレンダーで確認する）
+type AccountPlannedWorkout struct {
+	ID             uint         `json:"id"`
+	Date           pkg.DateOnly `json:"date"`
+	Workout        string       `json:"workout"`
+	Mileage        float64      `json:"mileage"`
+	MileageUnit    string       `json:"mileage_unit"`
+	Note           *string      `json:"note"`
+	TrainingPlanId *uint        `json:"training_plan_id"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+// AccountDeletionRequest はアカウント削除の確認（本人のパスワード、またはパスワードがない場合は確認メールのトークン）
+type AccountDeletionRequest struct {
+	Password string `json:"password"`
+	Token    string `json:"token"`
+}
+
+// AccountDeletionResponse はアカウント削除で消したデータの報告
+type AccountDeletionResponse struct {
+	UserID    uint             `json:"user_id"`
+	DeletedAt time.Time        `json:"deleted_at"`
+	Removed   UserRecordCounts `json:"removed"`
+}
+
+// UserRecordCounts はユーザーのデータのテーブルごとの件数
+type UserRecordCounts struct {
+	Vdots           int64 `json:"vdots"`
+	Workouts        int64 `json:"workouts"` // 論理削除済みの練習を含む
+	SpecialtyEvents int64 `json:"specialty_events"`
+	ZoneProfiles    int64 `json:"zone_profiles"`
+	TrainingPlans   int64 `json:"training_plans"`
+	PlannedWorkouts int64 `json:"planned_workouts"`
+	CoachAthletes   int64 `json:"coach_athletes"` // コーチとしても選手としてもつながりを数える
+	Sessions        int64 `json:"sessions"`       // 無効にされた・期限切れのセッションを含む
+	UserTokens      int64 `json:"user_tokens"`    // メールの確認・パスワードの再設定のトークン
+	UserIdentities  int64 `json:"user_identities"`
+	AccessTokens    int64 `json:"personal_access_tokens"` // 個人用アクセストークン（無効にした・期限切れのものを含む）
+}
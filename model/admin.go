@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// AdminUserResponse は管理者向けのユーザー情報
+type AdminUserResponse struct {
+	ID          uint       `json:"id"`
+	Name        string     `json:"name"`
+	Email       string     `json:"email"`
+	IsAdmin     bool       `json:"is_admin"`
+	DisabledAt  *time.Time `json:"disabled_at"`
+	LockedUntil *time.Time `json:"locked_until"` // ログインの失敗が続いてロックしている期限（期限が過ぎていても次に成功するまで残る）
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type AdminUserListResponse struct {
+	Users  []AdminUserResponse `json:"users"`
+	Total  int64               `json:"total"` // 検索条件に一致したユーザーの総数
+	Limit  int                 `json:"limit"`
+	Offset int                 `json:"offset"`
+}
+
+// AdminUserDetailResponse はユーザー情報とテーブルごとのデータの件数
+type AdminUserDetailResponse struct {
+	AdminUserResponse
+	Records UserRecordCounts `json:"records"`
+}
+
+// AdminPasswordResetResponse は管理者がリセットしたパスワード（一時パスワードはこのレスポンスでのみ返す）
+type AdminPasswordResetResponse struct {
+	UserID            uint   `json:"user_id"`
+	TemporaryPassword string `json:"temporary_password"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// 監査ログの操作の種類
+const (
+	AuditAccountDeleted  = "account_deleted"
+	AuditAccountDisabled = "account_disabled"
+	AuditAccountEnabled  = "account_enabled"
+	AuditPasswordReset   = "password_reset"
+)
+
+// AuditLog はアカウントに対する操作の記録
+// 対象のユーザーが削除された後も残すため、users への外部キーは持たない
+type AuditLog struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	UserId    uint        `json:"user_id"`  // 操作の対象のユーザー
+	ActorId   uint        `json:"actor_id"` // 操作したユーザー（本人の場合は UserId と同じ）
+	Action    string      `json:"action"`
+	Detail    AuditDetail `json:"detail"`
+	IPAddress string      `json:"ip_address"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// AuditDetail は操作ごとの詳細（JSON カラム）。個人情報は入れない
+type AuditDetail map[string]interface{}
+
+func (d AuditDetail) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+func (d *AuditDetail) Scan(value interface{}) error {
+	return scanJSON(value, d)
+}
@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+// コーチと選手のつながりの状態
+const (
+	CoachLinkPending  = "pending"  // コーチが招待し、選手の承認待ち
+	CoachLinkAccepted = "accepted" // 選手が承認済み
+)
+
+// CoachAthlete はコーチと選手のつながり。選手が承認するとコーチは選手の記録・練習を閲覧し、予定を作成できる
+type CoachAthlete struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Status     string     `json:"status"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	Coach     User  `json:"-" gorm:"foreignKey:CoachId;constraint:OnDelete:CASCADE"`
+	CoachId   uint  `json:"coach_id"`
+	Athlete   User  `json:"-" gorm:"foreignKey:AthleteId;constraint:OnDelete:CASCADE"`
+	AthleteId *uint `json:"athlete_id"` // 選手が承認するまでは null（招待したメールアドレスで選手と照合する）
+
+	InvitedEmail string `json:"invited_email"` // 招待したメールアドレス
+}
+
+type CoachAthleteResponse struct {
+	ID         uint             `json:"id"`
+	Coach      CoachAthleteUser `json:"coach"`
+	Athlete    CoachAthleteUser `json:"athlete"`
+	Status     string           `json:"status"`
+	AcceptedAt *time.Time       `json:"accepted_at"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+type CoachAthleteUser struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// CoachLinksResponse はコーチとしての選手一覧と、選手としてのコーチ一覧（招待を含む）
+type CoachLinksResponse struct {
+	Athletes []CoachAthleteResponse `json:"athletes"`
+	Coaches  []CoachAthleteResponse `json:"coaches"`
+}
+
+// CoachInvitationRequest は選手の招待（選手のメールアドレス）
+type CoachInvitationRequest struct {
+	Email string `json:"email"`
+}
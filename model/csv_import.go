@@ -0,0 +1,16 @@
+package model
+
+// CSVImportResponse は CSV の一括取り込みの結果
+type CSVImportResponse struct {
+	Total        int           `json:"total"`    // 空行を除いたデータ行の数
+	Imported     int           `json:"imported"` // 保存した行の数
+	Failed       int           `json:"failed"`
+	AllOrNothing bool          `json:"all_or_nothing"` // true の場合は1行でも失敗すると1行も保存しない
+	Errors       []CSVRowError `json:"errors"`
+}
+
+// CSVRowError は取り込めなかった行とその理由
+type CSVRowError struct {
+	Row   int    `json:"row"` // ファイル上の行番号（ヘッダーが1行目）
+	Error string `json:"error"`
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// 個人用アクセストークンの権限
+const (
+	TokenScopeRead  = "read"  // GET のみ
+	TokenScopeWrite = "write" // 作成・更新・削除（read を含む）
+)
+
+type TokenScopes []string
+
+func (s TokenScopes) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+func (s *TokenScopes) Scan(value interface{}) error {
+	return scanJSON(value, s)
+}
+
+func (s TokenScopes) Has(scope string) bool {
+	for _, v := range s {
+		if v == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// PersonalAccessToken はスクリプト・連携サービスから Authorization: Bearer で使うトークン
+// トークンはハッシュのみを保存し、作成したときに一度だけ返す
+type PersonalAccessToken struct {
+	ID         uint        `json:"id" gorm:"primaryKey"`
+	Name       string      `json:"name"`
+	TokenHash  string      `json:"-"`
+	Prefix     string      `json:"prefix"` // 一覧で見分けるためのトークンの先頭
+	Scopes     TokenScopes `json:"scopes" gorm:"type:json"`
+	LastUsedAt *time.Time  `json:"last_used_at"`
+	ExpiresAt  *time.Time  `json:"expires_at"` // null の場合は無期限
+	RevokedAt  *time.Time  `json:"revoked_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+
+	User   User `json:"-" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	UserId uint `json:"user_id"`
+}
+
+type PersonalAccessTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0 の場合は無期限
+}
+
+type PersonalAccessTokenResponse struct {
+	ID         uint        `json:"id"`
+	Name       string      `json:"name"`
+	Prefix     string      `json:"prefix"`
+	Scopes     TokenScopes `json:"scopes"`
+	LastUsedAt *time.Time  `json:"last_used_at"`
+	ExpiresAt  *time.Time  `json:"expires_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// PersonalAccessTokenCreatedResponse は作成したトークン（token は再表示できない）
+type PersonalAccessTokenCreatedResponse struct {
+	PersonalAccessTokenResponse
+	Token string `json:"token"`
+}
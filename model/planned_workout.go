@@ -0,0 +1,60 @@
+package model
+
+import (
+	"go_vdot_api/pkg"
+	"time"
+)
+
+// PlannedWorkout は予定している練習（練習計画から作られたもの、または個別に登録したもの）
+type PlannedWorkout struct {
+	ID             uint         `json:"id" gorm:"primaryKey"`
+	Date           pkg.DateOnly `json:"date"`             // 予定日
+	Workout        string       `json:"workout"`          // 練習内容（Workout.Workout と同じ表記）
+	Mileage        float64      `json:"mileage"`          // 予定距離
+	MileageUnit    string       `json:"mileage_unit"`     // 予定距離の単位（km, mile）
+	Note           *string      `json:"note"`             // メモ（null 許容）
+	TrainingPlanId *uint        `json:"training_plan_id"` // 練習計画から作られた場合の計画のID
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+
+	User   User `json:"user" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	UserId uint `json:"user_id"`
+}
+
+type PlannedWorkoutResponse struct {
+	ID             uint             `json:"id"`
+	Date           pkg.DateOnly     `json:"date"`
+	Workout        string           `json:"workout"`
+	Mileage        float64          `json:"mileage"`
+	MileageUnit    string           `json:"mileage_unit"`
+	Note           *string          `json:"note"`
+	TrainingPlanId *uint            `json:"training_plan_id"`
+	Status         string           `json:"status"`     // completed, missed, upcoming
+	WorkoutID      *uint            `json:"workout_id"` // 同じ日の練習記録と対応付けた場合のID
+	Compliance     *ComplianceScore `json:"compliance"` // 対応する練習記録がない場合は null
+}
+
+// ComplianceScore は予定と実際の練習の一致度（0〜100）
+type ComplianceScore struct {
+	Distance float64  `json:"distance"` // 距離の一致度（短い方 / 長い方）
+	Pace     *float64 `json:"pace"`     // ラップのうち目標ペースに入った割合（ラップがない・比較できない場合は null）
+	Overall  float64  `json:"overall"`  // distance と pace の平均（pace がない場合は distance）
+}
+
+// CalendarResponse は1か月分の予定と実際の練習
+type CalendarResponse struct {
+	Year         int           `json:"year"`
+	Month        int           `json:"month"`
+	Days         []CalendarDay `json:"days"`
+	Planned      int           `json:"planned"`       // 予定の数
+	Completed    int           `json:"completed"`     // 練習記録と対応付いた予定の数
+	Missed       int           `json:"missed"`        // 過ぎた日の予定で、練習記録がないものの数
+	AverageScore *float64      `json:"average_score"` // completed の予定の overall の平均
+}
+
+type CalendarDay struct {
+	Date      pkg.DateOnly             `json:"date"`
+	Planned   []PlannedWorkoutResponse `json:"planned"`
+	Done      []WorkoutResponse        `json:"done"`
+	Unplanned []uint                   `json:"unplanned"` // 予定と対応付かなかった練習記録のID
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// Session はログインごとのセッション。リフレッシュトークンはハッシュのみを保存する
+type Session struct {
+	ID                uint       `json:"id" gorm:"primaryKey"`
+	RefreshTokenHash  string     `json:"-"`
+	PreviousTokenHash string     `json:"-"` // 1つ前のリフレッシュトークン（再利用された場合に盗用とみなしてセッションを無効にする）
+	UserAgent         string     `json:"user_agent"`
+	IPAddress         string     `json:"ip_address"`
+	LastSeenAt        time.Time  `json:"last_seen_at"` // 最後にトークンを更新した日時
+	ExpiresAt         time.Time  `json:"expires_at"`   // リフレッシュトークンの有効期限
+	RevokedAt         *time.Time `json:"revoked_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+
+	User   User `json:"-" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	UserId uint `json:"user_id"`
+}
+
+type SessionResponse struct {
+	ID         uint      `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"` // このリクエストのセッションか
+}
+
+// AuthTokens はログイン・トークン更新で発行するトークン（Cookie に入れて返す）
+type AuthTokens struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
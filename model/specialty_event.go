@@ -19,3 +19,21 @@ type SpecialtyEvent struct {
 	User   User `json:"user" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
 	UserId uint `json:"user_id"`
 }
+
+type SpecialtyEventResponse struct {
+	ID              uint             `json:"id"`
+	EventName       string           `json:"event_name"`
+	BestTime        string           `json:"best_time"`
+	RecordedAt      pkg.DateOnly     `json:"recorded_at"`
+	Vdot            *float64         `json:"vdot"`             // ベストタイムから算出したVDOT（算出できない種目は null）
+	RacePredictions []RacePrediction `json:"race_predictions"` // 同じVDOTでの他距離の相当タイム
+}
+
+// SpecialtyEventSummaryResponse は最もVDOTの高い種目を現在のVDOTとしたまとめ
+type SpecialtyEventSummaryResponse struct {
+	BestEvent       SpecialtyEventResponse   `json:"best_event"`
+	Vdot            float64                  `json:"vdot"`
+	PaceZones       []PaceZone               `json:"pace_zones"`
+	RacePredictions []RacePrediction         `json:"race_predictions"`
+	Events          []SpecialtyEventResponse `json:"events"`
+}
@@ -0,0 +1,49 @@
+package model
+
+import "go_vdot_api/pkg"
+
+// TrainingLoadResponse は練習記録から算出したトレーニング負荷
+// 負荷の単位は Daniels のポイント（ゾーンごとの1分あたりのポイント × 時間）
+type TrainingLoadResponse struct {
+	From       pkg.DateOnly                   `json:"from"`
+	To         pkg.DateOnly                   `json:"to"`
+	Vdot       float64                        `json:"vdot"` // ゾーンのペースの算出に使ったVDOT
+	Thresholds TrainingLoadThresholdsResponse `json:"thresholds"`
+	Days       []TrainingLoadDay              `json:"days"`
+	Warnings   []TrainingLoadWarning          `json:"warnings"`
+}
+
+type TrainingLoadThresholdsResponse struct {
+	AcwrHigh     float64 `json:"acwr_high"`
+	AcwrLow      float64 `json:"acwr_low"`
+	MonotonyHigh float64 `json:"monotony_high"`
+	StrainHigh   float64 `json:"strain_high"`
+}
+
+// TrainingLoadDay は1日分の負荷
+type TrainingLoadDay struct {
+	Date        pkg.DateOnly          `json:"date"`
+	Load        float64               `json:"load"`         // その日の負荷の合計
+	Sessions    []TrainingLoadSession `json:"sessions"`     // その日の練習ごとの負荷
+	AcuteLoad   float64               `json:"acute_load"`   // 直近7日間の1日あたりの平均負荷
+	ChronicLoad float64               `json:"chronic_load"` // 直近28日間の1日あたりの平均負荷
+	Acwr        *float64              `json:"acwr"`         // acute / chronic（chronic が 0 の場合は null）
+	Monotony    *float64              `json:"monotony"`     // 直近7日間の平均 / 標準偏差（標準偏差が 0 の場合は null）
+	Strain      *float64              `json:"strain"`       // 直近7日間の合計 × monotony
+}
+
+// TrainingLoadSession は1回の練習の負荷
+type TrainingLoadSession struct {
+	WorkoutID uint    `json:"workout_id"`
+	Minutes   float64 `json:"minutes"` // ゾーンのペースから推定した練習時間
+	Load      float64 `json:"load"`
+	Parsed    bool    `json:"parsed"` // 練習内容を解析できたか（できない場合は全て E として計算）
+}
+
+// TrainingLoadWarning はしきい値を超えた日と指標
+type TrainingLoadWarning struct {
+	Date      pkg.DateOnly `json:"date"`
+	Metric    string       `json:"metric"` // acwr_high, acwr_low, monotony_high, strain_high
+	Value     float64      `json:"value"`
+	Threshold float64      `json:"threshold"`
+}
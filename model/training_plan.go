@@ -0,0 +1,86 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"go_vdot_api/pkg"
+	"time"
+)
+
+// TrainingPlan は目標レースに向けた練習計画（VDOTとペースゾーンから生成する）
+type TrainingPlan struct {
+	ID            uint         `json:"id" gorm:"primaryKey"`
+	Name          string       `json:"name" gorm:"type:varchar(50);not null"`
+	GoalDistance  float64      `json:"goal_distance"`                            // 目標レースの距離（m）
+	GoalDate      pkg.DateOnly `json:"goal_date"`                                // 目標レースの日
+	StartDate     pkg.DateOnly `json:"start_date"`                               // 計画の開始日（省略時は次の月曜日）
+	WeeklyMileage float64      `json:"weekly_mileage"`                           // 現在の週間走行距離
+	MileageUnit   string       `json:"mileage_unit"`                             // 週間走行距離の単位（km, mile）
+	AvailableDays PlanDays     `json:"available_days" gorm:"type:json;not null"` // 練習できる曜日（例：["tue", "thu", "sat", "sun"]）
+	Profile       string       `json:"profile"`                                  // ペースの算出に使うゾーン定義（daniels またはゾーン定義のID）
+	Vdot          float64      `json:"vdot"`                                     // 生成に使ったVDOT
+	Weeks         PlanWeeks    `json:"weeks" gorm:"type:json;not null"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+
+	User   User `json:"user" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	UserId uint `json:"user_id"`
+}
+
+// PlanWeek は計画の1週間分
+type PlanWeek struct {
+	Week      int           `json:"week"`  // 何週目か（1始まり）
+	Phase     string        `json:"phase"` // base, quality, peak, taper
+	StartDate pkg.DateOnly  `json:"start_date"`
+	MileageKm float64       `json:"mileage_km"` // その週の予定距離
+	Sessions  []PlanSession `json:"sessions"`
+}
+
+// PlanSession は計画の1回分の練習
+type PlanSession struct {
+	Date        pkg.DateOnly `json:"date"`
+	Type        string       `json:"type"`        // easy, long, quality, race
+	Workout     string       `json:"workout"`     // Workout.Workout と同じ表記（例：E3km, 5x(I1000m・ジョグ3分), E2km）
+	DistanceKm  float64      `json:"distance_km"` // 予定距離（ジョグでつなぐ分は含まない）
+	TargetPaces []PaceZone   `json:"target_paces"`
+	TargetTime  *string      `json:"target_time"` // レースの予測タイム（レース以外は null）
+}
+
+type PlanDays []string
+
+type PlanWeeks []PlanWeek
+
+type TrainingPlanResponse struct {
+	ID            uint         `json:"id"`
+	Name          string       `json:"name"`
+	GoalDistance  float64      `json:"goal_distance"`
+	GoalDate      pkg.DateOnly `json:"goal_date"`
+	StartDate     pkg.DateOnly `json:"start_date"`
+	WeeklyMileage float64      `json:"weekly_mileage"`
+	MileageUnit   string       `json:"mileage_unit"`
+	AvailableDays PlanDays     `json:"available_days"`
+	Profile       string       `json:"profile"`
+	Vdot          float64      `json:"vdot"`
+	CurrentVdot   *float64     `json:"current_vdot"` // 現在のVDOT（記録がない場合は null）
+	VdotChanged   bool         `json:"vdot_changed"` // 生成後にVDOTが変わった場合は true（再生成できる）
+	Weeks         PlanWeeks    `json:"weeks"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// GORM対応（JSONカラムとして保存）
+func (d PlanDays) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+func (d *PlanDays) Scan(value interface{}) error {
+	return scanJSON(value, d)
+}
+
+func (w PlanWeeks) Value() (driver.Value, error) {
+	return json.Marshal(w)
+}
+
+func (w *PlanWeeks) Scan(value interface{}) error {
+	return scanJSON(value, w)
+}
@@ -3,17 +3,22 @@ package model
 import "time"
 
 type User struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email" gorm:"unique"`
-	Password  string    `json:"password"`
-	IsAdmin   bool      `json:"is_admin"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                  uint       `json:"id" gorm:"primaryKey"`
+	Name                string     `json:"name"`
+	Email               string     `json:"email" gorm:"unique"`
+	Password            string     `json:"password"`
+	IsAdmin             bool       `json:"is_admin"`
+	DisabledAt          *time.Time `json:"disabled_at"`       // 管理者が無効にした日時（有効な場合は null）
+	EmailVerifiedAt     *time.Time `json:"email_verified_at"` // メールアドレスを確認した日時（未確認の場合は null）
+	FailedLoginAttempts int        `json:"-"`                 // 続けてパスワードを間違えた回数（ログインに成功すると 0 に戻す）
+	LockedUntil         *time.Time `json:"-"`                 // ログインの失敗が続いてロックしている期限
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
 }
 
 type UserResponse struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Name      string    `json:"name"`
-	Email     string	`json:"email" gorm:"unique"`
+	ID            uint   `json:"id" gorm:"primaryKey"`
+	Name          string `json:"name"`
+	Email         string `json:"email" gorm:"unique"`
+	EmailVerified bool   `json:"email_verified"`
 }
@@ -0,0 +1,43 @@
+package model
+
+import "time"
+
+// UserIdentity はユーザーに紐づけた外部のサービス（Google・Apple・Strava）のアカウント
+type UserIdentity struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"subject"` // サービス内で変わらないアカウントの ID
+	Email       string     `json:"email"`   // サービスから取得したメールアドレス（取得できない場合は空）
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+
+	User   User `json:"-" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	UserId uint `json:"user_id"`
+}
+
+type UserIdentityResponse struct {
+	Provider    string     `json:"provider"`
+	Email       string     `json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// UserIdentitiesResponse は紐づけたアカウントと、ログインに使えるプロバイダーの一覧
+type UserIdentitiesResponse struct {
+	Identities  []UserIdentityResponse `json:"identities"`
+	Providers   []string               `json:"providers"`
+	HasPassword bool                   `json:"has_password"` // パスワードでもログインできるか
+}
+
+// 外部のアカウントでのログインの結果
+const (
+	OAuthLoggedIn = "logged_in" // 紐づけたアカウントでログインした
+	OAuthSignedUp = "signed_up" // 新しいユーザーを作ってログインした
+	OAuthLinked   = "linked"    // ログイン中のユーザーに紐づけた
+)
+
+type OAuthResult struct {
+	Status   string
+	Provider string
+	Tokens   AuthTokens // ログインした場合のみ
+}
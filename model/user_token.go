@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// UserToken の用途
+const (
+	TokenEmailVerification = "email_verification"
+	TokenPasswordReset     = "password_reset"
+	TokenAccountDeletion   = "account_deletion"
+)
+
+// UserToken はメールで送る1回限りのトークン。トークンはハッシュのみを保存する
+type UserToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Purpose   string     `json:"purpose"`
+	TokenHash string     `json:"-"`
+	Email     string     `json:"email"` // 送信先のメールアドレス
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	User   User `json:"-" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	UserId uint `json:"user_id"`
+}
+
+type EmailVerificationRequest struct {
+	Token string `json:"token"`
+}
+
+type PasswordForgotRequest struct {
+	Email string `json:"email"`
+}
+
+type PasswordResetRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
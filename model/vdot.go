@@ -1,25 +1,82 @@
 package model
 
-import "time"
+import (
+	"go_vdot_api/pkg"
+	"time"
+)
 
 type Vdot struct {
-	ID            uint      `json:"id" gorm:"primaryKey"`
-	DistanceValue float64   `json:"distance_value"`
-	DistanceUnit  string    `json:"distance_unit"`
-	Time          string    `json:"time" gorm:"type:time"`
-	Elevation     *float64  `json:"elevation"`   // NULL を許容するためポインタ型
-	Temperature   *float64  `json:"temperature"` // NULL を許容するためポインタ型
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
-	User          User      `json:"user" gorm:"foreignKey:UserId; constraint:OnDelete:CASCADE"`
-	UserId        uint      `json:"user_id" gorm:"not null"`
+	ID            uint         `json:"id" gorm:"primaryKey"`
+	DistanceValue float64      `json:"distance_value"`
+	DistanceUnit  string       `json:"distance_unit"`
+	Time          string       `json:"time" gorm:"type:time"`
+	Elevation     *float64     `json:"elevation"`   // NULL を許容するためポインタ型
+	Temperature   *float64     `json:"temperature"` // NULL を許容するためポインタ型
+	RecordedAt    pkg.DateOnly `json:"recorded_at"` // レース・記録会の日付（例：2023-10-01）
+	IsPinned      bool         `json:"is_pinned"`   // 計算に使う記録として固定されているか
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+	User          User         `json:"user" gorm:"foreignKey:UserId; constraint:OnDelete:CASCADE"`
+	UserId        uint         `json:"user_id" gorm:"not null"`
 }
 
 type VdotResponse struct {
-	ID            uint     `json:"id" gorm:"primaryKey"`
-	DistanceValue float64  `json:"distance_value"`
-	DistanceUnit  string   `json:"distance_unit"`
-	Time          string   `json:"time" gorm:"type:time"`
-	Elevation     *float64 `json:"elevation"`
-	Temperature   *float64 `json:"temperature"`
+	ID            uint         `json:"id" gorm:"primaryKey"`
+	DistanceValue float64      `json:"distance_value"`
+	DistanceUnit  string       `json:"distance_unit"`
+	Time          string       `json:"time" gorm:"type:time"`
+	Elevation     *float64     `json:"elevation"`
+	Temperature   *float64     `json:"temperature"`
+	RecordedAt    pkg.DateOnly `json:"recorded_at"`
+	IsPinned      bool         `json:"is_pinned"`
+}
+
+// VdotHistoryResponse は記録一覧の1件分（記録ごとに算出したVDOTを含む）
+type VdotHistoryResponse struct {
+	VdotResponse
+	Vdot         float64 `json:"vdot"`
+	AdjustedVdot float64 `json:"adjusted_vdot"` // 暑さ・高地を補正したVDOT
+}
+
+// VdotValueResponse はVDOTの算出結果（/api/v2 のレスポンス）
+type VdotValueResponse struct {
+	Record             *VdotResponse               `json:"record"` // 算出に使った記録（VDOTを直接指定した場合は null）
+	Vdot               float64                     `json:"vdot"`
+	AdjustedVdot       float64                     `json:"adjusted_vdot"`
+	Vo2                *float64                    `json:"vo2"`            // 酸素摂取量（ml/kg/分）
+	PercentVo2max      *float64                    `json:"percent_vo2max"` // 例：0.95
+	Velocity           *float64                    `json:"velocity"`       // 速度（m/分）
+	PaceZones          []PaceZone                  `json:"pace_zones"`
+	RacePredictions    []RacePrediction            `json:"race_predictions"`
+	TrainingConditions *TrainingConditionsResponse `json:"training_conditions"`
+}
+
+// PaceZone はゾーン（E, M, T, I, R）ごとの距離別ペース
+type PaceZone struct {
+	Zone  string     `json:"zone"`
+	Paces []ZonePace `json:"paces"`
+}
+
+type ZonePace struct {
+	Distance       string   `json:"distance"`        // 例：1Km
+	DistanceMeters float64  `json:"distance_meters"` // 例：1000
+	LowerPace      string   `json:"lower_pace"`      // 例：04:18
+	UpperPace      *string  `json:"upper_pace"`      // 上限のないゾーンは null
+	LowerSeconds   float64  `json:"lower_seconds"`
+	UpperSeconds   *float64 `json:"upper_seconds"`
+}
+
+type RacePrediction struct {
+	Race             string  `json:"race"`
+	DistanceMeters   float64 `json:"distance_meters"`
+	PredictedTime    string  `json:"predicted_time"` // 例：00:19:57
+	PredictedSeconds float64 `json:"predicted_seconds"`
+	PacePerKm        string  `json:"pace_per_km"` // 例：03:59 /km
+	PacePerKmSeconds float64 `json:"pace_per_km_seconds"`
+}
+
+type TrainingConditionsResponse struct {
+	Temperature *float64 `json:"temperature"`
+	Elevation   *float64 `json:"elevation"`
+	PaceFactor  float64  `json:"pace_factor"`
 }
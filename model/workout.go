@@ -1,34 +1,132 @@
 package model
 
 import (
-	"time"
 	"go_vdot_api/pkg"
+	"go_vdot_api/pkg/workoutparser"
+	"time"
+
+	"gorm.io/gorm"
 )
-	
 
 type Workout struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Date        pkg.DateOnly `json:"date"`         // 練習日（例：2023-10-01）
-	StartTime   string    `json:"start_time"`   // 練習開始時刻（例："07:30"）
-	Workout     string    `json:"workout"`      // 練習内容（例：E3.2km, 6x(I800m・レスト2分）, E3.2km）
-	LapTime     *string    `json:"lap_time"`     // ラップタイム（例：[3:30, 3:40, 3:50]）
-	Mileage     float64   `json:"mileage"`      // 練習距離（例：10, 20.2）
-	MileageUnit string    `json:"mileage_unit"` // 練習距離の単位（例：km, mile）
-	Weather     string    `json:"weather"`      // 天候（例：晴れ、曇り、雨）
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	Date          pkg.DateOnly   `json:"date"`           // 練習日（例：2023-10-01）
+	StartTime     string         `json:"start_time"`     // 練習開始時刻（例："07:30"）
+	Workout       string         `json:"workout"`        // 練習内容（例：E3.2km, 6x(I800m・レスト2分）, E3.2km）
+	LapTime       *string        `json:"lap_time"`       // ラップタイム（例：[3:30, 3:40, 3:50]）
+	Mileage       float64        `json:"mileage"`        // 練習距離（例：10, 20.2）
+	MileageUnit   string         `json:"mileage_unit"`   // 練習距離の単位（例：km, mile）
+	Weather       string         `json:"weather"`        // 天候（例：晴れ、曇り、雨）
+	ElevationGain *float64       `json:"elevation_gain"` // 獲得標高（m）。記録ファイルから取り込んだ場合のみ
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 
 	User   User `json:"user" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
 	UserId uint `json:"user_id"`
 }
 
 type WorkoutResponse struct {
-	ID          uint      `json:"id"`
-	Date        pkg.DateOnly `json:"date"`         // 練習日（例：2023-10-01）
-	StartTime   string    `json:"start_time"`   // 練習開始時刻（例："07:30"）
-	Workout     string    `json:"workout"`      // 練習内容（例：E3.2km, 6x(I800m・レスト2分）, E3.2km）
-	LapTime     *string    `json:"lap_time"`     // ラップタイム（例：[3:30, 3:40, 3:50]）
-	Mileage     float64   `json:"mileage"`      // 練習距離（例：10, 20.2）
-	MileageUnit string    `json:"mileage_unit"` // 練習距離の単位（例：km, mile）
-	Weather     string    `json:"weather"`      // 天候（例：晴れ、曇り、雨）
+	ID            uint                    `json:"id"`
+	Date          pkg.DateOnly            `json:"date"`           // 練習日（例：2023-10-01）
+	StartTime     string                  `json:"start_time"`     // 練習開始時刻（例："07:30"）
+	Workout       string                  `json:"workout"`        // 練習内容（例：E3.2km, 6x(I800m・レスト2分）, E3.2km）
+	LapTime       *string                 `json:"lap_time"`       // ラップタイム（例：[3:30, 3:40, 3:50]）
+	Mileage       float64                 `json:"mileage"`        // 練習距離（例：10, 20.2）
+	MileageUnit   string                  `json:"mileage_unit"`   // 練習距離の単位（例：km, mile）
+	Weather       string                  `json:"weather"`        // 天候（例：晴れ、曇り、雨）
+	ElevationGain *float64                `json:"elevation_gain"` // 獲得標高（m）
+	Segments      []workoutparser.Segment `json:"segments"`       // 練習内容を解析した結果（解析できない場合は null）
+}
+
+// WorkoutListResponse は期間を指定した練習一覧の1ページ分
+type WorkoutListResponse struct {
+	Workouts   []WorkoutResponse `json:"workouts"`
+	NextCursor *string           `json:"next_cursor"` // 次のページを取得するためのカーソル（最後のページは null）
+}
+
+// WorkoutImportResponse は記録ファイル（GPX, TCX, FIT）から作った練習
+// 確認前（confirm=false）は保存せずに内容だけを返す
+type WorkoutImportResponse struct {
+	Format          string             `json:"format"` // gpx, tcx, fit
+	Saved           bool               `json:"saved"`
+	StartTime       time.Time          `json:"start_time"`
+	DistanceMeters  float64            `json:"distance_meters"`
+	DurationSeconds float64            `json:"duration_seconds"`
+	ElevationGain   float64            `json:"elevation_gain"`
+	Laps            []WorkoutImportLap `json:"laps"`
+	Workout         WorkoutResponse    `json:"workout"` // 保存する（した）練習。保存前は id が 0
+}
+
+type WorkoutImportLap struct {
+	Lap             int     `json:"lap"` // 何本目のラップか（1始まり）
+	DistanceMeters  float64 `json:"distance_meters"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+// WorkoutAnalysisResponse はラップタイムとVDOTのペースゾーンとの比較結果
+type WorkoutAnalysisResponse struct {
+	WorkoutID     uint          `json:"workout_id"`
+	Vdot          float64       `json:"vdot"` // 比較に使ったVDOT
+	Laps          []LapAnalysis `json:"laps"`
+	OnTarget      int           `json:"on_target"`
+	TooFast       int           `json:"too_fast"`
+	TooSlow       int           `json:"too_slow"`
+	NotComparable int           `json:"not_comparable"`
+}
+
+// LapAnalysis は1本分のラップの評価
+type LapAnalysis struct {
+	Lap                  int      `json:"lap"`           // 何本目のラップか（1始まり）
+	SegmentIndex         int      `json:"segment_index"` // 対応する練習内容の区切り（1始まり）
+	Repetition           int      `json:"repetition"`    // 区切りの中で何本目か（1始まり）
+	Zone                 string   `json:"zone"`
+	DistanceMeters       *float64 `json:"distance_meters"`
+	DurationSeconds      *float64 `json:"duration_seconds"`
+	LapSeconds           float64  `json:"lap_seconds"`
+	TargetFastestSeconds *float64 `json:"target_fastest_seconds"` // 目標タイムの範囲（距離で指定した本のみ）
+	TargetSlowestSeconds *float64 `json:"target_slowest_seconds"`
+	DiffSeconds          *float64 `json:"diff_seconds"` // 目標範囲からのずれ（+は遅い、-は速い、範囲内は 0）
+	Status               string   `json:"status"`       // on_target, too_fast, too_slow, not_comparable
+}
+
+// WorkoutAggregate は期間ごとに集計した練習（距離は km）
+type WorkoutAggregate struct {
+	Period     string  `json:"period"`
+	Sessions   int     `json:"sessions"`
+	DistanceKm float64 `json:"distance_km"`
+	LongestKm  float64 `json:"longest_km"`
+}
+
+// WorkoutStatsResponse は期間ごとの走行距離の集計（距離は unit の単位）
+type WorkoutStatsResponse struct {
+	Unit          string               `json:"unit"`
+	From          pkg.DateOnly         `json:"from"`
+	To            pkg.DateOnly         `json:"to"`
+	TotalDistance float64              `json:"total_distance"`
+	Sessions      int                  `json:"sessions"`
+	LongestRun    *WorkoutLongestRun   `json:"longest_run"` // 練習がない場合は null
+	Weeks         []WorkoutWeekStats   `json:"weeks"`       // ISO週（例：2024-W05）
+	Months        []WorkoutPeriodStats `json:"months"`      // 例：2024-02
+	Years         []WorkoutPeriodStats `json:"years"`       // 例：2024
+}
+
+type WorkoutPeriodStats struct {
+	Period     string  `json:"period"`
+	Distance   float64 `json:"distance"`
+	Sessions   int     `json:"sessions"`
+	LongestRun float64 `json:"longest_run"`
+}
+
+// WorkoutWeekStats は前週比を含めた週ごとの集計
+type WorkoutWeekStats struct {
+	WorkoutPeriodStats
+	Change        *float64 `json:"change"`         // 前週との差（最初の週は null）
+	ChangePercent *float64 `json:"change_percent"` // 前週比（%）。前週が 0 の場合は null
+}
+
+type WorkoutLongestRun struct {
+	WorkoutID uint         `json:"workout_id"`
+	Date      pkg.DateOnly `json:"date"`
+	Distance  float64      `json:"distance"`
 }
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ZoneProfile はペースゾーンの定義（ゾーンごとの%と、ペースを出す距離の一覧）
+type ZoneProfile struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	Name      string          `json:"name" gorm:"type:varchar(50);not null"`
+	Zones     ZoneDefinitions `json:"zones" gorm:"type:json;not null"`
+	Distances ZoneDistances   `json:"distances" gorm:"type:json;not null"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+
+	User   User `json:"user" gorm:"foreignKey:UserId;constraint:OnDelete:CASCADE"`
+	UserId uint `json:"user_id"`
+}
+
+// ZoneDefinition は1つのゾーン（例：E 70〜77%）。Upper が nil のゾーンは単一のペース
+type ZoneDefinition struct {
+	Zone  string   `json:"zone"`
+	Lower float64  `json:"lower"`
+	Upper *float64 `json:"upper"`
+}
+
+// ZoneDistance はペースを出す距離（例：1Km = 1000m）
+type ZoneDistance struct {
+	Label  string  `json:"label"`
+	Meters float64 `json:"meters"`
+}
+
+type ZoneDefinitions []ZoneDefinition
+
+type ZoneDistances []ZoneDistance
+
+type ZoneProfileResponse struct {
+	ID        uint            `json:"id"`
+	Name      string          `json:"name"`
+	Zones     ZoneDefinitions `json:"zones"`
+	Distances ZoneDistances   `json:"distances"`
+	IsBuiltin bool            `json:"is_builtin"` // Daniels のデフォルト（編集・削除不可）
+	IsShared  bool            `json:"is_shared"`  // 承認したコーチのゾーン定義（選手のペースに使えるが、選手は編集・削除不可）
+}
+
+// GORM対応（JSONカラムとして保存）
+func (z ZoneDefinitions) Value() (driver.Value, error) {
+	return json.Marshal(z)
+}
+
+func (z *ZoneDefinitions) Scan(value interface{}) error {
+	return scanJSON(value, z)
+}
+
+func (d ZoneDistances) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+func (d *ZoneDistances) Scan(value interface{}) error {
+	return scanJSON(value, d)
+}
+
+func scanJSON(value interface{}, dest interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, dest)
+	case string:
+		return json.Unmarshal([]byte(v), dest)
+	}
+	return fmt.Errorf("cannot convert %v to JSON", value)
+}
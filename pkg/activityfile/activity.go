@@ -0,0 +1,184 @@
+// Package activityfile は GPS ウォッチの記録ファイル（GPX, TCX, FIT）から
+// 開始時刻・距離・ラップ・獲得標高を取り出す。外部のライブラリは使わない。
+package activityfile
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ファイルの形式
+const (
+	FormatGPX = "gpx"
+	FormatTCX = "tcx"
+	FormatFIT = "fit"
+)
+
+// Activity はファイルから取り出した1回分の練習
+type Activity struct {
+	Format          string
+	StartTime       time.Time
+	DistanceMeters  float64
+	DurationSeconds float64
+	ElevationGain   float64 // 獲得標高（m）
+	Laps            []Lap
+}
+
+// Lap は1周分の記録
+type Lap struct {
+	DistanceMeters  float64
+	DurationSeconds float64
+}
+
+// 獲得標高を計算する際に無視する上下の幅（m）。GPS の標高のぶれを積み上げないため
+const elevationThreshold = 2.0
+
+// GPX にはラップがないため、この距離（m）ごとに区切ってラップとする
+const splitMeters = 1000.0
+
+var ErrUnknownFormat = errors.New("unsupported file format. Use .gpx, .tcx or .fit")
+
+// Parse はファイル名の拡張子（わからない場合は中身）から形式を判定して解析する
+func Parse(filename string, data []byte) (*Activity, error) {
+	format := DetectFormat(filename, data)
+	var (
+		activity *Activity
+		err      error
+	)
+	switch format {
+	case FormatGPX:
+		activity, err = parseGPX(data)
+	case FormatTCX:
+		activity, err = parseTCX(data)
+	case FormatFIT:
+		activity, err = parseFIT(data)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	activity.Format = format
+	if activity.StartTime.IsZero() {
+		return nil, errors.New("start time not found in the file")
+	}
+	return activity, nil
+}
+
+// DetectFormat はファイルの形式を返す（判定できない場合は空文字）
+func DetectFormat(filename string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".gpx":
+		return FormatGPX
+	case ".tcx":
+		return FormatTCX
+	case ".fit":
+		return FormatFIT
+	}
+	if len(data) >= 12 && string(data[8:12]) == ".FIT" {
+		return FormatFIT
+	}
+	head := data
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	switch {
+	case bytes.Contains(head, []byte("<gpx")):
+		return FormatGPX
+	case bytes.Contains(head, []byte("<TrainingCenterDatabase")):
+		return FormatTCX
+	}
+	return ""
+}
+
+// trackPoint は GPX, TCX の記録点
+type trackPoint struct {
+	time      time.Time
+	lat, lon  *float64
+	elevation *float64
+	distance  *float64 // 開始からの距離（記録されている場合のみ）
+}
+
+// elevationGain は標高の上りの合計を返す（elevationThreshold 未満の上下は無視する）
+func elevationGain(points []trackPoint) float64 {
+	gain, base, found := 0.0, 0.0, false
+	for _, p := range points {
+		if p.elevation == nil {
+			continue
+		}
+		if !found {
+			base, found = *p.elevation, true
+			continue
+		}
+		switch diff := *p.elevation - base; {
+		case diff >= elevationThreshold:
+			gain += diff
+			base = *p.elevation
+		case diff <= -elevationThreshold:
+			base = *p.elevation
+		}
+	}
+	return math.Round(gain*10) / 10
+}
+
+// cumulativeDistances は記録点ごとの開始からの距離を返す（記録されていなければ緯度経度から計算する）
+func cumulativeDistances(points []trackPoint) []float64 {
+	distances := make([]float64, len(points))
+	total := 0.0
+	var prev *trackPoint
+	for i := range points {
+		p := &points[i]
+		if p.distance != nil {
+			total = *p.distance
+		} else if prev != nil && prev.lat != nil && p.lat != nil {
+			total += haversine(*prev.lat, *prev.lon, *p.lat, *p.lon)
+		}
+		distances[i] = total
+		if p.lat != nil {
+			prev = p
+		}
+	}
+	return distances
+}
+
+// splitLaps は splitMeters ごとのラップを作る（10m 以上の端数も最後のラップにする）
+func splitLaps(points []trackPoint, distances []float64) []Lap {
+	if len(points) < 2 {
+		return nil
+	}
+	var laps []Lap
+	lapStartTime := points[0].time
+	lapStartDistance := distances[0]
+	for i := 1; i < len(points); i++ {
+		for distances[i]-lapStartDistance >= splitMeters {
+			// 区切りの距離を通過した時刻を直前の点との間で補間する
+			target := lapStartDistance + splitMeters
+			ratio := 1.0
+			if span := distances[i] - distances[i-1]; span > 0 {
+				ratio = (target - distances[i-1]) / span
+			}
+			at := points[i-1].time.Add(time.Duration(float64(points[i].time.Sub(points[i-1].time)) * ratio))
+			laps = append(laps, Lap{DistanceMeters: splitMeters, DurationSeconds: at.Sub(lapStartTime).Seconds()})
+			lapStartTime = at
+			lapStartDistance = target
+		}
+	}
+	if rest := distances[len(distances)-1] - lapStartDistance; rest >= 10 {
+		laps = append(laps, Lap{DistanceMeters: rest, DurationSeconds: points[len(points)-1].time.Sub(lapStartTime).Seconds()})
+	}
+	return laps
+}
+
+// haversine は2点間の距離（m）を返す
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadius = 6371008.8
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
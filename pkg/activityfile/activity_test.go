@@ -0,0 +1,109 @@
+package activityfile
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func assertNear(t *testing.T, name string, got, want, tolerance float64) {
+	t.Helper()
+	if math.Abs(got-want) > tolerance {
+		t.Errorf("%s = %v, want %v (±%v)", name, got, want, tolerance)
+	}
+}
+
+func TestParseGPX(t *testing.T) {
+	activity, err := Parse("run.gpx", readTestdata(t, "sample.gpx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if activity.Format != FormatGPX {
+		t.Errorf("Format = %q, want %q", activity.Format, FormatGPX)
+	}
+	if got := activity.StartTime.Format(time.RFC3339); got != "2024-05-12T07:30:00+09:00" {
+		t.Errorf("StartTime = %s", got)
+	}
+	// 緯度 0.005 度ずつ4区間
+	assertNear(t, "DistanceMeters", activity.DistanceMeters, 2223.9, 1)
+	assertNear(t, "DurationSeconds", activity.DurationSeconds, 600, 0)
+	// 10 → 13 (+3) → 12 → 16 (+3 from 13) → 15
+	assertNear(t, "ElevationGain", activity.ElevationGain, 6, 0)
+
+	if len(activity.Laps) != 3 {
+		t.Fatalf("len(Laps) = %d, want 3", len(activity.Laps))
+	}
+	assertNear(t, "Laps[0].DistanceMeters", activity.Laps[0].DistanceMeters, 1000, 0)
+	assertNear(t, "Laps[1].DistanceMeters", activity.Laps[1].DistanceMeters, 1000, 0)
+	assertNear(t, "Laps[2].DistanceMeters", activity.Laps[2].DistanceMeters, 223.9, 1)
+	assertNear(t, "Laps[0].DurationSeconds", activity.Laps[0].DurationSeconds, 269.8, 0.5)
+	total := 0.0
+	for _, lap := range activity.Laps {
+		total += lap.DurationSeconds
+	}
+	assertNear(t, "sum of lap durations", total, 600, 0.01)
+}
+
+func TestParseTCX(t *testing.T) {
+	activity, err := Parse("run.tcx", readTestdata(t, "sample.tcx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if activity.Format != FormatTCX {
+		t.Errorf("Format = %q, want %q", activity.Format, FormatTCX)
+	}
+	if !activity.StartTime.Equal(time.Date(2024, 6, 1, 6, 0, 0, 0, time.UTC)) {
+		t.Errorf("StartTime = %s", activity.StartTime)
+	}
+	assertNear(t, "DistanceMeters", activity.DistanceMeters, 1600, 0)
+	assertNear(t, "DurationSeconds", activity.DurationSeconds, 390, 0)
+	// 100 → 103 (+3) → 101 → 105 (+4)
+	assertNear(t, "ElevationGain", activity.ElevationGain, 7, 0)
+
+	want := []Lap{{DistanceMeters: 1000, DurationSeconds: 240}, {DistanceMeters: 600, DurationSeconds: 150}}
+	if len(activity.Laps) != len(want) {
+		t.Fatalf("len(Laps) = %d, want %d", len(activity.Laps), len(want))
+	}
+	for i, lap := range want {
+		if activity.Laps[i] != lap {
+			t.Errorf("Laps[%d] = %+v, want %+v", i, activity.Laps[i], lap)
+		}
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	cases := []struct {
+		filename string
+		file     string
+		want     string
+	}{
+		{"run.GPX", "sample.gpx", FormatGPX},
+		{"upload", "sample.gpx", FormatGPX},
+		{"upload", "sample.tcx", FormatTCX},
+		{"upload", "sample.fit", FormatFIT},
+		{"run.txt", "", ""},
+	}
+	for _, c := range cases {
+		var data []byte
+		if c.file != "" {
+			data = readTestdata(t, c.file)
+		}
+		if got := DetectFormat(c.filename, data); got != c.want {
+			t.Errorf("DetectFormat(%q, %s) = %q, want %q", c.filename, c.file, got, c.want)
+		}
+	}
+	if _, err := Parse("run.txt", []byte("hello")); err != ErrUnknownFormat {
+		t.Errorf("Parse(run.txt) error = %v, want ErrUnknownFormat", err)
+	}
+}
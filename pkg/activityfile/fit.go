@@ -0,0 +1,301 @@
+package activityfile
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// FIT の時刻の基準（1989-12-31 00:00:00 UTC からの秒数）
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// 使用するメッセージの番号（FIT SDK の global message number）
+const (
+	fitMesgSession  = 18
+	fitMesgLap      = 19
+	fitMesgRecord   = 20
+	fitMesgActivity = 34
+)
+
+// 使用するフィールドの番号
+const (
+	fitFieldTimestamp        = 253
+	fitFieldStartTime        = 2  // session, lap
+	fitFieldTotalElapsedTime = 7  // session, lap（ミリ秒）
+	fitFieldTotalTimerTime   = 8  // session, lap（ミリ秒、停止中を除く）
+	fitFieldTotalDistance    = 9  // session, lap（cm）
+	fitFieldTotalAscent      = 22 // session（m）
+	fitFieldRecordAltitude   = 2  // record（(m + 500) * 5）
+	fitFieldRecordDistance   = 5  // record（cm）
+	fitFieldEnhancedAltitude = 78 // record（(m + 500) * 5）
+	fitFieldLocalTimestamp   = 5  // activity
+)
+
+type fitFieldDef struct {
+	num  byte
+	size byte
+}
+
+type fitDefinition struct {
+	global    uint16
+	order     binary.ByteOrder
+	fields    []fitFieldDef
+	devFields int // 開発者フィールドの合計バイト数（読み飛ばす）
+}
+
+// fitMessage はフィールド番号ごとの値（符号なし整数として読み、無効値は含めない）
+type fitMessage map[byte]uint64
+
+// parseFIT は FIT のバイナリを解析し、session と lap のメッセージ（なければ record）から練習を作る
+func parseFIT(data []byte) (*Activity, error) {
+	if len(data) < 12 {
+		return nil, errors.New("invalid fit: file is too short")
+	}
+	headerSize := int(data[0])
+	if (headerSize != 12 && headerSize != 14) || string(data[8:12]) != ".FIT" {
+		return nil, errors.New("invalid fit: header not found")
+	}
+	dataSize := int(binary.LittleEndian.Uint32(data[4:8]))
+	end := headerSize + dataSize
+	if len(data) < end {
+		return nil, errors.New("invalid fit: file is truncated")
+	}
+	if len(data) >= end+2 {
+		if crc := binary.LittleEndian.Uint16(data[end : end+2]); crc != fitCRC(data[:end]) {
+			return nil, errors.New("invalid fit: crc mismatch")
+		}
+	}
+
+	var sessions, laps, records, activities []fitMessage
+	definitions := map[byte]*fitDefinition{}
+	var lastTimestamp uint32
+	pos := headerSize
+	for pos < end {
+		header := data[pos]
+		pos++
+
+		var local byte
+		var compressedOffset *byte
+		switch {
+		case header&0x80 != 0:
+			// 時刻を圧縮したヘッダー（データメッセージ）
+			local = (header >> 5) & 0x03
+			offset := header & 0x1F
+			compressedOffset = &offset
+		case header&0x40 != 0:
+			// 定義メッセージ
+			def, n, err := readFitDefinition(data[pos:end], header&0x20 != 0)
+			if err != nil {
+				return nil, err
+			}
+			definitions[header&0x0F] = def
+			pos += n
+			continue
+		default:
+			local = header & 0x0F
+		}
+
+		def, ok := definitions[local]
+		if !ok {
+			return nil, fmt.Errorf("invalid fit: data message without definition (local %d)", local)
+		}
+		msg, n, err := readFitData(data[pos:end], def)
+		if err != nil {
+			return nil, err
+		}
+		pos += n
+
+		if ts, ok := msg[fitFieldTimestamp]; ok {
+			lastTimestamp = uint32(ts)
+		} else if compressedOffset != nil {
+			ts := lastTimestamp&^0x1F + uint32(*compressedOffset)
+			if uint32(*compressedOffset) < lastTimestamp&0x1F {
+				ts += 0x20
+			}
+			lastTimestamp = ts
+			msg[fitFieldTimestamp] = uint64(ts)
+		}
+
+		switch def.global {
+		case fitMesgSession:
+			sessions = append(sessions, msg)
+		case fitMesgLap:
+			laps = append(laps, msg)
+		case fitMesgRecord:
+			records = append(records, msg)
+		case fitMesgActivity:
+			activities = append(activities, msg)
+		}
+	}
+
+	activity := &Activity{}
+	points := fitTrackPoints(records)
+
+	if len(sessions) > 0 {
+		s := sessions[0]
+		if v, ok := s[fitFieldStartTime]; ok {
+			activity.StartTime = fitTime(v)
+		}
+		activity.DistanceMeters = float64(s[fitFieldTotalDistance]) / 100
+		activity.DurationSeconds = fitDuration(s)
+		if v, ok := s[fitFieldTotalAscent]; ok {
+			activity.ElevationGain = float64(v)
+		} else {
+			activity.ElevationGain = elevationGain(points)
+		}
+	} else if len(points) > 0 {
+		distances := cumulativeDistances(points)
+		activity.StartTime = points[0].time
+		activity.DistanceMeters = distances[len(distances)-1]
+		activity.DurationSeconds = points[len(points)-1].time.Sub(points[0].time).Seconds()
+		activity.ElevationGain = elevationGain(points)
+	}
+
+	for _, l := range laps {
+		activity.Laps = append(activity.Laps, Lap{
+			DistanceMeters:  float64(l[fitFieldTotalDistance]) / 100,
+			DurationSeconds: fitDuration(l),
+		})
+	}
+	if len(laps) == 0 && len(points) > 0 {
+		activity.Laps = splitLaps(points, cumulativeDistances(points))
+	}
+
+	// activity に現地時刻があれば、開始時刻をその時差のタイムゾーンにする
+	if len(activities) > 0 && !activity.StartTime.IsZero() {
+		utc, okUTC := activities[0][fitFieldTimestamp]
+		local, okLocal := activities[0][fitFieldLocalTimestamp]
+		if okUTC && okLocal {
+			offset := int(int64(local) - int64(utc))
+			activity.StartTime = activity.StartTime.In(time.FixedZone("", offset))
+		}
+	}
+	return activity, nil
+}
+
+func readFitDefinition(b []byte, hasDevFields bool) (*fitDefinition, int, error) {
+	if len(b) < 5 {
+		return nil, 0, errors.New("invalid fit: definition is truncated")
+	}
+	def := &fitDefinition{order: binary.LittleEndian}
+	if b[1] == 1 {
+		def.order = binary.BigEndian
+	}
+	def.global = def.order.Uint16(b[2:4])
+	count := int(b[4])
+	pos := 5
+	if len(b) < pos+count*3 {
+		return nil, 0, errors.New("invalid fit: definition is truncated")
+	}
+	for i := 0; i < count; i++ {
+		def.fields = append(def.fields, fitFieldDef{num: b[pos], size: b[pos+1]})
+		pos += 3
+	}
+	if hasDevFields {
+		if len(b) < pos+1 {
+			return nil, 0, errors.New("invalid fit: definition is truncated")
+		}
+		devCount := int(b[pos])
+		pos++
+		if len(b) < pos+devCount*3 {
+			return nil, 0, errors.New("invalid fit: definition is truncated")
+		}
+		for i := 0; i < devCount; i++ {
+			def.devFields += int(b[pos+1])
+			pos += 3
+		}
+	}
+	return def, pos, nil
+}
+
+func readFitData(b []byte, def *fitDefinition) (fitMessage, int, error) {
+	msg := fitMessage{}
+	pos := 0
+	for _, f := range def.fields {
+		size := int(f.size)
+		if len(b) < pos+size {
+			return nil, 0, errors.New("invalid fit: data is truncated")
+		}
+		if v, ok := fitUint(b[pos:pos+size], def.order); ok {
+			msg[f.num] = v
+		}
+		pos += size
+	}
+	if len(b) < pos+def.devFields {
+		return nil, 0, errors.New("invalid fit: data is truncated")
+	}
+	return msg, pos + def.devFields, nil
+}
+
+// fitUint は 1, 2, 4 バイトの符号なし整数を読む（すべてのビットが1の無効値の場合は false）
+func fitUint(b []byte, order binary.ByteOrder) (uint64, bool) {
+	switch len(b) {
+	case 1:
+		return uint64(b[0]), b[0] != 0xFF
+	case 2:
+		v := order.Uint16(b)
+		return uint64(v), v != 0xFFFF
+	case 4:
+		v := order.Uint32(b)
+		return uint64(v), v != 0xFFFFFFFF
+	}
+	return 0, false
+}
+
+func fitTime(v uint64) time.Time {
+	return fitEpoch.Add(time.Duration(v) * time.Second)
+}
+
+// fitDuration は停止中を除いた時間（なければ経過時間）を秒で返す
+func fitDuration(msg fitMessage) float64 {
+	if v, ok := msg[fitFieldTotalTimerTime]; ok {
+		return float64(v) / 1000
+	}
+	return float64(msg[fitFieldTotalElapsedTime]) / 1000
+}
+
+func fitTrackPoints(records []fitMessage) []trackPoint {
+	var points []trackPoint
+	for _, r := range records {
+		ts, ok := r[fitFieldTimestamp]
+		if !ok {
+			continue
+		}
+		p := trackPoint{time: fitTime(ts)}
+		if v, ok := r[fitFieldRecordDistance]; ok {
+			d := float64(v) / 100
+			p.distance = &d
+		}
+		altitude, ok := r[fitFieldEnhancedAltitude]
+		if !ok {
+			altitude, ok = r[fitFieldRecordAltitude]
+		}
+		if ok {
+			e := math.Round((float64(altitude)/5-500)*10) / 10
+			p.elevation = &e
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+var fitCRCTable = [16]uint16{
+	0x0000, 0xCC01, 0xD801, 0x1400, 0xF001, 0x3C00, 0x2800, 0xE401,
+	0xA001, 0x6C00, 0x7800, 0xB401, 0x5000, 0x9C01, 0x8801, 0x4400,
+}
+
+// fitCRC は FIT の CRC-16 を計算する
+func fitCRC(b []byte) uint16 {
+	var crc uint16
+	for _, c := range b {
+		tmp := fitCRCTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ fitCRCTable[c&0xF]
+		tmp = fitCRCTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ fitCRCTable[(c>>4)&0xF]
+	}
+	return crc
+}
@@ -0,0 +1,80 @@
+package activityfile
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+)
+
+// buildFIT はメッセージ部分にヘッダーと CRC を付けて FIT ファイルにする
+func buildFIT(body []byte) []byte {
+	header := []byte{14, 0x20, 0x08, 0x08}
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(body)))
+	header = append(header, ".FIT"...)
+	header = binary.LittleEndian.AppendUint16(header, fitCRC(header))
+	file := append(header, body...)
+	return binary.LittleEndian.AppendUint16(file, fitCRC(file))
+}
+
+func TestParseFIT(t *testing.T) {
+	activity, err := Parse("run.fit", readTestdata(t, "sample.fit"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if activity.Format != FormatFIT {
+		t.Errorf("Format = %q, want %q", activity.Format, FormatFIT)
+	}
+	// session の開始時刻は UTC、activity の local_timestamp との差から +09:00 にする
+	if got := activity.StartTime.Format(time.RFC3339); got != "2024-05-13T07:00:00+09:00" {
+		t.Errorf("StartTime = %s", got)
+	}
+	if got := activity.StartTime.Format("2006-01-02"); got != "2024-05-13" {
+		t.Errorf("local date = %s, want 2024-05-13", got)
+	}
+	assertNear(t, "DistanceMeters", activity.DistanceMeters, 5000, 0)
+	assertNear(t, "DurationSeconds", activity.DurationSeconds, 1250, 0)
+	assertNear(t, "ElevationGain", activity.ElevationGain, 42, 0)
+
+	want := []Lap{{DistanceMeters: 3000, DurationSeconds: 750}, {DistanceMeters: 2000, DurationSeconds: 500}}
+	if len(activity.Laps) != len(want) {
+		t.Fatalf("len(Laps) = %d, want %d", len(activity.Laps), len(want))
+	}
+	for i, lap := range want {
+		if activity.Laps[i] != lap {
+			t.Errorf("Laps[%d] = %+v, want %+v", i, activity.Laps[i], lap)
+		}
+	}
+}
+
+func TestParseFITInvalid(t *testing.T) {
+	sample := readTestdata(t, "sample.fit")
+
+	badCRC := append([]byte(nil), sample...)
+	badCRC[len(badCRC)-1] ^= 0xFF
+
+	// 定義メッセージ（0x40）の前に local 0 のデータメッセージがある
+	noDefinition := buildFIT([]byte{0x00, 0x01, 0x02, 0x03, 0x04})
+
+	// 定義では4バイトのフィールドだが、データが2バイトしかない
+	shortData := buildFIT([]byte{0x40, 0, 0, 20, 0, 1, 253, 4, 0x86, 0x00, 0x01, 0x02})
+
+	cases := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"too short", sample[:10], "too short"},
+		{"no header", append([]byte{14, 0x20, 0x08, 0x08, 0, 0, 0, 0}, "FIT."...), "header not found"},
+		{"truncated", sample[:len(sample)-20], "truncated"},
+		{"bad crc", badCRC, "crc mismatch"},
+		{"data without definition", noDefinition, "without definition"},
+		{"truncated message", shortData, "data is truncated"},
+	}
+	for _, c := range cases {
+		_, err := Parse("run.fit", c.data)
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s: error = %v, want %q", c.name, err, c.want)
+		}
+	}
+}
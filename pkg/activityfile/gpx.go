@@ -0,0 +1,58 @@
+package activityfile
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type gpxFile struct {
+	Tracks []struct {
+		Segments []struct {
+			Points []struct {
+				Lat       float64  `xml:"lat,attr"`
+				Lon       float64  `xml:"lon,attr"`
+				Elevation *float64 `xml:"ele"`
+				Time      string   `xml:"time"`
+			} `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+// parseGPX は GPX の記録点から距離・時間・獲得標高を計算し、1kmごとのラップを作る
+func parseGPX(data []byte) (*Activity, error) {
+	var f gpxFile
+	if err := xml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid gpx: %v", err)
+	}
+
+	var points []trackPoint
+	for _, trk := range f.Tracks {
+		for _, seg := range trk.Segments {
+			for _, pt := range seg.Points {
+				if pt.Time == "" {
+					continue
+				}
+				t, err := time.Parse(time.RFC3339, pt.Time)
+				if err != nil {
+					return nil, fmt.Errorf("invalid gpx time %q", pt.Time)
+				}
+				lat, lon := pt.Lat, pt.Lon
+				points = append(points, trackPoint{time: t, lat: &lat, lon: &lon, elevation: pt.Elevation})
+			}
+		}
+	}
+	if len(points) == 0 {
+		return nil, errors.New("gpx has no track points with time")
+	}
+
+	distances := cumulativeDistances(points)
+	return &Activity{
+		StartTime:       points[0].time,
+		DistanceMeters:  distances[len(distances)-1],
+		DurationSeconds: points[len(points)-1].time.Sub(points[0].time).Seconds(),
+		ElevationGain:   elevationGain(points),
+		Laps:            splitLaps(points, distances),
+	}, nil
+}
@@ -0,0 +1,79 @@
+package activityfile
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type tcxFile struct {
+	Activities []struct {
+		Laps []struct {
+			StartTime        string  `xml:"StartTime,attr"`
+			TotalTimeSeconds float64 `xml:"TotalTimeSeconds"`
+			DistanceMeters   float64 `xml:"DistanceMeters"`
+			Tracks           []struct {
+				Points []struct {
+					Time     string `xml:"Time"`
+					Position *struct {
+						Lat float64 `xml:"LatitudeDegrees"`
+						Lon float64 `xml:"LongitudeDegrees"`
+					} `xml:"Position"`
+					Altitude *float64 `xml:"AltitudeMeters"`
+					Distance *float64 `xml:"DistanceMeters"`
+				} `xml:"Trackpoint"`
+			} `xml:"Track"`
+		} `xml:"Lap"`
+	} `xml:"Activities>Activity"`
+}
+
+// parseTCX は TCX のラップ（Lap）をそのままラップとし、獲得標高は記録点から計算する
+func parseTCX(data []byte) (*Activity, error) {
+	var f tcxFile
+	if err := xml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid tcx: %v", err)
+	}
+	if len(f.Activities) == 0 || len(f.Activities[0].Laps) == 0 {
+		return nil, errors.New("tcx has no laps")
+	}
+
+	// 複数のアクティビティがある場合は最初の1つだけを取り込む
+	activity := &Activity{}
+	var points []trackPoint
+	for i, lap := range f.Activities[0].Laps {
+		if i == 0 {
+			start, err := time.Parse(time.RFC3339, lap.StartTime)
+			if err != nil {
+				return nil, fmt.Errorf("invalid tcx lap start time %q", lap.StartTime)
+			}
+			activity.StartTime = start
+		}
+		activity.Laps = append(activity.Laps, Lap{DistanceMeters: lap.DistanceMeters, DurationSeconds: lap.TotalTimeSeconds})
+		activity.DistanceMeters += lap.DistanceMeters
+		activity.DurationSeconds += lap.TotalTimeSeconds
+
+		for _, trk := range lap.Tracks {
+			for _, pt := range trk.Points {
+				t, err := time.Parse(time.RFC3339, pt.Time)
+				if err != nil {
+					continue
+				}
+				p := trackPoint{time: t, elevation: pt.Altitude, distance: pt.Distance}
+				if pt.Position != nil {
+					lat, lon := pt.Position.Lat, pt.Position.Lon
+					p.lat, p.lon = &lat, &lon
+				}
+				points = append(points, p)
+			}
+		}
+	}
+
+	// ラップに距離がない場合は記録点から計算する
+	if activity.DistanceMeters == 0 && len(points) > 0 {
+		distances := cumulativeDistances(points)
+		activity.DistanceMeters = distances[len(distances)-1]
+	}
+	activity.ElevationGain = elevationGain(points)
+	return activity, nil
+}
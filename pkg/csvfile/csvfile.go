@@ -0,0 +1,102 @@
+// Package csvfile は一括取り込み・書き出し用の CSV（1行目が列名）を読み書きする
+package csvfile
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Row は CSV の1行（ヘッダーを除いたデータ行）
+type Row struct {
+	Line   int // ファイル上の行番号（ヘッダーが1行目）
+	values map[string]string
+}
+
+// Get は列の値を前後の空白を除いて返す（列がない場合は空文字）
+func (r Row) Get(column string) string {
+	return strings.TrimSpace(r.values[column])
+}
+
+// Read は CSV を読み込む。columns にない列、required の列がない場合はエラーにする
+func Read(data []byte, columns []string, required []string) ([]Row, error) {
+	// Excel で保存した UTF-8 の CSV に付く BOM を取り除く
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("csv header is required")
+	}
+
+	header := make([]string, len(records[0]))
+	seen := map[string]bool{}
+	for i, name := range records[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !contains(columns, name) {
+			return nil, fmt.Errorf("unknown column %q (columns: %s)", name, strings.Join(columns, ","))
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		seen[name] = true
+		header[i] = name
+	}
+	for _, name := range required {
+		if !seen[name] {
+			return nil, fmt.Errorf("column %q is required", name)
+		}
+	}
+
+	rows := make([]Row, 0, len(records)-1)
+	for i, record := range records[1:] {
+		if isBlank(record) {
+			continue
+		}
+		row := Row{Line: i + 2, values: map[string]string{}}
+		for j, value := range record {
+			if j < len(header) {
+				row.values[header[j]] = value
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Write は columns をヘッダーとした CSV を作る
+func Write(columns []string, records [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(columns); err != nil {
+		return nil, err
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func isBlank(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+// Package mailer はメールの送信（SMTP・開発用のメモリ／ファイル）をまとめる
+package mailer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Message は送信するテキストメール
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// NewFromEnv は MAIL_DRIVER に応じた Mailer を作る
+//   - smtp:   SMTP_HOST / SMTP_PORT / SMTP_USERNAME / SMTP_PASSWORD / MAIL_FROM で送信する
+//   - file:   MAIL_DIR（既定 tmp/mail）に .eml として保存する
+//   - memory: メモリに保持するだけで送信しない（テスト用。明示した場合のみ）
+//
+// 未設定の場合は、メールが届かないまま動き続けないよう起動時のエラーにする
+func NewFromEnv() (Mailer, error) {
+	switch driver := os.Getenv("MAIL_DRIVER"); driver {
+	case "smtp":
+		port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+		if err != nil {
+			port = 587
+		}
+		return NewSMTPMailer(SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		})
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "tmp/mail"
+		}
+		from := os.Getenv("MAIL_FROM")
+		if from == "" {
+			from = "no-reply@localhost"
+		}
+		return NewFileMailer(dir, from)
+	case "memory":
+		return NewMemoryMailer(), nil
+	case "":
+		return nil, errors.New("MAIL_DRIVER is not set. Use smtp, file or memory")
+	default:
+		return nil, fmt.Errorf("unsupported MAIL_DRIVER: %s", driver)
+	}
+}
@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"go_vdot_api/pkg/logger"
+	"sync"
+)
+
+// MemoryMailer は送信したメールをメモリに保持する（テスト用）
+type MemoryMailer struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+// Send はメールを保持する
+// 本文には確認・再設定のトークンが含まれるため、ログには宛先と件名だけを出す
+func (m *MemoryMailer) Send(msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	logger.Info("mail to=%s subject=%s", msg.To, msg.Subject)
+	return nil
+}
+
+// Messages は送信したメールを古い順に返す
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
@@ -0,0 +1,69 @@
+// Package metrics はプロセス内で数えるカウンター（管理者向けの API で参照する）
+package metrics
+
+import (
+	"sync"
+)
+
+// CounterVec はラベルごとの回数を数える
+type CounterVec struct {
+	mu     sync.Mutex
+	values map[string]int64
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*CounterVec{}
+)
+
+// NewCounterVec はカウンターを作り、Snapshot に含まれるよう name で登録する
+func NewCounterVec(name string) *CounterVec {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	c := &CounterVec{values: map[string]int64{}}
+	registry[name] = c
+	return c
+}
+
+func (c *CounterVec) Inc(label string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[label]++
+}
+
+func (c *CounterVec) Get(label string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[label]
+}
+
+func (c *CounterVec) snapshot() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	values := make(map[string]int64, len(c.values))
+	for label, v := range c.values {
+		values[label] = v
+	}
+	return values
+}
+
+// Snapshot は登録されたすべてのカウンターの現在の値を返す（起動してからの累計）
+func Snapshot() map[string]map[string]int64 {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	res := make(map[string]map[string]int64, len(registry))
+	for name, c := range registry {
+		res[name] = c.snapshot()
+	}
+	return res
+}
+
+// ログインのカウンター
+var (
+	// LoginAttempts はログインの結果（success / invalid_credentials / disabled、外部のアカウントでのログインは oauth_<provider>）
+	LoginAttempts = NewCounterVec("login_attempts")
+	// LoginBlocked はパスワードを確認せずに拒否したログイン（ip_rate_limit / account_rate_limit / account_locked）
+	LoginBlocked = NewCounterVec("login_blocked")
+	// AccountLockouts はログインの失敗が続いてロックしたアカウントの数（locked）
+	AccountLockouts = NewCounterVec("account_lockouts")
+)
@@ -0,0 +1,187 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Identity は外部のサービスのアカウント
+type Identity struct {
+	Provider      string
+	Subject       string // サービス内で変わらないアカウントの ID
+	Email         string // 取得できない場合は空
+	EmailVerified bool
+	Name          string
+}
+
+// AuthRequest は認可リクエストごとに作る値（コールバックで同じものか確認する）
+type AuthRequest struct {
+	State        string // CSRF 対策
+	Nonce        string // ID トークンの再利用の対策（OpenID Connect のみ）
+	CodeVerifier string // PKCE
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func NewAuthRequest() (AuthRequest, error) {
+	var req AuthRequest
+	for _, v := range []*string{&req.State, &req.Nonce, &req.CodeVerifier} {
+		s, err := randomString()
+		if err != nil {
+			return AuthRequest{}, err
+		}
+		*v = s
+	}
+	return req, nil
+}
+
+// AuthCodeURL はユーザーを送る認可画面の URL
+func (p *Provider) AuthCodeURL(req AuthRequest) string {
+	challenge := sha256.Sum256([]byte(req.CodeVerifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {p.RedirectURL},
+		"scope":                 {strings.Join(p.Scopes, " ")},
+		"state":                 {req.State},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	if p.OIDC() {
+		q.Set("nonce", req.Nonce)
+	}
+	if p.ResponseMode != "" {
+		q.Set("response_mode", p.ResponseMode)
+	}
+	sep := "?"
+	if strings.Contains(p.AuthURL, "?") {
+		sep = "&"
+	}
+	return p.AuthURL + sep + q.Encode()
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+}
+
+// Exchange は認可コードをトークンに交換し、アカウントを取得する
+func (p *Provider) Exchange(ctx context.Context, code string, req AuthRequest) (Identity, error) {
+	if code == "" {
+		return Identity{}, errors.New("authorization code is missing")
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURL},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+		"code_verifier": {req.CodeVerifier},
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Identity{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	httpReq.Header.Set("Accept", "application/json")
+	token := tokenResponse{}
+	if err := doJSON(httpReq, &token); err != nil {
+		return Identity{}, fmt.Errorf("token exchange failed: %w", err)
+	}
+
+	if p.OIDC() {
+		return p.verifyIDToken(ctx, token.IDToken, req.Nonce)
+	}
+	return p.userInfo(ctx, token.AccessToken)
+}
+
+// userInfo は OpenID Connect に対応していないサービス（Strava）でアクセストークンを使ってアカウントを取得する
+func (p *Provider) userInfo(ctx context.Context, accessToken string) (Identity, error) {
+	if accessToken == "" {
+		return Identity{}, errors.New("access token is missing")
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, p.UserInfoURL, nil)
+	if err != nil {
+		return Identity{}, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+accessToken)
+	httpReq.Header.Set("Accept", "application/json")
+	info := map[string]interface{}{}
+	if err := doJSON(httpReq, &info); err != nil {
+		return Identity{}, fmt.Errorf("user info request failed: %w", err)
+	}
+	identity := Identity{
+		Provider: p.Name,
+		Subject:  claimString(info, p.SubjectField),
+		Name:     claimString(info, p.NameField),
+	}
+	if p.EmailField != "" {
+		identity.Email = claimString(info, p.EmailField)
+		identity.EmailVerified = claimBool(info, "email_verified")
+	}
+	if identity.Subject == "" {
+		return Identity{}, errors.New("user info does not contain the account id")
+	}
+	return identity, nil
+}
+
+func doJSON(req *http.Request, v interface{}) error {
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d: %s", req.URL.Host, res.StatusCode, strings.TrimSpace(string(body)))
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(body)))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// claimString は文字列または数値の項目を文字列で返す（Strava の ID は数値）
+func claimString(claims map[string]interface{}, key string) string {
+	switch v := claims[key].(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// claimBool は真偽値または "true" の文字列を true とする（Apple の email_verified は文字列）
+func claimBool(claims map[string]interface{}, key string) bool {
+	switch v := claims[key].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
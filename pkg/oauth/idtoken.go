@@ -0,0 +1,160 @@
+package oauth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// 公開鍵を取得し直すまでの時間（鍵が見つからない場合はすぐに取得し直す）
+const jwksCacheTTL = time.Hour
+
+// keySet は ID トークンの署名を検証する公開鍵（JWKS）のキャッシュ
+type keySet struct {
+	url       string
+	mu        sync.Mutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+}
+
+func newKeySet(url string) *keySet {
+	return &keySet{url: url}
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (ks *keySet) key(ctx context.Context, kid string) (interface{}, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if key, ok := ks.keys[kid]; ok && time.Since(ks.fetchedAt) < jwksCacheTTL {
+		return key, nil
+	}
+	if err := ks.fetch(ctx); err != nil {
+		return nil, err
+	}
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("signing key %q not found", kid)
+	}
+	return key, nil
+}
+
+func (ks *keySet) fetch(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.url, nil)
+	if err != nil {
+		return err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := doJSON(req, &set); err != nil {
+		return fmt.Errorf("jwks request failed: %w", err)
+	}
+	keys := map[string]interface{}{}
+	for _, k := range set.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			// 対応していない種類の鍵は使わない
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	ks.keys = keys
+	ks.fetchedAt = time.Now()
+	return nil
+}
+
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// verifyIDToken は ID トークンの署名・発行者・対象（client_id）・有効期限・nonce を検証してアカウントを返す
+func (p *Provider) verifyIDToken(ctx context.Context, idToken string, nonce string) (Identity, error) {
+	if idToken == "" {
+		return Identity{}, errors.New("id token is missing")
+	}
+	parser := jwt.Parser{ValidMethods: []string{"RS256", "ES256"}}
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.keys.key(ctx, kid)
+	})
+	if err != nil {
+		return Identity{}, fmt.Errorf("invalid id token: %w", err)
+	}
+	if !claims.VerifyIssuer(p.Issuer, true) {
+		return Identity{}, errors.New("invalid id token: unexpected issuer")
+	}
+	if !claims.VerifyAudience(p.ClientID, true) {
+		return Identity{}, errors.New("invalid id token: unexpected audience")
+	}
+	if _, ok := claims["exp"]; !ok {
+		return Identity{}, errors.New("invalid id token: exp is missing")
+	}
+	if claimString(claims, "nonce") != nonce {
+		return Identity{}, errors.New("invalid id token: nonce mismatch")
+	}
+
+	identity := Identity{
+		Provider:      p.Name,
+		Subject:       claimString(claims, "sub"),
+		Email:         claimString(claims, "email"),
+		EmailVerified: claimBool(claims, "email_verified"),
+		Name:          claimString(claims, p.NameField),
+	}
+	if identity.Subject == "" {
+		return Identity{}, errors.New("invalid id token: sub is missing")
+	}
+	return identity, nil
+}
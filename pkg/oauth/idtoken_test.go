@@ -0,0 +1,95 @@
+package oauth
+
+import (
+	"context"
+	"go_vdot_api/pkg/oauth/oauthtest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func testProvider(idp *oauthtest.IdP) *Provider {
+	return &Provider{
+		Name:      "google",
+		ClientID:  oauthtest.ClientID,
+		TokenURL:  idp.TokenURL(),
+		JWKSURL:   idp.JWKSURL(),
+		Issuer:    idp.Issuer,
+		NameField: "name",
+		keys:      newKeySet(idp.JWKSURL()),
+	}
+}
+
+func TestExchangeIDToken(t *testing.T) {
+	idp := oauthtest.NewIdP(t)
+	p := testProvider(idp)
+	req := AuthRequest{State: "state", Nonce: "nonce", CodeVerifier: "verifier"}
+
+	tests := []struct {
+		name    string
+		kid     string
+		modify  func(jwt.MapClaims)
+		wantErr string
+	}{
+		{name: "valid", kid: oauthtest.KeyID},
+		{name: "wrong issuer", kid: oauthtest.KeyID, modify: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, wantErr: "unexpected issuer"},
+		{name: "wrong audience", kid: oauthtest.KeyID, modify: func(c jwt.MapClaims) { c["aud"] = "another-client" }, wantErr: "unexpected audience"},
+		{name: "wrong nonce", kid: oauthtest.KeyID, modify: func(c jwt.MapClaims) { c["nonce"] = "replayed" }, wantErr: "nonce mismatch"},
+		{name: "missing nonce", kid: oauthtest.KeyID, modify: func(c jwt.MapClaims) { delete(c, "nonce") }, wantErr: "nonce mismatch"},
+		{name: "expired", kid: oauthtest.KeyID, modify: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, wantErr: "expired"},
+		{name: "missing exp", kid: oauthtest.KeyID, modify: func(c jwt.MapClaims) { delete(c, "exp") }, wantErr: "exp is missing"},
+		{name: "missing sub", kid: oauthtest.KeyID, modify: func(c jwt.MapClaims) { c["sub"] = "" }, wantErr: "sub is missing"},
+		{name: "unknown kid", kid: "rotated-key", wantErr: `signing key "rotated-key" not found`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := idp.Claims("subject-1", "runner@example.com", req.Nonce)
+			if tt.modify != nil {
+				tt.modify(claims)
+			}
+			code := idp.Issue(idp.Sign(t, tt.kid, claims))
+
+			identity, err := p.Exchange(context.Background(), code, req)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			want := Identity{Provider: "google", Subject: "subject-1", Email: "runner@example.com", EmailVerified: true, Name: "Test Runner"}
+			if identity != want {
+				t.Errorf("identity = %+v, want %+v", identity, want)
+			}
+		})
+	}
+}
+
+func TestExchangeTamperedIDToken(t *testing.T) {
+	idp := oauthtest.NewIdP(t)
+	p := testProvider(idp)
+	req := AuthRequest{State: "state", Nonce: "nonce", CodeVerifier: "verifier"}
+
+	// 署名はそのままで、ペイロードを別のアカウントのものに差し替える
+	signed := strings.Split(idp.Sign(t, oauthtest.KeyID, idp.Claims("subject-1", "runner@example.com", req.Nonce)), ".")
+	other := strings.Split(idp.Sign(t, oauthtest.KeyID, idp.Claims("subject-2", "victim@example.com", req.Nonce)), ".")
+	code := idp.Issue(signed[0] + "." + other[1] + "." + signed[2])
+
+	if _, err := p.Exchange(context.Background(), code, req); err == nil || !strings.Contains(err.Error(), "invalid id token") {
+		t.Fatalf("error = %v, want invalid id token", err)
+	}
+}
+
+func TestExchangeUnknownCode(t *testing.T) {
+	idp := oauthtest.NewIdP(t)
+	p := testProvider(idp)
+
+	_, err := p.Exchange(context.Background(), "not-issued", AuthRequest{Nonce: "nonce", CodeVerifier: "verifier"})
+	if err == nil || !strings.Contains(err.Error(), "token exchange failed") {
+		t.Fatalf("error = %v, want token exchange failed", err)
+	}
+}
@@ -0,0 +1,127 @@
+// Package oauthtest はテストで使う OpenID Connect の認可サーバー（トークンと JWKS のエンドポイント）
+package oauthtest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// KeyID は JWKS で公開する鍵の kid
+const KeyID = "test-key"
+
+// ClientID は ID トークンの aud に入れる client_id
+const ClientID = "test-client"
+
+// IdP は認可コードを ID トークンに交換する認可サーバー
+// Issue で ID トークンを登録して認可コードを受け取り、トークンのエンドポイントでその ID トークンを返す
+type IdP struct {
+	*httptest.Server
+	Issuer string
+
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]string
+}
+
+// NewIdP は RSA の鍵を作ってサーバーを起動する（テストの終わりに停止する）
+func NewIdP(t *testing.T) *IdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	idp := &IdP{key: key, codes: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", idp.token)
+	mux.HandleFunc("/jwks", idp.jwks)
+	idp.Server = httptest.NewServer(mux)
+	idp.Issuer = idp.URL
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+func (idp *IdP) TokenURL() string { return idp.URL + "/token" }
+func (idp *IdP) JWKSURL() string  { return idp.URL + "/jwks" }
+
+// Claims は nonce に対する有効な ID トークンの内容（テストごとに項目を変更して使う）
+func (idp *IdP) Claims(subject string, email string, nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            idp.Issuer,
+		"aud":            ClientID,
+		"sub":            subject,
+		"email":          email,
+		"email_verified": true,
+		"name":           "Test Runner",
+		"nonce":          nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	}
+}
+
+// Sign は kid を付けて ID トークンに署名する（JWKS にない kid も指定できる）
+func (idp *IdP) Sign(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(idp.key)
+	if err != nil {
+		t.Fatalf("sign id token: %v", err)
+	}
+	return signed
+}
+
+// Issue は ID トークンを返す認可コードを登録する
+func (idp *IdP) Issue(idToken string) string {
+	idp.mu.Lock()
+	defer idp.mu.Unlock()
+	code := "code-" + strconv.Itoa(len(idp.codes)+1)
+	idp.codes[code] = idToken
+	return code
+}
+
+func (idp *IdP) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		http.Error(w, `{"error":"invalid_request"}`, http.StatusBadRequest)
+		return
+	}
+	if r.PostForm.Get("client_id") != ClientID || r.PostForm.Get("code_verifier") == "" {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+	idp.mu.Lock()
+	idToken, ok := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+	if !ok {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"access_token": "test-access-token", "id_token": idToken})
+}
+
+func (idp *IdP) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := idp.key.PublicKey
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kid": KeyID,
+			"kty": "RSA",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
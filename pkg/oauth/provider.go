@@ -0,0 +1,117 @@
+// Package oauth は外部のアカウント（Google・Apple・Strava）でのログインに使う OAuth2 / OpenID Connect のクライアント
+package oauth
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"strings"
+)
+
+var ErrUnknownProvider = errors.New("unknown or disabled login provider")
+
+// Provider は認可サーバーの設定
+// JWKSURL がある場合は OpenID Connect として ID トークンからアカウントを取得し、ない場合は UserInfoURL から取得する
+type Provider struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	JWKSURL      string
+	Issuer       string
+	Scopes       []string
+	ResponseMode string // Apple はメールアドレス・名前を要求する場合 form_post（コールバックが POST になる）
+
+	// UserInfoURL のレスポンスの項目名（EmailField が空の場合はメールアドレスを取得しない）
+	SubjectField string
+	EmailField   string
+	NameField    string
+
+	keys *keySet
+}
+
+// OIDC は ID トークンを検証するプロバイダーか返す
+func (p *Provider) OIDC() bool {
+	return p.JWKSURL != ""
+}
+
+// defaultProviders は各サービスの既定のエンドポイント
+var defaultProviders = map[string]Provider{
+	"google": {
+		AuthURL:  "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL: "https://oauth2.googleapis.com/token",
+		JWKSURL:  "https://www.googleapis.com/oauth2/v3/certs",
+		Issuer:   "https://accounts.google.com",
+		Scopes:   []string{"openid", "email", "profile"},
+	},
+	"apple": {
+		AuthURL:      "https://appleid.apple.com/auth/authorize",
+		TokenURL:     "https://appleid.apple.com/auth/token",
+		JWKSURL:      "https://appleid.apple.com/auth/keys",
+		Issuer:       "https://appleid.apple.com",
+		Scopes:       []string{"name", "email"},
+		ResponseMode: "form_post",
+	},
+	"strava": {
+		AuthURL:      "https://www.strava.com/oauth/authorize",
+		TokenURL:     "https://www.strava.com/oauth/token",
+		UserInfoURL:  "https://www.strava.com/api/v3/athlete",
+		Scopes:       []string{"read"},
+		SubjectField: "id",
+		NameField:    "firstname",
+	},
+}
+
+// ProvidersFromEnv は OAUTH_<NAME>_CLIENT_ID が設定されたプロバイダーを返す
+// エンドポイントは OAUTH_<NAME>_AUTH_URL / TOKEN_URL / USERINFO_URL / JWKS_URL / ISSUER で変更できる（ローカルの IdP でテストする場合など）
+// コールバックの URL は OAUTH_REDIRECT_BASE_URL（API のオリジン）+ /api/auth/oauth/<name>/callback
+func ProvidersFromEnv() map[string]*Provider {
+	providers := map[string]*Provider{}
+	for name, defaults := range defaultProviders {
+		p := defaults
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		p.ClientID = os.Getenv(prefix + "CLIENT_ID")
+		if p.ClientID == "" {
+			continue
+		}
+		p.Name = name
+		p.ClientSecret = os.Getenv(prefix + "CLIENT_SECRET")
+		p.RedirectURL = strings.TrimRight(os.Getenv("OAUTH_REDIRECT_BASE_URL"), "/") + "/api/auth/oauth/" + name + "/callback"
+		overrideEnv(&p.AuthURL, prefix+"AUTH_URL")
+		overrideEnv(&p.TokenURL, prefix+"TOKEN_URL")
+		overrideEnv(&p.UserInfoURL, prefix+"USERINFO_URL")
+		overrideEnv(&p.JWKSURL, prefix+"JWKS_URL")
+		overrideEnv(&p.Issuer, prefix+"ISSUER")
+		if scopes := os.Getenv(prefix + "SCOPES"); scopes != "" {
+			p.Scopes = strings.Fields(scopes)
+		}
+		if p.SubjectField == "" {
+			p.SubjectField = "sub"
+		}
+		if p.NameField == "" {
+			p.NameField = "name"
+		}
+		p.keys = newKeySet(p.JWKSURL)
+		providers[name] = &p
+	}
+	return providers
+}
+
+func overrideEnv(value *string, key string) {
+	if v := os.Getenv(key); v != "" {
+		*value = v
+	}
+}
+
+// Names はプロバイダーの名前を並べて返す
+func Names(providers map[string]*Provider) []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,39 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var (
+	clockTimePattern  = regexp.MustCompile(`^(\d{1,2}):(\d{2}):(\d{2})$`)    // 例: 2:22:25
+	markedTimePattern = regexp.MustCompile(`^(\d{1,2})'(\d{2})"(\d{1,2})?$`) // 例: 4'12"11
+)
+
+// ParseRaceTime はレースのタイム（hh:mm:ss または m'ss"SS）を分に変換する
+func ParseRaceTime(s string) (float64, error) {
+	if m := clockTimePattern.FindStringSubmatch(s); m != nil {
+		hh, _ := strconv.Atoi(m[1])
+		mm, _ := strconv.Atoi(m[2])
+		ss, _ := strconv.Atoi(m[3])
+		if mm >= 60 || ss >= 60 {
+			return 0, fmt.Errorf("invalid time values: %s", s)
+		}
+		return float64(hh)*60 + float64(mm) + float64(ss)/60, nil
+	}
+	if m := markedTimePattern.FindStringSubmatch(s); m != nil {
+		mm, _ := strconv.Atoi(m[1])
+		ss, _ := strconv.Atoi(m[2])
+		if ss >= 60 {
+			return 0, fmt.Errorf("invalid time values: %s", s)
+		}
+		// 秒の小数部（"4'12"11" は 4分12秒11、"4'12"1" は 4分12秒1）
+		fraction := 0.0
+		if m[3] != "" {
+			fraction, _ = strconv.ParseFloat("0."+m[3], 64)
+		}
+		return float64(mm) + (float64(ss)+fraction)/60, nil
+	}
+	return 0, fmt.Errorf("invalid time format: %s", s)
+}
@@ -0,0 +1,77 @@
+package workoutparser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	lapClockPattern  = regexp.MustCompile(`^(?:(\d+):)?(\d{1,2}):(\d{2}(?:\.\d+)?)$`) // 例: 3:30, 1:05:30, 3:30.5
+	lapMarkedPattern = regexp.MustCompile(`^(?:(\d+)')?(\d{1,2})"(\d{1,2})?$`)        // 例: 4'12"11, 72"5
+	lapSecondPattern = regexp.MustCompile(`^\d+(?:\.\d+)?$`)                          // 例: 72, 72.5
+)
+
+// ParseLaps はラップタイム（例：[3:30, 3:40, 3:50]）を秒の配列に変換する
+func ParseLaps(s string) ([]float64, error) {
+	s = strings.TrimSpace(normalizer.Replace(s))
+	s = strings.NewReplacer("［", "[", "］", "]", "’", "'", "”", `"`).Replace(s)
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return nil, fmt.Errorf("lap_time must be enclosed in [ ]")
+	}
+	body := strings.TrimSpace(s[1 : len(s)-1])
+	if body == "" {
+		return []float64{}, nil
+	}
+
+	items := strings.Split(body, ",")
+	laps := make([]float64, 0, len(items))
+	for i, item := range items {
+		lap, err := parseLap(strings.Trim(strings.TrimSpace(item), `"`))
+		if err != nil {
+			return nil, fmt.Errorf("lap %d %q: %v", i+1, strings.TrimSpace(item), err)
+		}
+		laps = append(laps, lap)
+	}
+	return laps, nil
+}
+
+func parseLap(s string) (float64, error) {
+	if m := lapClockPattern.FindStringSubmatch(s); m != nil {
+		hours := 0
+		if m[1] != "" {
+			hours, _ = strconv.Atoi(m[1])
+		}
+		minutes, _ := strconv.Atoi(m[2])
+		seconds, _ := strconv.ParseFloat(m[3], 64)
+		if seconds >= 60 || (m[1] != "" && minutes >= 60) {
+			return 0, fmt.Errorf("invalid time values")
+		}
+		return positive(float64(hours*3600+minutes*60) + seconds)
+	}
+	if m := lapMarkedPattern.FindStringSubmatch(s); m != nil {
+		minutes := 0
+		if m[1] != "" {
+			minutes, _ = strconv.Atoi(m[1])
+		}
+		seconds, _ := strconv.Atoi(m[2])
+		fraction := 0.0
+		if m[3] != "" {
+			fraction, _ = strconv.ParseFloat("0."+m[3], 64)
+		}
+		return positive(float64(minutes*60+seconds) + fraction)
+	}
+	if lapSecondPattern.MatchString(s) {
+		seconds, _ := strconv.ParseFloat(s, 64)
+		return positive(seconds)
+	}
+	return 0, fmt.Errorf("must be m:ss, h:mm:ss, m'ss\"SS or seconds")
+}
+
+func positive(seconds float64) (float64, error) {
+	if seconds <= 0 {
+		return 0, fmt.Errorf("must be greater than 0")
+	}
+	return seconds, nil
+}
@@ -0,0 +1,55 @@
+package workoutparser
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseLaps(t *testing.T) {
+	cases := []struct {
+		input string
+		want  []float64
+	}{
+		{"[3:30, 3:40, 3:50]", []float64{210, 220, 230}},
+		{"[1:05:30]", []float64{3930}},
+		{"[3:30.5]", []float64{210.5}},
+		{`[4'12"11, 72"5]`, []float64{252.11, 72.5}},
+		{"[72, 72.5]", []float64{72, 72.5}},
+		{`["3:30", "3:40"]`, []float64{210, 220}},
+		{"［3:30，3:40］", []float64{210, 220}},
+		{"[]", []float64{}},
+	}
+	for _, c := range cases {
+		got, err := ParseLaps(c.input)
+		if err != nil {
+			t.Errorf("ParseLaps(%q) error = %v", c.input, err)
+			continue
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("ParseLaps(%q) = %v, want %v", c.input, got, c.want)
+			continue
+		}
+		for i := range got {
+			if math.Abs(got[i]-c.want[i]) > 1e-9 {
+				t.Errorf("ParseLaps(%q) = %v, want %v", c.input, got, c.want)
+				break
+			}
+		}
+	}
+}
+
+func TestParseLapsInvalid(t *testing.T) {
+	for _, input := range []string{
+		"3:30, 3:40", // [ ] がない
+		"[3:60]",     // 秒が 60 以上
+		"[1:60:00]",  // 時間がある場合の分が 60 以上
+		"[abc]",
+		"[0]",
+		"[3:30,]",
+		"[3:30, , 3:40]",
+	} {
+		if laps, err := ParseLaps(input); err == nil {
+			t.Errorf("ParseLaps(%q) = %v, want error", input, laps)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+// Package workoutparser は練習内容の表記（例：E3.2km, 6x(I800m・レスト2分）, E3.2km）を
+// 構造化されたセグメントに変換する。
+package workoutparser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ゾーン（Daniels の E, M, T, I, R）
+var Zones = []string{"E", "M", "T", "I", "R"}
+
+// 休息の種類
+const (
+	RecoveryRest = "rest" // レスト・休息（止まる、歩く）
+	RecoveryJog  = "jog"  // ジョグでつなぐ
+)
+
+// Segment は練習内容の1区切り（カンマ区切りの1つ分）
+type Segment struct {
+	Raw             string    `json:"raw"`              // 元の表記（例：6x(I800m・レスト2分)）
+	Repetitions     int       `json:"repetitions"`      // 本数（繰り返しがない場合は 1）
+	Zone            string    `json:"zone"`             // E, M, T, I, R
+	DistanceMeters  *float64  `json:"distance_meters"`  // 1本あたりの距離（時間で指定した場合は null）
+	DurationSeconds *float64  `json:"duration_seconds"` // 1本あたりの時間（距離で指定した場合は null）
+	Recovery        *Recovery `json:"recovery"`         // 1本ごとの休息（ない場合は null）
+}
+
+// Recovery は繰り返しの間の休息
+type Recovery struct {
+	Kind            string   `json:"kind"` // rest または jog
+	DistanceMeters  *float64 `json:"distance_meters"`
+	DurationSeconds *float64 `json:"duration_seconds"`
+}
+
+// ParseError は解析できなかった区切りと理由
+type ParseError struct {
+	Index   int    // 何番目の区切りか（1始まり）
+	Segment string // 解析できなかった表記
+	Reason  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("segment %d %q: %s", e.Index, e.Segment, e.Reason)
+}
+
+var (
+	repeatPattern   = regexp.MustCompile(`^(\d+)\s*x\s*(.+)$`)
+	workPattern     = regexp.MustCompile(`^([A-Za-z])\s*(.+)$`)
+	recoveryPattern = regexp.MustCompile(`(?i)^(レスト|休息|休憩|rest|ジョグ|jog|つなぎ)\s*(.+)$`)
+	amountPattern   = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)?)\s*(km|k|miles|mile|mi|min|m|分|秒|sec|s|時間|h)$`)
+	minSecPattern   = regexp.MustCompile(`^(\d+)分(\d+)秒$`)
+)
+
+// 全角の記号を半角にそろえる
+var normalizer = strings.NewReplacer(
+	"（", "(", "）", ")", "，", ",", "、", ",",
+	"×", "x", "X", "x", "＊", "x", "*", "x",
+	"／", "/", "＋", "+", "　", " ",
+)
+
+// Parse は練習内容の表記を解析する。解析できない区切りがあれば *ParseError を返す
+func Parse(s string) ([]Segment, error) {
+	s = strings.TrimSpace(normalizer.Replace(s))
+	if s == "" {
+		return nil, &ParseError{Index: 1, Segment: s, Reason: "workout is empty"}
+	}
+
+	parts, err := splitTopLevel(s)
+	if err != nil {
+		return nil, &ParseError{Index: 1, Segment: s, Reason: err.Error()}
+	}
+
+	segments := make([]Segment, 0, len(parts))
+	for i, part := range parts {
+		segment, err := parseSegment(part)
+		if err != nil {
+			return nil, &ParseError{Index: i + 1, Segment: part, Reason: err.Error()}
+		}
+		segments = append(segments, segment)
+	}
+	return segments, nil
+}
+
+// splitTopLevel は括弧の外にあるカンマで区切る
+func splitTopLevel(s string) ([]string, error) {
+	var parts []string
+	depth := 0
+	start := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses")
+			}
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses")
+	}
+	parts = append(parts, strings.TrimSpace(s[start:]))
+	for _, p := range parts {
+		if p == "" {
+			return nil, fmt.Errorf("empty segment")
+		}
+	}
+	return parts, nil
+}
+
+func parseSegment(raw string) (Segment, error) {
+	segment := Segment{Raw: raw, Repetitions: 1}
+	body := raw
+
+	// 繰り返し（例：6x(I800m・レスト2分)、6xI800m）
+	if m := repeatPattern.FindStringSubmatch(raw); m != nil {
+		reps, err := strconv.Atoi(m[1])
+		if err != nil || reps < 1 {
+			return Segment{}, fmt.Errorf("repetitions must be 1 or more")
+		}
+		segment.Repetitions = reps
+		body = strings.TrimSpace(m[2])
+		if strings.HasPrefix(body, "(") && strings.HasSuffix(body, ")") {
+			body = strings.TrimSpace(body[1 : len(body)-1])
+		}
+		// セグメントは1段の繰り返しのみ表せるため、入れ子の繰り返しは区切りごとに書いてもらう
+		if strings.ContainsAny(body, "()") || repeatPattern.MatchString(body) {
+			return Segment{}, fmt.Errorf("nested repetitions are not supported. Write each set as its own segment (e.g. 3x(R200m・ジョグ200m), E1km, 3x(R200m・ジョグ200m))")
+		}
+	}
+
+	// 本練習と休息（例：I800m・レスト2分）
+	steps := strings.FieldsFunc(body, func(r rune) bool {
+		return r == '・' || r == '/' || r == '+'
+	})
+	if len(steps) == 0 {
+		return Segment{}, fmt.Errorf("workout step is missing")
+	}
+
+	if err := parseWork(strings.TrimSpace(steps[0]), &segment); err != nil {
+		return Segment{}, err
+	}
+	for _, step := range steps[1:] {
+		step = strings.TrimSpace(step)
+		if segment.Recovery != nil {
+			return Segment{}, fmt.Errorf("only one recovery is supported per segment")
+		}
+		recovery, err := parseRecovery(step)
+		if err != nil {
+			return Segment{}, err
+		}
+		segment.Recovery = recovery
+	}
+	if segment.Recovery != nil && segment.Repetitions == 1 {
+		return Segment{}, fmt.Errorf("recovery is only allowed with repetitions (e.g. 6x(I800m・レスト2分))")
+	}
+	return segment, nil
+}
+
+func parseWork(step string, segment *Segment) error {
+	m := workPattern.FindStringSubmatch(step)
+	if m == nil {
+		return fmt.Errorf("%q must start with a zone (E, M, T, I, R)", step)
+	}
+	zone := strings.ToUpper(m[1])
+	if !isZone(zone) {
+		return fmt.Errorf("unknown zone %q. Use E, M, T, I or R", m[1])
+	}
+	distance, duration, err := parseAmount(strings.TrimSpace(m[2]))
+	if err != nil {
+		return err
+	}
+	segment.Zone = zone
+	segment.DistanceMeters = distance
+	segment.DurationSeconds = duration
+	return nil
+}
+
+func parseRecovery(step string) (*Recovery, error) {
+	m := recoveryPattern.FindStringSubmatch(step)
+	if m == nil {
+		return nil, fmt.Errorf("%q is not a recovery (e.g. レスト2分, ジョグ200m)", step)
+	}
+	kind := RecoveryRest
+	switch strings.ToLower(m[1]) {
+	case "ジョグ", "jog", "つなぎ":
+		kind = RecoveryJog
+	}
+	distance, duration, err := parseAmount(strings.TrimSpace(m[2]))
+	if err != nil {
+		return nil, err
+	}
+	return &Recovery{Kind: kind, DistanceMeters: distance, DurationSeconds: duration}, nil
+}
+
+// parseAmount は距離（m）または時間（秒）を返す
+func parseAmount(s string) (*float64, *float64, error) {
+	if m := minSecPattern.FindStringSubmatch(s); m != nil {
+		minutes, _ := strconv.Atoi(m[1])
+		seconds, _ := strconv.Atoi(m[2])
+		duration := float64(minutes*60 + seconds)
+		return nil, &duration, nil
+	}
+	m := amountPattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil, nil, fmt.Errorf("%q must be a distance (km, m, mi) or a duration (分, 秒, min, s)", s)
+	}
+	value, err := strconv.ParseFloat(m[1], 64)
+	if err != nil || value <= 0 {
+		return nil, nil, fmt.Errorf("%q must be greater than 0", s)
+	}
+	var amount float64
+	switch strings.ToLower(m[2]) {
+	case "km", "k":
+		amount = value * 1000
+	case "m":
+		amount = value
+	case "mi", "mile", "miles":
+		amount = value * 1609.34
+	case "分", "min":
+		amount = value * 60
+		return nil, &amount, nil
+	case "秒", "s", "sec":
+		amount = value
+		return nil, &amount, nil
+	case "時間", "h":
+		amount = value * 3600
+		return nil, &amount, nil
+	}
+	return &amount, nil, nil
+}
+
+func isZone(zone string) bool {
+	for _, z := range Zones {
+		if z == zone {
+			return true
+		}
+	}
+	return false
+}
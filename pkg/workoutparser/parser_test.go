@@ -0,0 +1,132 @@
+package workoutparser
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func meters(v float64) *float64  { return &v }
+func seconds(v float64) *float64 { return &v }
+
+func equalAmount(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return math.Abs(*a-*b) < 1e-6
+}
+
+func equalSegment(a, b Segment) bool {
+	if a.Repetitions != b.Repetitions || a.Zone != b.Zone ||
+		!equalAmount(a.DistanceMeters, b.DistanceMeters) || !equalAmount(a.DurationSeconds, b.DurationSeconds) {
+		return false
+	}
+	if a.Recovery == nil || b.Recovery == nil {
+		return a.Recovery == b.Recovery
+	}
+	return a.Recovery.Kind == b.Recovery.Kind &&
+		equalAmount(a.Recovery.DistanceMeters, b.Recovery.DistanceMeters) &&
+		equalAmount(a.Recovery.DurationSeconds, b.Recovery.DurationSeconds)
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  []Segment
+	}{
+		{"request example", "E3.2km, 6x(I800m・レスト2分）, E3.2km", []Segment{
+			{Repetitions: 1, Zone: "E", DistanceMeters: meters(3200)},
+			{Repetitions: 6, Zone: "I", DistanceMeters: meters(800), Recovery: &Recovery{Kind: RecoveryRest, DurationSeconds: seconds(120)}},
+			{Repetitions: 1, Zone: "E", DistanceMeters: meters(3200)},
+		}},
+		{"full width symbols", "6×（I800m・レスト2分）、E3km", []Segment{
+			{Repetitions: 6, Zone: "I", DistanceMeters: meters(800), Recovery: &Recovery{Kind: RecoveryRest, DurationSeconds: seconds(120)}},
+			{Repetitions: 1, Zone: "E", DistanceMeters: meters(3000)},
+		}},
+		{"reps without parentheses", "6xI800m", []Segment{
+			{Repetitions: 6, Zone: "I", DistanceMeters: meters(800)},
+		}},
+		{"jog recovery by distance", "8x(R200m/ジョグ200m)", []Segment{
+			{Repetitions: 8, Zone: "R", DistanceMeters: meters(200), Recovery: &Recovery{Kind: RecoveryJog, DistanceMeters: meters(200)}},
+		}},
+		{"rest recovery in seconds", "5 x (T1km + rest 60s)", []Segment{
+			{Repetitions: 5, Zone: "T", DistanceMeters: meters(1000), Recovery: &Recovery{Kind: RecoveryRest, DurationSeconds: seconds(60)}},
+		}},
+		{"recovery in minutes and seconds", "4x(I1200m・休息2分30秒)", []Segment{
+			{Repetitions: 4, Zone: "I", DistanceMeters: meters(1200), Recovery: &Recovery{Kind: RecoveryRest, DurationSeconds: seconds(150)}},
+		}},
+		{"miles", "E2mi, M1mile, E1.5miles", []Segment{
+			{Repetitions: 1, Zone: "E", DistanceMeters: meters(2 * 1609.34)},
+			{Repetitions: 1, Zone: "M", DistanceMeters: meters(1609.34)},
+			{Repetitions: 1, Zone: "E", DistanceMeters: meters(1.5 * 1609.34)},
+		}},
+		{"kilometers and meters", "M10k, T5000m, e 3 km", []Segment{
+			{Repetitions: 1, Zone: "M", DistanceMeters: meters(10000)},
+			{Repetitions: 1, Zone: "T", DistanceMeters: meters(5000)},
+			{Repetitions: 1, Zone: "E", DistanceMeters: meters(3000)},
+		}},
+		{"durations", "T20分, E45min, E1.5h, T3分30秒", []Segment{
+			{Repetitions: 1, Zone: "T", DurationSeconds: seconds(1200)},
+			{Repetitions: 1, Zone: "E", DurationSeconds: seconds(2700)},
+			{Repetitions: 1, Zone: "E", DurationSeconds: seconds(5400)},
+			{Repetitions: 1, Zone: "T", DurationSeconds: seconds(210)},
+		}},
+	}
+	for _, c := range cases {
+		got, err := Parse(c.input)
+		if err != nil {
+			t.Errorf("%s: Parse(%q) error = %v", c.name, c.input, err)
+			continue
+		}
+		if len(got) != len(c.want) {
+			t.Errorf("%s: Parse(%q) returned %d segments, want %d", c.name, c.input, len(got), len(c.want))
+			continue
+		}
+		for i := range got {
+			if !equalSegment(got[i], c.want[i]) {
+				t.Errorf("%s: segment %d = %+v, want %+v", c.name, i+1, got[i], c.want[i])
+			}
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	cases := []struct {
+		name   string
+		input  string
+		index  int    // ParseError.Index
+		reason string // ParseError.Reason に含まれる文字列
+	}{
+		{"empty", "  ", 1, "empty"},
+		{"trailing comma", "E3km,", 1, "empty segment"},
+		{"unbalanced parentheses", "6x(I800m・レスト2分", 1, "unbalanced"},
+		{"unknown zone", "X5km", 1, "unknown zone"},
+		{"missing zone", "3km", 1, "must start with a zone"},
+		{"negative amount", "E-3km", 1, "must be a distance"},
+		{"zero amount", "E0km", 1, "greater than 0"},
+		{"unknown unit", "E3yd", 1, "must be a distance"},
+		{"zero repetitions", "0xI800m", 1, "1 or more"},
+		{"recovery without repetitions", "I800m・レスト2分", 1, "only allowed with repetitions"},
+		{"two recoveries", "6x(I800m・レスト2分・ジョグ200m)", 1, "only one recovery"},
+		{"invalid recovery", "6x(I800m・E200m)", 1, "is not a recovery"},
+		{"nested repetitions", "2x(3x(R200m・ジョグ200m)・レスト5分)", 1, "nested repetitions"},
+		{"nested repetitions without parentheses", "3x(2xR200m)", 1, "nested repetitions"},
+		{"error after valid segments", "E3.2km, 6x(I800m・レスト2分), E3.2kg", 3, "must be a distance"},
+	}
+	for _, c := range cases {
+		segments, err := Parse(c.input)
+		if segments != nil {
+			t.Errorf("%s: Parse(%q) returned partial segments %+v", c.name, c.input, segments)
+		}
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%s: Parse(%q) error = %v, want *ParseError", c.name, c.input, err)
+			continue
+		}
+		if parseErr.Index != c.index || !strings.Contains(parseErr.Reason, c.reason) {
+			t.Errorf("%s: Parse(%q) error = %v, want segment %d containing %q", c.name, c.input, err, c.index, c.reason)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"go_vdot_api/model"
+
+	"gorm.io/gorm"
+)
+
+type IAuditLogRepository interface {
+	CreateAuditLog(auditLog *model.AuditLog) error
+	GetAuditLogs(userId uint, action string, limit int) ([]model.AuditLog, error)
+}
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) IAuditLogRepository {
+	return &auditLogRepository{db}
+}
+
+func (alr *auditLogRepository) CreateAuditLog(auditLog *model.AuditLog) error {
+	if err := alr.db.Create(auditLog).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetAuditLogs は新しい順に監査ログを取得する（userId が 0、action が空の場合は絞り込まない）
+func (alr *auditLogRepository) GetAuditLogs(userId uint, action string, limit int) ([]model.AuditLog, error) {
+	db := alr.db
+	if userId != 0 {
+		db = db.Where("user_id = ?", userId)
+	}
+	if action != "" {
+		db = db.Where("action = ?", action)
+	}
+	auditLogs := []model.AuditLog{}
+	if err := db.Order("created_at DESC").Order("id DESC").Limit(limit).Find(&auditLogs).Error; err != nil {
+		return nil, err
+	}
+	return auditLogs, nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"database/sql"
+	"go_vdot_api/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ICoachAthleteRepository interface {
+	CreateCoachAthlete(coachAthlete *model.CoachAthlete) error
+	GetCoachAthleteById(coachAthlete *model.CoachAthlete, userId uint, coachAthleteId uint) error
+	GetAthletes(coachId uint) ([]model.CoachAthlete, error)
+	GetCoaches(athleteId uint) ([]model.CoachAthlete, error)
+	AcceptCoachAthlete(athleteId uint, coachAthleteId uint) error
+	DeleteCoachAthlete(userId uint, coachAthleteId uint) error
+	IsAcceptedCoach(coachId uint, athleteId uint) (bool, error)
+}
+
+type coachAthleteRepository struct {
+	db *gorm.DB
+}
+
+func NewCoachAthleteRepository(db *gorm.DB) ICoachAthleteRepository {
+	return &coachAthleteRepository{db}
+}
+
+// athleteWhere は @user_id が選手であるつながりの条件
+// 承認前の招待は athlete_id が null のため、招待したメールアドレスがユーザーの確認済みのメールアドレスと一致するものを含める
+const athleteWhere = "(athlete_id = @user_id OR (athlete_id IS NULL AND invited_email = " +
+	"(SELECT email FROM users WHERE id = @user_id AND email_verified_at IS NOT NULL)))"
+
+func (car *coachAthleteRepository) CreateCoachAthlete(coachAthlete *model.CoachAthlete) error {
+	if err := car.db.Create(coachAthlete).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetCoachAthleteById はコーチまたは選手として userId が含まれるつながりを取得する
+func (car *coachAthleteRepository) GetCoachAthleteById(coachAthlete *model.CoachAthlete, userId uint, coachAthleteId uint) error {
+	if err := car.db.
+		Preload("Coach").Preload("Athlete").
+		Where("id = @id AND (coach_id = @user_id OR "+athleteWhere+")", sql.Named("id", coachAthleteId), sql.Named("user_id", userId)).
+		First(coachAthlete).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (car *coachAthleteRepository) GetAthletes(coachId uint) ([]model.CoachAthlete, error) {
+	coachAthletes := []model.CoachAthlete{}
+	if err := car.db.
+		Preload("Coach").Preload("Athlete").
+		Where("coach_id = ?", coachId).
+		Order("id").
+		Find(&coachAthletes).Error; err != nil {
+		return nil, err
+	}
+	return coachAthletes, nil
+}
+
+// GetCoaches は選手としてのつながりを取得する（メールアドレスに届いた承認前の招待を含む）
+func (car *coachAthleteRepository) GetCoaches(athleteId uint) ([]model.CoachAthlete, error) {
+	coachAthletes := []model.CoachAthlete{}
+	if err := car.db.
+		Preload("Coach").Preload("Athlete").
+		Where(athleteWhere, sql.Named("user_id", athleteId)).
+		Order("id").
+		Find(&coachAthletes).Error; err != nil {
+		return nil, err
+	}
+	return coachAthletes, nil
+}
+
+// AcceptCoachAthlete は選手が承認待ちの招待を承認し、つながりの選手を athleteId に決める
+func (car *coachAthleteRepository) AcceptCoachAthlete(athleteId uint, coachAthleteId uint) error {
+	result := car.db.Model(&model.CoachAthlete{}).
+		Where("id = @id AND status = @status AND "+athleteWhere,
+			sql.Named("id", coachAthleteId), sql.Named("status", model.CoachLinkPending), sql.Named("user_id", athleteId)).
+		Updates(map[string]interface{}{"status": model.CoachLinkAccepted, "accepted_at": time.Now(), "athlete_id": athleteId})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteCoachAthlete はつながりを解除する（招待の取り消し・辞退を含め、コーチと選手のどちらからでもできる）
+func (car *coachAthleteRepository) DeleteCoachAthlete(userId uint, coachAthleteId uint) error {
+	result := car.db.
+		Where("id = @id AND (coach_id = @user_id OR "+athleteWhere+")", sql.Named("id", coachAthleteId), sql.Named("user_id", userId)).
+		Delete(&model.CoachAthlete{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// IsAcceptedCoach は coachId が athleteId に承認されたコーチか返す
+func (car *coachAthleteRepository) IsAcceptedCoach(coachId uint, athleteId uint) (bool, error) {
+	var count int64
+	if err := car.db.Model(&model.CoachAthlete{}).
+		Where("coach_id = ? AND athlete_id = ? AND status = ?", coachId, athleteId, model.CoachLinkAccepted).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"go_vdot_api/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IPersonalAccessTokenRepository interface {
+	CreateToken(token *model.PersonalAccessToken) error
+	GetActiveTokens(userId uint) ([]model.PersonalAccessToken, error)
+	GetTokens(userId uint) ([]model.PersonalAccessToken, error)
+	GetTokenByHash(token *model.PersonalAccessToken, tokenHash string) error
+	TouchToken(tokenId uint) error
+	RevokeToken(userId uint, tokenId uint) error
+}
+
+type personalAccessTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPersonalAccessTokenRepository(db *gorm.DB) IPersonalAccessTokenRepository {
+	return &personalAccessTokenRepository{db}
+}
+
+func (patr *personalAccessTokenRepository) CreateToken(token *model.PersonalAccessToken) error {
+	if err := patr.db.Create(token).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetActiveTokens は無効にされておらず期限内のトークンを新しい順に取得する
+func (patr *personalAccessTokenRepository) GetActiveTokens(userId uint) ([]model.PersonalAccessToken, error) {
+	tokens := []model.PersonalAccessToken{}
+	if err := patr.db.
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", userId, time.Now()).
+		Order("id DESC").
+		Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// GetTokens は無効にされた・期限切れのものを含むすべてのトークンを古い順に取得する
+func (patr *personalAccessTokenRepository) GetTokens(userId uint) ([]model.PersonalAccessToken, error) {
+	tokens := []model.PersonalAccessToken{}
+	if err := patr.db.Where("user_id = ?", userId).Order("id").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (patr *personalAccessTokenRepository) GetTokenByHash(token *model.PersonalAccessToken, tokenHash string) error {
+	if err := patr.db.Preload("User").Where("token_hash = ?", tokenHash).First(token).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// TouchToken は最後に使われた日時を更新する
+func (patr *personalAccessTokenRepository) TouchToken(tokenId uint) error {
+	return patr.db.Model(&model.PersonalAccessToken{}).Where("id = ?", tokenId).Update("last_used_at", time.Now()).Error
+}
+
+func (patr *personalAccessTokenRepository) RevokeToken(userId uint, tokenId uint) error {
+	result := patr.db.Model(&model.PersonalAccessToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", tokenId, userId).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"go_vdot_api/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IPlannedWorkoutRepository interface {
+	CreatePlannedWorkout(plannedWorkout *model.PlannedWorkout) error
+	GetPlannedWorkoutsInRange(userId uint, from time.Time, to time.Time) ([]model.PlannedWorkout, error)
+	GetPlannedWorkouts(userId uint) ([]model.PlannedWorkout, error)
+	GetPlannedWorkoutById(plannedWorkout *model.PlannedWorkout, userId uint, plannedWorkoutId uint) error
+	UpdatePlannedWorkout(plannedWorkout *model.PlannedWorkout, userId uint, plannedWorkoutId uint) error
+	DeletePlannedWorkout(userId uint, plannedWorkoutId uint) error
+}
+
+type plannedWorkoutRepository struct {
+	db *gorm.DB
+}
+
+func NewPlannedWorkoutRepository(db *gorm.DB) IPlannedWorkoutRepository {
+	return &plannedWorkoutRepository{db}
+}
+
+func (pwr *plannedWorkoutRepository) CreatePlannedWorkout(plannedWorkout *model.PlannedWorkout) error {
+	if err := pwr.db.Create(plannedWorkout).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (pwr *plannedWorkoutRepository) GetPlannedWorkoutsInRange(userId uint, from time.Time, to time.Time) ([]model.PlannedWorkout, error) {
+	plannedWorkouts := []model.PlannedWorkout{}
+	if err := pwr.db.
+		Where("user_id = ? AND date BETWEEN ? AND ?", userId, from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order("date").Order("id").
+		Find(&plannedWorkouts).Error; err != nil {
+		return nil, err
+	}
+	return plannedWorkouts, nil
+}
+
+// GetPlannedWorkouts はすべての予定を日付順に取得する
+func (pwr *plannedWorkoutRepository) GetPlannedWorkouts(userId uint) ([]model.PlannedWorkout, error) {
+	plannedWorkouts := []model.PlannedWorkout{}
+	if err := pwr.db.Where("user_id = ?", userId).Order("date").Order("id").Find(&plannedWorkouts).Error; err != nil {
+		return nil, err
+	}
+	return plannedWorkouts, nil
+}
+
+func (pwr *plannedWorkoutRepository) GetPlannedWorkoutById(plannedWorkout *model.PlannedWorkout, userId uint, plannedWorkoutId uint) error {
+	if err := pwr.db.Where("id = ? AND user_id = ?", plannedWorkoutId, userId).First(plannedWorkout).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (pwr *plannedWorkoutRepository) UpdatePlannedWorkout(plannedWorkout *model.PlannedWorkout, userId uint, plannedWorkoutId uint) error {
+	result := pwr.db.Model(plannedWorkout).Select("date", "workout", "mileage", "mileage_unit", "note").Where("id = ? AND user_id = ?", plannedWorkoutId, userId).Updates(plannedWorkout)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (pwr *plannedWorkoutRepository) DeletePlannedWorkout(userId uint, plannedWorkoutId uint) error {
+	result := pwr.db.Where("id = ? AND user_id = ?", plannedWorkoutId, userId).Delete(&model.PlannedWorkout{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// replacePlanWorkouts は練習計画から作った予定のうち from 以降のものを plannedWorkouts に置き換える
+// 計画の保存と同じトランザクション（tx）で実行する
+func replacePlanWorkouts(tx *gorm.DB, userId uint, trainingPlanId uint, from time.Time, plannedWorkouts []model.PlannedWorkout) error {
+	if err := tx.
+		Where("user_id = ? AND training_plan_id = ? AND date >= ?", userId, trainingPlanId, from.Format("2006-01-02")).
+		Delete(&model.PlannedWorkout{}).Error; err != nil {
+		return err
+	}
+	if len(plannedWorkouts) == 0 {
+		return nil
+	}
+	for i := range plannedWorkouts {
+		plannedWorkouts[i].TrainingPlanId = &trainingPlanId
+		plannedWorkouts[i].UserId = userId
+	}
+	return tx.Create(&plannedWorkouts).Error
+}
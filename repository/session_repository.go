@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"go_vdot_api/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ISessionRepository interface {
+	CreateSession(session *model.Session) error
+	GetSessionById(session *model.Session, userId uint, sessionId uint) error
+	GetSessionByTokenHash(session *model.Session, tokenHash string) error
+	GetSessionByPreviousTokenHash(session *model.Session, tokenHash string) error
+	GetActiveSessions(userId uint) ([]model.Session, error)
+	GetSessions(userId uint) ([]model.Session, error)
+	RotateSession(sessionId uint, oldTokenHash string, newTokenHash string, userAgent string, ipAddress string, expiresAt time.Time) error
+	RevokeSession(userId uint, sessionId uint) error
+	RevokeUserSessions(userId uint, exceptSessionId uint) error
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) ISessionRepository {
+	return &sessionRepository{db}
+}
+
+func (sr *sessionRepository) CreateSession(session *model.Session) error {
+	if err := sr.db.Create(session).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sr *sessionRepository) GetSessionById(session *model.Session, userId uint, sessionId uint) error {
+	if err := sr.db.Where("id = ? AND user_id = ?", sessionId, userId).First(session).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sr *sessionRepository) GetSessionByTokenHash(session *model.Session, tokenHash string) error {
+	if err := sr.db.Where("refresh_token_hash = ?", tokenHash).First(session).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sr *sessionRepository) GetSessionByPreviousTokenHash(session *model.Session, tokenHash string) error {
+	if err := sr.db.Where("previous_token_hash = ?", tokenHash).First(session).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetActiveSessions は無効にされておらず期限内のセッションを最後に使われた順に取得する
+func (sr *sessionRepository) GetActiveSessions(userId uint) ([]model.Session, error) {
+	sessions := []model.Session{}
+	if err := sr.db.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userId, time.Now()).
+		Order("last_seen_at DESC").Order("id DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// GetSessions は無効にされた・期限切れのものを含むすべてのセッションを古い順に取得する
+func (sr *sessionRepository) GetSessions(userId uint) ([]model.Session, error) {
+	sessions := []model.Session{}
+	if err := sr.db.Where("user_id = ?", userId).Order("id").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RotateSession はリフレッシュトークンを新しいものに置き換える
+// 同時に同じトークンで更新された場合に片方だけが成功するよう、古いトークンのハッシュを条件にする
+func (sr *sessionRepository) RotateSession(sessionId uint, oldTokenHash string, newTokenHash string, userAgent string, ipAddress string, expiresAt time.Time) error {
+	result := sr.db.Model(&model.Session{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", sessionId, oldTokenHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":  newTokenHash,
+			"previous_token_hash": oldTokenHash,
+			"user_agent":          userAgent,
+			"ip_address":          ipAddress,
+			"last_seen_at":        time.Now(),
+			"expires_at":          expiresAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (sr *sessionRepository) RevokeSession(userId uint, sessionId uint) error {
+	result := sr.db.Model(&model.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionId, userId).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RevokeUserSessions はユーザーのすべてのセッションを無効にする（exceptSessionId が 0 でない場合はそのセッションを残す）
+func (sr *sessionRepository) RevokeUserSessions(userId uint, exceptSessionId uint) error {
+	db := sr.db.Model(&model.Session{}).Where("user_id = ? AND revoked_at IS NULL", userId)
+	if exceptSessionId != 0 {
+		db = db.Where("id <> ?", exceptSessionId)
+	}
+	return db.Update("revoked_at", time.Now()).Error
+}
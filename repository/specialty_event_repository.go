@@ -8,6 +8,7 @@ import (
 
 type ISpecialtyEventRepository interface {
 	CreateSpecialtyEvent(specialtyEvent *model.SpecialtyEvent) error
+	CreateSpecialtyEvents(specialtyEvents []model.SpecialtyEvent) error
 	GetSpecialtyEvent(userId uint) ([]model.SpecialtyEvent, error)
 	UpdateSpecialtyEvent(specialtyEvent *model.SpecialtyEvent, userId uint, specialtyEventId uint) error
 }
@@ -28,6 +29,13 @@ func (ser *specialtyEventRepository) CreateSpecialtyEvent(specialtyEvent *model.
 	return nil
 }
 
+// CreateSpecialtyEvents は複数の種目を1つのトランザクションで保存する（1件でも失敗した場合はすべて保存しない）
+func (ser *specialtyEventRepository) CreateSpecialtyEvents(specialtyEvents []model.SpecialtyEvent) error {
+	return ser.db.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(&specialtyEvents, 100).Error
+	})
+}
+
 func (ser *specialtyEventRepository) GetSpecialtyEvent(userId uint) ([]model.SpecialtyEvent, error) {
 	specialtyEvents := []model.SpecialtyEvent{}
 	if err := ser.db.
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"go_vdot_api/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ITrainingPlanRepository interface {
+	CreateTrainingPlan(trainingPlan *model.TrainingPlan, plannedWorkouts []model.PlannedWorkout) error
+	GetTrainingPlans(userId uint) ([]model.TrainingPlan, error)
+	GetTrainingPlanById(trainingPlan *model.TrainingPlan, userId uint, trainingPlanId uint) error
+	UpdateTrainingPlan(trainingPlan *model.TrainingPlan, userId uint, trainingPlanId uint, from time.Time, plannedWorkouts []model.PlannedWorkout) error
+	DeleteTrainingPlan(userId uint, trainingPlanId uint) error
+}
+
+type trainingPlanRepository struct {
+	db *gorm.DB
+}
+
+func NewTrainingPlanRepository(db *gorm.DB) ITrainingPlanRepository {
+	return &trainingPlanRepository{db}
+}
+
+// CreateTrainingPlan は計画と、計画から作った予定を1つのトランザクションで保存する
+func (tpr *trainingPlanRepository) CreateTrainingPlan(trainingPlan *model.TrainingPlan, plannedWorkouts []model.PlannedWorkout) error {
+	return tpr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(trainingPlan).Error; err != nil {
+			return err
+		}
+		return replacePlanWorkouts(tx, trainingPlan.UserId, trainingPlan.ID, trainingPlan.StartDate.Time, plannedWorkouts)
+	})
+}
+
+func (tpr *trainingPlanRepository) GetTrainingPlans(userId uint) ([]model.TrainingPlan, error) {
+	trainingPlans := []model.TrainingPlan{}
+	if err := tpr.db.Where("user_id = ?", userId).Order("goal_date DESC, id DESC").Find(&trainingPlans).Error; err != nil {
+		return nil, err
+	}
+	return trainingPlans, nil
+}
+
+func (tpr *trainingPlanRepository) GetTrainingPlanById(trainingPlan *model.TrainingPlan, userId uint, trainingPlanId uint) error {
+	if err := tpr.db.Where("id = ? AND user_id = ?", trainingPlanId, userId).First(trainingPlan).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateTrainingPlan は再生成した計画（VDOTと週ごとの練習）を保存し、from 以降の予定を plannedWorkouts に置き換える
+func (tpr *trainingPlanRepository) UpdateTrainingPlan(trainingPlan *model.TrainingPlan, userId uint, trainingPlanId uint, from time.Time, plannedWorkouts []model.PlannedWorkout) error {
+	return tpr.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(trainingPlan).Select("vdot", "weeks").Where("id = ? AND user_id = ?", trainingPlanId, userId).Updates(trainingPlan)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < 1 {
+			return gorm.ErrRecordNotFound
+		}
+		return replacePlanWorkouts(tx, userId, trainingPlanId, from, plannedWorkouts)
+	})
+}
+
+func (tpr *trainingPlanRepository) DeleteTrainingPlan(userId uint, trainingPlanId uint) error {
+	result := tpr.db.Where("id = ? AND user_id = ?", trainingPlanId, userId).Delete(&model.TrainingPlan{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"go_vdot_api/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IUserIdentityRepository interface {
+	GetIdentity(identity *model.UserIdentity, provider string, subject string) error
+	GetIdentities(userId uint) ([]model.UserIdentity, error)
+	CreateIdentity(identity *model.UserIdentity) error
+	CreateUserWithIdentity(user *model.User, identity *model.UserIdentity) error
+	UpdateLastLogin(identityId uint, email string) error
+	DeleteIdentity(userId uint, provider string) error
+}
+
+type userIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewUserIdentityRepository(db *gorm.DB) IUserIdentityRepository {
+	return &userIdentityRepository{db}
+}
+
+func (uir *userIdentityRepository) GetIdentity(identity *model.UserIdentity, provider string, subject string) error {
+	if err := uir.db.Where("provider = ? AND subject = ?", provider, subject).First(identity).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (uir *userIdentityRepository) GetIdentities(userId uint) ([]model.UserIdentity, error) {
+	identities := []model.UserIdentity{}
+	if err := uir.db.Where("user_id = ?", userId).Order("provider").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+func (uir *userIdentityRepository) CreateIdentity(identity *model.UserIdentity) error {
+	if err := uir.db.Create(identity).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// CreateUserWithIdentity は外部のアカウントで登録したユーザーとその紐づけを同じトランザクションで作る
+func (uir *userIdentityRepository) CreateUserWithIdentity(user *model.User, identity *model.UserIdentity) error {
+	return uir.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserId = user.ID
+		return tx.Create(identity).Error
+	})
+}
+
+// UpdateLastLogin はログインした日時と、サービスで変更されたメールアドレスを保存する
+func (uir *userIdentityRepository) UpdateLastLogin(identityId uint, email string) error {
+	if err := uir.db.Model(&model.UserIdentity{}).Where("id = ?", identityId).
+		Updates(map[string]interface{}{"last_login_at": time.Now(), "email": email}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (uir *userIdentityRepository) DeleteIdentity(userId uint, provider string) error {
+	result := uir.db.Where("user_id = ? AND provider = ?", userId, provider).Delete(&model.UserIdentity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -1,7 +1,11 @@
 package repository
 
 import (
+	"database/sql"
 	"go_vdot_api/model"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -10,7 +14,15 @@ type IUserRepository interface {
 	GetUserByID(user *model.User, userId uint) error
 	CreateUser(user *model.User) error
 	UpdateUser(user *model.User) error
-	DeleteUser(userId uint) error
+	DeleteUser(userId uint, auditLog *model.AuditLog) (model.UserRecordCounts, error)
+	SearchUsers(query string, limit int, offset int) ([]model.User, int64, error)
+	SetUserDisabled(userId uint, disabledAt *time.Time) error
+	UpdatePassword(userId uint, hash string) error
+	SetEmailVerified(userId uint, email string, verifiedAt *time.Time) error
+	RecordLoginFailure(userId uint) (int, error)
+	LockUser(userId uint, until time.Time) error
+	ResetLoginFailures(userId uint) error
+	CountUserRecords(userId uint) (model.UserRecordCounts, error)
 }
 
 type userRepository struct {
@@ -53,9 +65,166 @@ func (ur *userRepository) UpdateUser(user *model.User) error {
 	return nil
 }
 
-func (ur *userRepository) DeleteUser(userId uint) error {
-	if err := ur.db.Delete(&model.User{}, userId).Error; err != nil {
+// DeleteUser はユーザーとそのすべてのデータを1つのトランザクションで削除し、テーブルごとの件数を返す
+// auditLog には削除した件数を detail の removed に入れ、同じトランザクションで保存する
+func (ur *userRepository) DeleteUser(userId uint, auditLog *model.AuditLog) (model.UserRecordCounts, error) {
+	counts := model.UserRecordCounts{}
+	err := ur.db.Transaction(func(tx *gorm.DB) error {
+		// 論理削除のテーブルも物理削除する
+		for _, t := range userRecordTables(&counts) {
+			result := tx.Unscoped().Where(t.where, sql.Named("user_id", userId)).Delete(t.table)
+			if result.Error != nil {
+				return result.Error
+			}
+			*t.count = result.RowsAffected
+		}
+
+		result := tx.Delete(&model.User{}, userId)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < 1 {
+			return gorm.ErrRecordNotFound
+		}
+
+		if auditLog.Detail == nil {
+			auditLog.Detail = model.AuditDetail{}
+		}
+		auditLog.Detail["removed"] = counts
+		return tx.Create(auditLog).Error
+	})
+	if err != nil {
+		return model.UserRecordCounts{}, err
+	}
+	return counts, nil
+}
+
+type userRecordTable struct {
+	table interface{}
+	where string // @user_id にユーザーの ID を入れる
+	count *int64
+}
+
+const userRecordWhere = "user_id = @user_id"
+
+// userRecordTables はユーザーのデータのテーブルと、件数を入れる counts のフィールド（外部キーの参照元から順）
+func userRecordTables(counts *model.UserRecordCounts) []userRecordTable {
+	return []userRecordTable{
+		{&model.PlannedWorkout{}, userRecordWhere, &counts.PlannedWorkouts},
+		{&model.TrainingPlan{}, userRecordWhere, &counts.TrainingPlans},
+		{&model.Workout{}, userRecordWhere, &counts.Workouts},
+		{&model.Vdot{}, userRecordWhere, &counts.Vdots},
+		{&model.SpecialtyEvent{}, userRecordWhere, &counts.SpecialtyEvents},
+		{&model.ZoneProfile{}, userRecordWhere, &counts.ZoneProfiles},
+		// コーチとしてのつながりも選手としてのつながりも削除する
+		{&model.CoachAthlete{}, "coach_id = @user_id OR athlete_id = @user_id", &counts.CoachAthletes},
+		{&model.Session{}, userRecordWhere, &counts.Sessions},
+		{&model.UserToken{}, userRecordWhere, &counts.UserTokens},
+		{&model.UserIdentity{}, userRecordWhere, &counts.UserIdentities},
+		{&model.PersonalAccessToken{}, userRecordWhere, &counts.AccessTokens},
+	}
+}
+
+// likeEscaper は LIKE の検索語の % と _ を文字として扱うためのエスケープ
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchUsers は名前またはメールアドレスに query を含むユーザーを ID 順に取得し、一致した総数も返す
+func (ur *userRepository) SearchUsers(query string, limit int, offset int) ([]model.User, int64, error) {
+	db := ur.db.Model(&model.User{})
+	if query != "" {
+		like := "%" + likeEscaper.Replace(query) + "%"
+		db = db.Where("name LIKE ? OR email LIKE ?", like, like)
+	}
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	users := []model.User{}
+	if err := db.Order("id").Limit(limit).Offset(offset).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// SetUserDisabled はユーザーを無効（disabledAt が nil の場合は有効）にする
+func (ur *userRepository) SetUserDisabled(userId uint, disabledAt *time.Time) error {
+	result := ur.db.Model(&model.User{}).Where("id = ?", userId).Update("disabled_at", disabledAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (ur *userRepository) UpdatePassword(userId uint, hash string) error {
+	result := ur.db.Model(&model.User{}).Where("id = ?", userId).Update("password", hash)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SetEmailVerified はメールアドレスを確認した日時を更新する（verifiedAt が nil の場合は未確認に戻す）
+// 確認までにメールアドレスが変更された場合に備え、email が現在のものと一致する場合のみ更新する
+func (ur *userRepository) SetEmailVerified(userId uint, email string, verifiedAt *time.Time) error {
+	result := ur.db.Model(&model.User{}).Where("id = ? AND email = ?", userId, email).Update("email_verified_at", verifiedAt)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RecordLoginFailure はログインの失敗を1回増やし、続けて失敗した回数を返す
+func (ur *userRepository) RecordLoginFailure(userId uint) (int, error) {
+	user := model.User{}
+	err := ur.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.User{}).Where("id = ?", userId).
+			Update("failed_login_attempts", gorm.Expr("failed_login_attempts + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < 1 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Select("failed_login_attempts").First(&user, userId).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return user.FailedLoginAttempts, nil
+}
+
+func (ur *userRepository) LockUser(userId uint, until time.Time) error {
+	if err := ur.db.Model(&model.User{}).Where("id = ?", userId).Update("locked_until", until).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// ResetLoginFailures はログインの失敗の回数とロックを解除する
+func (ur *userRepository) ResetLoginFailures(userId uint) error {
+	if err := ur.db.Model(&model.User{}).Where("id = ?", userId).
+		Updates(map[string]interface{}{"failed_login_attempts": 0, "locked_until": nil}).Error; err != nil {
 		return err
 	}
 	return nil
 }
+
+// CountUserRecords はユーザーのデータのテーブルごとの件数を返す（論理削除済みを含む）
+func (ur *userRepository) CountUserRecords(userId uint) (model.UserRecordCounts, error) {
+	counts := model.UserRecordCounts{}
+	for _, t := range userRecordTables(&counts) {
+		if err := ur.db.Unscoped().Model(t.table).Where(t.where, sql.Named("user_id", userId)).Count(t.count).Error; err != nil {
+			return model.UserRecordCounts{}, err
+		}
+	}
+	return counts, nil
+}
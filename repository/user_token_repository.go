@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"go_vdot_api/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IUserTokenRepository interface {
+	CreateUserToken(userToken *model.UserToken) error
+	GetUserToken(userToken *model.UserToken, purpose string, tokenHash string) error
+	UseUserToken(userTokenId uint) error
+	ExpireUserTokens(userId uint, purpose string) error
+	GetUserTokens(userId uint) ([]model.UserToken, error)
+}
+
+type userTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewUserTokenRepository(db *gorm.DB) IUserTokenRepository {
+	return &userTokenRepository{db}
+}
+
+func (utr *userTokenRepository) CreateUserToken(userToken *model.UserToken) error {
+	if err := utr.db.Create(userToken).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (utr *userTokenRepository) GetUserToken(userToken *model.UserToken, purpose string, tokenHash string) error {
+	if err := utr.db.Where("purpose = ? AND token_hash = ?", purpose, tokenHash).First(userToken).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// UseUserToken はトークンを使用済みにする
+// 同時に使われた場合に片方だけが成功するよう、未使用のトークンのみ更新する
+func (utr *userTokenRepository) UseUserToken(userTokenId uint) error {
+	result := utr.db.Model(&model.UserToken{}).
+		Where("id = ? AND used_at IS NULL", userTokenId).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ExpireUserTokens はユーザーの未使用のトークンを使えなくする（新しいトークンを送る前に古いものを無効にする）
+func (utr *userTokenRepository) ExpireUserTokens(userId uint, purpose string) error {
+	now := time.Now()
+	return utr.db.Model(&model.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?", userId, purpose, now).
+		Update("expires_at", now).Error
+}
+
+// GetUserTokens は使用済み・期限切れのものを含むすべてのトークンを古い順に取得する
+func (utr *userTokenRepository) GetUserTokens(userId uint) ([]model.UserToken, error) {
+	userTokens := []model.UserToken{}
+	if err := utr.db.Where("user_id = ?", userId).Order("id").Find(&userTokens).Error; err != nil {
+		return nil, err
+	}
+	return userTokens, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"go_vdot_api/model"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -9,8 +10,14 @@ import (
 
 type IVdotRepository interface {
 	CreateVdot(vdot *model.Vdot) error
+	CreateVdots(vdots []model.Vdot) error
 	GetVdot(vdot *model.Vdot, userId uint) error
+	GetVdotHistory(userId uint) ([]model.Vdot, error)
+	GetVdotsSince(userId uint, since time.Time) ([]model.Vdot, error)
+	GetPinnedVdot(vdot *model.Vdot, userId uint) error
 	UpdateVdot(vdot *model.Vdot, userId uint, vdotId uint) error
+	PinVdot(userId uint, vdotId uint) error
+	UnpinVdot(userId uint) error
 }
 
 type vdotRepository struct {
@@ -28,15 +35,55 @@ func (vr *vdotRepository) CreateVdot(vdot *model.Vdot) error {
 	return nil
 }
 
+// CreateVdots は複数の記録を1つのトランザクションで保存する（1件でも失敗した場合はすべて保存しない）
+func (vr *vdotRepository) CreateVdots(vdots []model.Vdot) error {
+	return vr.db.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(&vdots, 100).Error
+	})
+}
+
+// GetVdot は最新の記録（記録日が新しい順、同日なら登録が新しい順）を取得する
 func (vr *vdotRepository) GetVdot(vdot *model.Vdot, userId uint) error {
-	if err := vr.db.Joins("User").Where("user_id = ?", userId).First(vdot).Error; err != nil {
+	if err := vr.db.
+		Where("user_id = ?", userId).
+		Order("recorded_at DESC").Order("id DESC").
+		First(vdot).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (vr *vdotRepository) GetVdotHistory(userId uint) ([]model.Vdot, error) {
+	vdots := []model.Vdot{}
+	if err := vr.db.
+		Where("user_id = ?", userId).
+		Order("recorded_at DESC").Order("id DESC").
+		Find(&vdots).Error; err != nil {
+		return nil, err
+	}
+	return vdots, nil
+}
+
+func (vr *vdotRepository) GetVdotsSince(userId uint, since time.Time) ([]model.Vdot, error) {
+	vdots := []model.Vdot{}
+	if err := vr.db.
+		Where("user_id = ? AND recorded_at >= ?", userId, since.Format("2006-01-02")).
+		Order("recorded_at DESC").Order("id DESC").
+		Find(&vdots).Error; err != nil {
+		return nil, err
+	}
+	return vdots, nil
+}
+
+func (vr *vdotRepository) GetPinnedVdot(vdot *model.Vdot, userId uint) error {
+	if err := vr.db.Where("user_id = ? AND is_pinned = ?", userId, true).First(vdot).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
 func (vr *vdotRepository) UpdateVdot(vdot *model.Vdot, userId uint, vdotId uint) error {
-	result := vr.db.Model(vdot).Clauses(clause.Returning{}).Where("id = ? AND user_id = ?", vdotId, userId).Updates(vdot)
+	result := vr.db.Model(vdot).Clauses(clause.Returning{}).Omit("is_pinned").Where("id = ? AND user_id = ?", vdotId, userId).Updates(vdot)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -45,3 +92,27 @@ func (vr *vdotRepository) UpdateVdot(vdot *model.Vdot, userId uint, vdotId uint)
 	}
 	return nil
 }
+
+// PinVdot は指定した記録だけを固定状態にする（ユーザーごとに固定できる記録は1件）
+func (vr *vdotRepository) PinVdot(userId uint, vdotId uint) error {
+	return vr.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Vdot{}).Where("user_id = ? AND is_pinned = ?", userId, true).Update("is_pinned", false).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&model.Vdot{}).Where("id = ? AND user_id = ?", vdotId, userId).Update("is_pinned", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected < 1 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+func (vr *vdotRepository) UnpinVdot(userId uint) error {
+	if err := vr.db.Model(&model.Vdot{}).Where("user_id = ? AND is_pinned = ?", userId, true).Update("is_pinned", false).Error; err != nil {
+		return err
+	}
+	return nil
+}
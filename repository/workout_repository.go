@@ -1,15 +1,39 @@
 package repository
 
 import (
+	"fmt"
 	"go_vdot_api/model"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// 集計の単位と、それぞれの期間を表すSQL
+const (
+	AggregateWeek  = "week"
+	AggregateMonth = "month"
+	AggregateYear  = "year"
+)
+
+var aggregatePeriods = map[string]string{
+	AggregateWeek:  "YEARWEEK(date, 3)", // ISO週（月曜始まり）例：202405
+	AggregateMonth: "DATE_FORMAT(date, '%Y-%m')",
+	AggregateYear:  "CAST(YEAR(date) AS CHAR)",
+}
+
+// mileageKmSQL は mileage を km に換算するSQL
+const mileageKmSQL = "CASE WHEN mileage_unit = 'mile' THEN mileage * 1.609344 ELSE mileage END"
+
 type IWorkoutRepository interface {
 	CreateWorkout(workout *model.Workout) error
+	CreateWorkouts(workouts []model.Workout) error
 	GetWorkoutPerMonth(userId uint, year int, month int) ([]model.Workout, error)
+	GetWorkoutById(workout *model.Workout, userId uint, workoutId uint) error
+	GetWorkoutsInRange(userId uint, from time.Time, to time.Time, after *model.Workout, desc bool, limit int) ([]model.Workout, error)
+	AggregateWorkouts(userId uint, from time.Time, to time.Time, period string) ([]model.WorkoutAggregate, error)
+	GetLongestWorkout(workout *model.Workout, userId uint, from time.Time, to time.Time) error
 	UpdateWorkout(workout *model.Workout, userId uint, workoutId uint) error
+	DeleteWorkout(userId uint, workoutId uint) error
 }
 
 type workoutRepository struct {
@@ -27,6 +51,13 @@ func (wr *workoutRepository) CreateWorkout(workout *model.Workout) error {
 	return nil
 }
 
+// CreateWorkouts は複数の練習を1つのトランザクションで保存する（1件でも失敗した場合はすべて保存しない）
+func (wr *workoutRepository) CreateWorkouts(workouts []model.Workout) error {
+	return wr.db.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(&workouts, 100).Error
+	})
+}
+
 func (wr *workoutRepository) GetWorkoutPerMonth(userId uint, year int, month int) ([]model.Workout, error) {
 	workouts := []model.Workout{}
 	if err := wr.db.
@@ -37,6 +68,74 @@ func (wr *workoutRepository) GetWorkoutPerMonth(userId uint, year int, month int
 	return workouts, nil
 }
 
+func (wr *workoutRepository) GetWorkoutById(workout *model.Workout, userId uint, workoutId uint) error {
+	if err := wr.db.Where("id = ? AND user_id = ?", workoutId, userId).First(workout).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetWorkoutsInRange は from〜to の練習を日付・開始時刻順に最大 limit 件取得する
+// after を指定した場合は、その練習より後（desc の場合は前）のものだけを返す
+// from, to がゼロ値の場合はその側の範囲を絞らない。limit が 0 以下の場合は全件を返す
+func (wr *workoutRepository) GetWorkoutsInRange(userId uint, from time.Time, to time.Time, after *model.Workout, desc bool, limit int) ([]model.Workout, error) {
+	query := wr.db.Where("user_id = ?", userId)
+	if !from.IsZero() {
+		query = query.Where("date >= ?", from.Format("2006-01-02"))
+	}
+	if !to.IsZero() {
+		query = query.Where("date <= ?", to.Format("2006-01-02"))
+	}
+
+	order, op := "ASC", ">"
+	if desc {
+		order, op = "DESC", "<"
+	}
+	if after != nil {
+		query = query.Where(fmt.Sprintf("(date, start_time, id) %s (?, ?, ?)", op), after.Date, after.StartTime, after.ID)
+	}
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	workouts := []model.Workout{}
+	if err := query.
+		Order(fmt.Sprintf("date %s, start_time %s, id %s", order, order, order)).
+		Find(&workouts).Error; err != nil {
+		return nil, err
+	}
+	return workouts, nil
+}
+
+// AggregateWorkouts は期間（week, month, year）ごとに練習回数・距離（km）・最長距離（km）を集計する
+func (wr *workoutRepository) AggregateWorkouts(userId uint, from time.Time, to time.Time, period string) ([]model.WorkoutAggregate, error) {
+	periodSQL, ok := aggregatePeriods[period]
+	if !ok {
+		return nil, fmt.Errorf("invalid period: %s", period)
+	}
+	aggregates := []model.WorkoutAggregate{}
+	if err := wr.db.Model(&model.Workout{}).
+		Select(fmt.Sprintf("%s AS period, COUNT(*) AS sessions, SUM(%s) AS distance_km, MAX(%s) AS longest_km", periodSQL, mileageKmSQL, mileageKmSQL)).
+		Where("user_id = ? AND date BETWEEN ? AND ?", userId, from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Group("period").
+		Order("period").
+		Scan(&aggregates).Error; err != nil {
+		return nil, err
+	}
+	return aggregates, nil
+}
+
+func (wr *workoutRepository) GetLongestWorkout(workout *model.Workout, userId uint, from time.Time, to time.Time) error {
+	if err := wr.db.
+		Where("user_id = ? AND date BETWEEN ? AND ?", userId, from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order(mileageKmSQL + " DESC").Order("date").
+		First(workout).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
 func (wr *workoutRepository) UpdateWorkout(workout *model.Workout, userId uint, workoutId uint) error {
 	result := wr.db.Model(workout).Where("id = ? AND user_id = ?", workoutId, userId).Updates(workout)
 	if result.Error != nil {
@@ -47,3 +146,14 @@ func (wr *workoutRepository) UpdateWorkout(workout *model.Workout, userId uint,
 	}
 	return nil
 }
+
+func (wr *workoutRepository) DeleteWorkout(userId uint, workoutId uint) error {
+	result := wr.db.Where("id = ? AND user_id = ?", workoutId, userId).Delete(&model.Workout{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"go_vdot_api/model"
+
+	"gorm.io/gorm"
+)
+
+type IZoneProfileRepository interface {
+	CreateZoneProfile(zoneProfile *model.ZoneProfile) error
+	GetZoneProfiles(userId uint) ([]model.ZoneProfile, error)
+	GetZoneProfileById(zoneProfile *model.ZoneProfile, userId uint, zoneProfileId uint) error
+	GetCoachZoneProfiles(athleteId uint) ([]model.ZoneProfile, error)
+	GetUsableZoneProfileById(zoneProfile *model.ZoneProfile, userId uint, zoneProfileId uint) error
+	UpdateZoneProfile(zoneProfile *model.ZoneProfile, userId uint, zoneProfileId uint) error
+	DeleteZoneProfile(userId uint, zoneProfileId uint) error
+}
+
+type zoneProfileRepository struct {
+	db *gorm.DB
+}
+
+func NewZoneProfileRepository(db *gorm.DB) IZoneProfileRepository {
+	return &zoneProfileRepository{db}
+}
+
+func (zpr *zoneProfileRepository) CreateZoneProfile(zoneProfile *model.ZoneProfile) error {
+	if err := zpr.db.Create(zoneProfile).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (zpr *zoneProfileRepository) GetZoneProfiles(userId uint) ([]model.ZoneProfile, error) {
+	zoneProfiles := []model.ZoneProfile{}
+	if err := zpr.db.Where("user_id = ?", userId).Order("id").Find(&zoneProfiles).Error; err != nil {
+		return nil, err
+	}
+	return zoneProfiles, nil
+}
+
+func (zpr *zoneProfileRepository) GetZoneProfileById(zoneProfile *model.ZoneProfile, userId uint, zoneProfileId uint) error {
+	if err := zpr.db.Where("id = ? AND user_id = ?", zoneProfileId, userId).First(zoneProfile).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// coachProfileWhere は選手（1つ目の ? ）を承認されたコーチが作ったゾーン定義の条件
+const coachProfileWhere = "user_id IN (SELECT coach_id FROM coach_athletes WHERE athlete_id = ? AND status = ?)"
+
+// GetCoachZoneProfiles は選手の承認されたコーチのゾーン定義を取得する（コーチは自分の定義を選手のペースに使える）
+func (zpr *zoneProfileRepository) GetCoachZoneProfiles(athleteId uint) ([]model.ZoneProfile, error) {
+	zoneProfiles := []model.ZoneProfile{}
+	if err := zpr.db.Where(coachProfileWhere, athleteId, model.CoachLinkAccepted).Order("id").Find(&zoneProfiles).Error; err != nil {
+		return nil, err
+	}
+	return zoneProfiles, nil
+}
+
+// GetUsableZoneProfileById はユーザー本人または承認されたコーチのゾーン定義を取得する
+func (zpr *zoneProfileRepository) GetUsableZoneProfileById(zoneProfile *model.ZoneProfile, userId uint, zoneProfileId uint) error {
+	if err := zpr.db.
+		Where("id = ? AND (user_id = ? OR "+coachProfileWhere+")", zoneProfileId, userId, userId, model.CoachLinkAccepted).
+		First(zoneProfile).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (zpr *zoneProfileRepository) UpdateZoneProfile(zoneProfile *model.ZoneProfile, userId uint, zoneProfileId uint) error {
+	result := zpr.db.Model(zoneProfile).Select("name", "zones", "distances").Where("id = ? AND user_id = ?", zoneProfileId, userId).Updates(zoneProfile)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (zpr *zoneProfileRepository) DeleteZoneProfile(userId uint, zoneProfileId uint) error {
+	result := zpr.db.Where("id = ? AND user_id = ?", zoneProfileId, userId).Delete(&model.ZoneProfile{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected < 1 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package router
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// IPExtractorFromEnv は c.RealIP() でクライアントの IP アドレスを取り出す方法を返す
+// TRUSTED_PROXIES（カンマ区切りの CIDR）が未設定の場合は X-Forwarded-For・X-Real-IP を信用せず接続元のアドレスを使う
+// 設定した場合は、その範囲のプロキシが付けた X-Forwarded-For だけをたどる
+// （ログインの回数制限・セッション・監査ログに使うため、クライアントが自由に書き換えられるヘッダーは信用しない）
+func IPExtractorFromEnv() (echo.IPExtractor, error) {
+	value := strings.TrimSpace(os.Getenv("TRUSTED_PROXIES"))
+	if value == "" {
+		return echo.ExtractIPDirect(), nil
+	}
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, cidr := range strings.Split(value, ",") {
+		_, ipRange, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid TRUSTED_PROXIES %q: %v", cidr, err)
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
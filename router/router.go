@@ -2,16 +2,18 @@ package router
 
 import (
 	"go_vdot_api/controller"
+	"go_vdot_api/pkg/logger"
+	"go_vdot_api/usecase"
 	"net/http"
 	"os"
-	"go_vdot_api/pkg/logger"
+	"time"
 
-	mymiddleware "go_vdot_api/middleware"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	mymiddleware "go_vdot_api/middleware"
 )
 
-func NewRouter(uc controller.IUserController, vc controller.IVdotController, wc controller.IWorkoutController, sec controller.ISpecialtyEventController) *echo.Echo {
+func NewRouter(uc controller.IUserController, vc controller.IVdotController, wc controller.IWorkoutController, sec controller.ISpecialtyEventController, zpc controller.IZoneProfileController, tlc controller.ITrainingLoadController, tpc controller.ITrainingPlanController, pwc controller.IPlannedWorkoutController, cac controller.ICoachAthleteController, ac controller.IAdminController, oc controller.IOAuthController, patc controller.IPersonalAccessTokenController, accessChecker mymiddleware.AccessChecker, sessionChecker mymiddleware.SessionChecker, tokenAuthenticator mymiddleware.TokenAuthenticator) *echo.Echo {
 	router := echo.New()
 	router.Use(logger.RequestLogger())
 	router.Use(middleware.CORSWithConfig(middleware.CORSConfig{
@@ -21,7 +23,17 @@ func NewRouter(uc controller.IUserController, vc controller.IVdotController, wc
 		AllowMethods:     []string{"GET", "PUT", "PATCH", "POST", "DELETE"},
 		AllowCredentials: true,
 	}))
+	bearerAuthenticated := mymiddleware.BearerAuthenticated(tokenAuthenticator)
 	router.Use(middleware.CSRFWithConfig(middleware.CSRFConfig{
+		// Apple のコールバックは認可画面からの POST のため CSRF トークンを持たない（state で確認する）
+		// 個人用アクセストークンで認証したリクエストは Cookie を使わないため CSRF トークンは不要
+		// （Authorization は AllowHeaders に含めないため、ブラウザから他のオリジンでは送れない）
+		Skipper: func(c echo.Context) bool {
+			if c.Request().Method == http.MethodPost && c.Path() == "/api/auth/oauth/:provider/callback" {
+				return true
+			}
+			return bearerAuthenticated(c)
+		},
 		CookiePath:     "/",
 		CookieDomain:   os.Getenv("API_DOMAIN"),
 		CookieHTTPOnly: true,
@@ -32,43 +44,175 @@ func NewRouter(uc controller.IUserController, vc controller.IVdotController, wc
 	// CSRFトークンを取得するためのエンドポイント
 	auth := router.Group("/api/auth")
 	auth.POST("/signup", uc.SignUp)
-	auth.POST("/login", uc.LogIn)
+	// ログインの試行は IP アドレスごとに1分間20回、メールアドレスごとに15分間10回まで
+	loginRateLimit := mymiddleware.LoginRateLimit(mymiddleware.NewRateLimiter(20, time.Minute), mymiddleware.NewRateLimiter(10, 15*time.Minute))
+	auth.POST("/login", uc.LogIn, loginRateLimit)
 	auth.POST("/logout", uc.LogOut)
+	auth.POST("/refresh", uc.Refresh)
+	auth.POST("/verify_email", uc.VerifyEmail)
+	auth.POST("/password/forgot", uc.ForgotPassword)
+	auth.POST("/password/reset", uc.ResetPassword)
 	auth.GET("/csrf", uc.CsrfToken)
-	auth.Use(mymiddleware.JWTMiddleware())
+	// 外部のアカウント（Google・Apple・Strava）でのログイン
+	auth.GET("/oauth/:provider/login", oc.LogIn)
+	auth.GET("/oauth/:provider/callback", oc.Callback)
+	auth.POST("/oauth/:provider/callback", oc.Callback)
+	// アクセストークンを検証し、無効にされたセッションを拒否するミドルウェア（以降のグループで共通）
+	jwtAuth := mymiddleware.JWTMiddleware(sessionChecker, tokenAuthenticator)
+	// アカウント・セッション・トークンの管理は個人用アクセストークンではできない
+	cookieOnly := mymiddleware.CookieOnly()
+	auth.Use(jwtAuth)
 
 	// ログイン確認用エンドポイント
 	authCheck := auth.Group("")
-	authCheck.Use(mymiddleware.JWTMiddleware())
+	authCheck.Use(jwtAuth)
 	authCheck.GET("/check", mymiddleware.CheckAuth)
+	authCheck.GET("/sessions", uc.GetSessions, cookieOnly)
+	authCheck.DELETE("/sessions/:id", uc.RevokeSession, cookieOnly)
+	authCheck.POST("/verify_email/resend", uc.ResendVerificationEmail, cookieOnly)
+
+	// 個人用アクセストークン（スクリプト・連携サービス用）のエンドポイント
+	accessToken := router.Group("/api/tokens")
+	accessToken.Use(jwtAuth, cookieOnly)
+	accessToken.POST("", patc.CreateToken)
+	accessToken.GET("", patc.GetTokens)
+	accessToken.DELETE("/:id", patc.RevokeToken)
 
 	// ユーザー情報取得用エンドポイント
 	user := router.Group("/api/user")
-	user.Use(mymiddleware.JWTMiddleware())
+	user.Use(jwtAuth, cookieOnly)
 	user.PATCH("", uc.UpdateUser)
 	user.DELETE("", uc.DeleteUser)
-	
+	user.POST("/delete/email", uc.SendAccountDeletionEmail)
+	user.GET("/export", uc.ExportAccount)
+	user.GET("/identities", oc.GetIdentities)
+	user.GET("/identities/:provider/link", oc.Link)
+	user.DELETE("/identities/:provider", oc.Unlink)
+
 	// Vdot関連のエンドポイント
 	vdot := router.Group("/api/vdots")
-	vdot.Use(mymiddleware.JWTMiddleware())
+	vdot.Use(jwtAuth)
 	vdot.POST("", vc.CreateVdot)
 	vdot.GET("", vc.GetVdot)
+	vdot.GET("/history", vc.GetVdotHistory)
+	vdot.POST("/import", vc.ImportVdots)
+	vdot.GET("/export", vc.ExportVdots)
 	vdot.PATCH("/:id", vc.UpdateVdot)
+	vdot.PATCH("/:id/pin", vc.PinVdot)
+	vdot.DELETE("/pin", vc.UnpinVdot)
 	vdot.GET("/value", vc.GetUserVdotValue)
 
+	// 記録を保存しないVDOT計算用エンドポイント
+	vdotCalculator := router.Group("/api/vdot")
+	vdotCalculator.Use(jwtAuth)
+	vdotCalculator.POST("/calculate", vc.CalculateVdot)
+	vdotCalculator.GET("/zones", vc.GetPaceZonesByVdot)
+
+	// v2: 型付き・snake_case で統一したレスポンスを返すエンドポイント
+	// （/api/vdots/value などの旧形式は既存クライアントのために残している）
+	v2 := router.Group("/api/v2")
+	v2.Use(jwtAuth)
+	v2.GET("/vdots/value", vc.GetUserVdotValueV2)
+	v2.POST("/vdot/calculate", vc.CalculateVdotV2)
+	v2.GET("/vdot/zones", vc.GetPaceZonesByVdotV2)
+
 	// Workout関連のエンドポイント
 	workout := router.Group("/api/workouts")
-	workout.Use(mymiddleware.JWTMiddleware())
+	workout.Use(jwtAuth)
 	workout.POST("", wc.CreateWorkout)
-	workout.GET("", wc.GetWorkoutPerMonth)
+	workout.GET("", wc.GetWorkouts)
+	workout.POST("/import", wc.ImportWorkout)
+	workout.GET("/export", wc.ExportWorkouts)
+	workout.GET("/stats", wc.GetWorkoutStats)
+	workout.GET("/calendar", pwc.GetCalendar)
+	workout.GET("/:id", wc.GetWorkoutById)
 	workout.PATCH("/:id", wc.UpdateWorkout)
+	workout.DELETE("/:id", wc.DeleteWorkout)
+	workout.GET("/:id/analysis", wc.AnalyzeWorkout)
 
 	// SpecialtyEvent関連のエンドポイント
 	specialtyEvent := router.Group("/api/specialty_events")
-	specialtyEvent.Use(mymiddleware.JWTMiddleware())
+	specialtyEvent.Use(jwtAuth)
 	specialtyEvent.POST("", sec.CreateSpecialtyEvent)
 	specialtyEvent.GET("", sec.GetSpecialtyEvent)
+	specialtyEvent.GET("/summary", sec.GetSpecialtyEventSummary)
+	specialtyEvent.POST("/import", sec.ImportSpecialtyEvents)
+	specialtyEvent.GET("/export", sec.ExportSpecialtyEvents)
 	specialtyEvent.PATCH("/:id", sec.UpdateSpecialtyEvent)
 
+	// ゾーン定義（ペースゾーンの%と距離）関連のエンドポイント
+	zoneProfile := router.Group("/api/zone_profiles")
+	zoneProfile.Use(jwtAuth)
+	zoneProfile.POST("", zpc.CreateZoneProfile)
+	zoneProfile.GET("", zpc.GetZoneProfiles)
+	zoneProfile.GET("/:id", zpc.GetZoneProfileById)
+	zoneProfile.PATCH("/:id", zpc.UpdateZoneProfile)
+	zoneProfile.DELETE("/:id", zpc.DeleteZoneProfile)
+
+	// トレーニング負荷のエンドポイント
+	trainingLoad := router.Group("/api/training-load")
+	trainingLoad.Use(jwtAuth)
+	trainingLoad.GET("", tlc.GetTrainingLoad)
+
+	// 練習計画関連のエンドポイント
+	trainingPlan := router.Group("/api/training_plans")
+	trainingPlan.Use(jwtAuth)
+	trainingPlan.POST("", tpc.CreateTrainingPlan)
+	trainingPlan.GET("", tpc.GetTrainingPlans)
+	trainingPlan.GET("/:id", tpc.GetTrainingPlanById)
+	trainingPlan.POST("/:id/regenerate", tpc.RegenerateTrainingPlan)
+	trainingPlan.DELETE("/:id", tpc.DeleteTrainingPlan)
+
+	// 予定している練習関連のエンドポイント
+	plannedWorkout := router.Group("/api/planned_workouts")
+	plannedWorkout.Use(jwtAuth)
+	plannedWorkout.POST("", pwc.CreatePlannedWorkout)
+	plannedWorkout.GET("/:id", pwc.GetPlannedWorkoutById)
+	plannedWorkout.PATCH("/:id", pwc.UpdatePlannedWorkout)
+	plannedWorkout.DELETE("/:id", pwc.DeletePlannedWorkout)
+
+	// コーチと選手のつながり（招待・承認・解除）のエンドポイント
+	coachLink := router.Group("/api/coach_links")
+	coachLink.Use(jwtAuth)
+	coachLink.POST("", cac.InviteAthlete)
+	coachLink.GET("", cac.GetCoachLinks)
+	coachLink.POST("/:id/accept", cac.AcceptInvitation)
+	coachLink.DELETE("/:id", cac.DeleteCoachAthlete)
+
+	// コーチが選手のデータを扱うエンドポイント（本人または承認されたコーチのみ）
+	// 各ハンドラーは AthleteAccess が保存した選手の ID を対象にする
+	athlete := router.Group("/api/athletes/:athlete_id")
+	athlete.Use(jwtAuth)
+	canRead := mymiddleware.AthleteAccess(accessChecker, usecase.PermissionReadAthlete)
+	canPlan := mymiddleware.AthleteAccess(accessChecker, usecase.PermissionPlanAthlete)
+	athlete.GET("/vdots", vc.GetVdot, canRead)
+	athlete.GET("/vdots/history", vc.GetVdotHistory, canRead)
+	athlete.GET("/vdots/value", vc.GetUserVdotValue, canRead)
+	athlete.GET("/workouts", wc.GetWorkouts, canRead)
+	athlete.GET("/workouts/stats", wc.GetWorkoutStats, canRead)
+	athlete.GET("/workouts/calendar", pwc.GetCalendar, canRead)
+	athlete.GET("/workouts/:id", wc.GetWorkoutById, canRead)
+	athlete.GET("/workouts/:id/analysis", wc.AnalyzeWorkout, canRead)
+	athlete.GET("/specialty_events", sec.GetSpecialtyEvent, canRead)
+	athlete.GET("/specialty_events/summary", sec.GetSpecialtyEventSummary, canRead)
+	athlete.GET("/training-load", tlc.GetTrainingLoad, canRead)
+	athlete.GET("/zone_profiles", zpc.GetZoneProfiles, canRead)
+	athlete.GET("/zone_profiles/:id", zpc.GetZoneProfileById, canRead)
+	athlete.GET("/planned_workouts/:id", pwc.GetPlannedWorkoutById, canRead)
+	athlete.POST("/planned_workouts", pwc.CreatePlannedWorkout, canPlan)
+	athlete.PATCH("/planned_workouts/:id", pwc.UpdatePlannedWorkout, canPlan)
+	athlete.DELETE("/planned_workouts/:id", pwc.DeletePlannedWorkout, canPlan)
+
+	// 管理者向けのエンドポイント（is_admin のユーザーのみ）
+	admin := router.Group("/api/admin")
+	admin.Use(jwtAuth, cookieOnly, mymiddleware.AdminOnly())
+	admin.GET("/users", ac.GetUsers)
+	admin.GET("/users/:id", ac.GetUser)
+	admin.POST("/users/:id/disable", ac.DisableUser)
+	admin.POST("/users/:id/enable", ac.EnableUser)
+	admin.POST("/users/:id/reset_password", ac.ResetPassword)
+	admin.GET("/audit_logs", ac.GetAuditLogs)
+	admin.GET("/metrics", ac.GetMetrics)
+
 	return router
 }
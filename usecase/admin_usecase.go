@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"go_vdot_api/model"
+	"go_vdot_api/repository"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ユーザー一覧・監査ログの1回あたりの件数
+const (
+	DefaultAdminPageSize = 50
+	MaxAdminPageSize     = 200
+)
+
+type IAdminUsecase interface {
+	GetUsers(query string, limit int, offset int) (model.AdminUserListResponse, error)
+	GetUser(userId uint) (model.AdminUserDetailResponse, error)
+	DisableUser(actorId uint, userId uint, ipAddress string) (model.AdminUserResponse, error)
+	EnableUser(actorId uint, userId uint, ipAddress string) (model.AdminUserResponse, error)
+	ResetPassword(actorId uint, userId uint, ipAddress string) (model.AdminPasswordResetResponse, error)
+	GetAuditLogs(userId uint, action string, limit int) ([]model.AuditLog, error)
+}
+
+type adminUsecase struct {
+	ur  repository.IUserRepository
+	alr repository.IAuditLogRepository
+	sr  repository.ISessionRepository
+}
+
+func NewAdminUsecase(ur repository.IUserRepository, alr repository.IAuditLogRepository, sr repository.ISessionRepository) IAdminUsecase {
+	return &adminUsecase{ur, alr, sr}
+}
+
+func toAdminUserResponse(user model.User) model.AdminUserResponse {
+	return model.AdminUserResponse{
+		ID:          user.ID,
+		Name:        user.Name,
+		Email:       user.Email,
+		IsAdmin:     user.IsAdmin,
+		DisabledAt:  user.DisabledAt,
+		LockedUntil: user.LockedUntil,
+		CreatedAt:   user.CreatedAt,
+	}
+}
+
+func adminPageSize(limit int) int {
+	if limit <= 0 {
+		return DefaultAdminPageSize
+	}
+	return min(limit, MaxAdminPageSize)
+}
+
+// GetUsers は名前またはメールアドレスに query を含むユーザーを ID 順に返す（query が空の場合はすべて）
+func (au *adminUsecase) GetUsers(query string, limit int, offset int) (model.AdminUserListResponse, error) {
+	limit = adminPageSize(limit)
+	offset = max(offset, 0)
+	users, total, err := au.ur.SearchUsers(query, limit, offset)
+	if err != nil {
+		return model.AdminUserListResponse{}, err
+	}
+	res := model.AdminUserListResponse{
+		Users:  make([]model.AdminUserResponse, len(users)),
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	}
+	for i, u := range users {
+		res.Users[i] = toAdminUserResponse(u)
+	}
+	return res, nil
+}
+
+// GetUser はユーザー情報とテーブルごとのデータの件数を返す
+func (au *adminUsecase) GetUser(userId uint) (model.AdminUserDetailResponse, error) {
+	user := model.User{}
+	if err := au.ur.GetUserByID(&user, userId); err != nil {
+		return model.AdminUserDetailResponse{}, err
+	}
+	counts, err := au.ur.CountUserRecords(userId)
+	if err != nil {
+		return model.AdminUserDetailResponse{}, err
+	}
+	return model.AdminUserDetailResponse{AdminUserResponse: toAdminUserResponse(user), Records: counts}, nil
+}
+
+// DisableUser はユーザーを無効にする（無効な間はログインできず、ログイン中のセッションもすべて無効にする）
+func (au *adminUsecase) DisableUser(actorId uint, userId uint, ipAddress string) (model.AdminUserResponse, error) {
+	if actorId == userId {
+		return model.AdminUserResponse{}, errors.New("you cannot disable your own account")
+	}
+	now := time.Now()
+	res, err := au.setDisabled(actorId, userId, &now, model.AuditAccountDisabled, ipAddress)
+	if err != nil {
+		return model.AdminUserResponse{}, err
+	}
+	if err := au.sr.RevokeUserSessions(userId, 0); err != nil {
+		return model.AdminUserResponse{}, err
+	}
+	return res, nil
+}
+
+func (au *adminUsecase) EnableUser(actorId uint, userId uint, ipAddress string) (model.AdminUserResponse, error) {
+	return au.setDisabled(actorId, userId, nil, model.AuditAccountEnabled, ipAddress)
+}
+
+func (au *adminUsecase) setDisabled(actorId uint, userId uint, disabledAt *time.Time, action string, ipAddress string) (model.AdminUserResponse, error) {
+	if err := au.ur.SetUserDisabled(userId, disabledAt); err != nil {
+		return model.AdminUserResponse{}, err
+	}
+	if err := au.alr.CreateAuditLog(&model.AuditLog{UserId: userId, ActorId: actorId, Action: action, Detail: model.AuditDetail{}, IPAddress: ipAddress}); err != nil {
+		return model.AdminUserResponse{}, err
+	}
+	user := model.User{}
+	if err := au.ur.GetUserByID(&user, userId); err != nil {
+		return model.AdminUserResponse{}, err
+	}
+	return toAdminUserResponse(user), nil
+}
+
+// ResetPassword はユーザーのパスワードを一時パスワードに変更する
+// 一時パスワードはレスポンスでのみ返すため、管理者からユーザーに伝えてログイン後に変更してもらう
+// 以前のパスワードでログインしていたセッションはすべて無効にし、ログインのロックも解除する
+func (au *adminUsecase) ResetPassword(actorId uint, userId uint, ipAddress string) (model.AdminPasswordResetResponse, error) {
+	password, err := generateTemporaryPassword()
+	if err != nil {
+		return model.AdminPasswordResetResponse{}, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), 10)
+	if err != nil {
+		return model.AdminPasswordResetResponse{}, err
+	}
+	if err := au.ur.UpdatePassword(userId, string(hash)); err != nil {
+		return model.AdminPasswordResetResponse{}, err
+	}
+	if err := au.sr.RevokeUserSessions(userId, 0); err != nil {
+		return model.AdminPasswordResetResponse{}, err
+	}
+	if err := au.ur.ResetLoginFailures(userId); err != nil {
+		return model.AdminPasswordResetResponse{}, err
+	}
+	if err := au.alr.CreateAuditLog(&model.AuditLog{UserId: userId, ActorId: actorId, Action: model.AuditPasswordReset, Detail: model.AuditDetail{}, IPAddress: ipAddress}); err != nil {
+		return model.AdminPasswordResetResponse{}, err
+	}
+	return model.AdminPasswordResetResponse{UserID: userId, TemporaryPassword: password}, nil
+}
+
+// generateTemporaryPassword は 16 文字のランダムなパスワードを作る（パスワードの長さの上限 30 文字以内）
+func generateTemporaryPassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// GetAuditLogs は新しい順に監査ログを返す（userId が 0、action が空の場合は絞り込まない）
+func (au *adminUsecase) GetAuditLogs(userId uint, action string, limit int) ([]model.AuditLog, error) {
+	return au.alr.GetAuditLogs(userId, action, adminPageSize(limit))
+}
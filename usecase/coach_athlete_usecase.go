@@ -0,0 +1,161 @@
+package usecase
+
+import (
+	"errors"
+	"go_vdot_api/model"
+	"go_vdot_api/repository"
+	"strings"
+)
+
+// 選手のデータに対する操作の権限
+const (
+	PermissionReadAthlete = "read" // 記録・練習・専門種目・予定の閲覧
+	PermissionPlanAthlete = "plan" // 予定している練習の作成・更新・削除
+)
+
+// coachPermissions は承認されたコーチが持つ権限
+var coachPermissions = map[string]bool{
+	PermissionReadAthlete: true,
+	PermissionPlanAthlete: true,
+}
+
+var (
+	ErrForbidden      = errors.New("you do not have access to this athlete")
+	ErrSelfInvitation = errors.New("you cannot invite yourself")
+	ErrInviteeEmail   = errors.New("email is required")
+)
+
+type ICoachAthleteUsecase interface {
+	InviteAthlete(coachId uint, email string) (model.CoachAthleteResponse, error)
+	GetCoachLinks(userId uint) (model.CoachLinksResponse, error)
+	AcceptInvitation(athleteId uint, coachAthleteId uint) (model.CoachAthleteResponse, error)
+	DeleteCoachAthlete(userId uint, coachAthleteId uint) error
+	CheckAccess(actorId uint, athleteId uint, permission string) error
+}
+
+type coachAthleteUsecase struct {
+	car repository.ICoachAthleteRepository
+	ur  repository.IUserRepository
+}
+
+func NewCoachAthleteUsecase(car repository.ICoachAthleteRepository, ur repository.IUserRepository) ICoachAthleteUsecase {
+	return &coachAthleteUsecase{car, ur}
+}
+
+// toCoachAthleteResponse は承認前の招待では選手を招待したメールアドレスのみにする
+// （メールアドレスが登録済みかどうかをコーチに知らせないため）
+func toCoachAthleteResponse(ca model.CoachAthlete) model.CoachAthleteResponse {
+	athlete := model.CoachAthleteUser{Email: ca.InvitedEmail}
+	if ca.Status == model.CoachLinkAccepted {
+		athlete = model.CoachAthleteUser{ID: ca.Athlete.ID, Name: ca.Athlete.Name, Email: ca.Athlete.Email}
+	}
+	return model.CoachAthleteResponse{
+		ID:         ca.ID,
+		Coach:      model.CoachAthleteUser{ID: ca.Coach.ID, Name: ca.Coach.Name, Email: ca.Coach.Email},
+		Athlete:    athlete,
+		Status:     ca.Status,
+		AcceptedAt: ca.AcceptedAt,
+		CreatedAt:  ca.CreatedAt,
+	}
+}
+
+// InviteAthlete はメールアドレスで選手を招待する（選手が承認するまではデータにアクセスできない）
+// メールアドレスが登録済みかどうかに関わらず同じ結果を返し、選手は確認済みのメールアドレスが一致する招待を承認できる
+// 同じメールアドレスをすでに招待している場合は、その招待を返す
+func (cau *coachAthleteUsecase) InviteAthlete(coachId uint, email string) (model.CoachAthleteResponse, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return model.CoachAthleteResponse{}, ErrInviteeEmail
+	}
+	coach := model.User{}
+	if err := cau.ur.GetUserByID(&coach, coachId); err != nil {
+		return model.CoachAthleteResponse{}, err
+	}
+	if strings.EqualFold(coach.Email, email) {
+		return model.CoachAthleteResponse{}, ErrSelfInvitation
+	}
+
+	athletes, err := cau.car.GetAthletes(coachId)
+	if err != nil {
+		return model.CoachAthleteResponse{}, err
+	}
+	for _, ca := range athletes {
+		if strings.EqualFold(ca.InvitedEmail, email) {
+			return toCoachAthleteResponse(ca), nil
+		}
+	}
+
+	coachAthlete := model.CoachAthlete{CoachId: coachId, InvitedEmail: email, Status: model.CoachLinkPending}
+	if err := cau.car.CreateCoachAthlete(&coachAthlete); err != nil {
+		return model.CoachAthleteResponse{}, err
+	}
+	if err := cau.car.GetCoachAthleteById(&coachAthlete, coachId, coachAthlete.ID); err != nil {
+		return model.CoachAthleteResponse{}, err
+	}
+	return toCoachAthleteResponse(coachAthlete), nil
+}
+
+// GetCoachLinks はコーチとしての選手一覧と、選手としてのコーチ一覧（承認待ちの招待を含む）を返す
+func (cau *coachAthleteUsecase) GetCoachLinks(userId uint) (model.CoachLinksResponse, error) {
+	athletes, err := cau.car.GetAthletes(userId)
+	if err != nil {
+		return model.CoachLinksResponse{}, err
+	}
+	coaches, err := cau.car.GetCoaches(userId)
+	if err != nil {
+		return model.CoachLinksResponse{}, err
+	}
+	return toCoachLinksResponse(athletes, coaches), nil
+}
+
+func toCoachLinksResponse(athletes []model.CoachAthlete, coaches []model.CoachAthlete) model.CoachLinksResponse {
+	res := model.CoachLinksResponse{
+		Athletes: make([]model.CoachAthleteResponse, len(athletes)),
+		Coaches:  make([]model.CoachAthleteResponse, len(coaches)),
+	}
+	for i, ca := range athletes {
+		res.Athletes[i] = toCoachAthleteResponse(ca)
+	}
+	for i, ca := range coaches {
+		res.Coaches[i] = toCoachAthleteResponse(ca)
+	}
+	return res
+}
+
+// AcceptInvitation は選手がコーチの招待を承認する
+func (cau *coachAthleteUsecase) AcceptInvitation(athleteId uint, coachAthleteId uint) (model.CoachAthleteResponse, error) {
+	if err := cau.car.AcceptCoachAthlete(athleteId, coachAthleteId); err != nil {
+		return model.CoachAthleteResponse{}, err
+	}
+	coachAthlete := model.CoachAthlete{}
+	if err := cau.car.GetCoachAthleteById(&coachAthlete, athleteId, coachAthleteId); err != nil {
+		return model.CoachAthleteResponse{}, err
+	}
+	return toCoachAthleteResponse(coachAthlete), nil
+}
+
+func (cau *coachAthleteUsecase) DeleteCoachAthlete(userId uint, coachAthleteId uint) error {
+	if err := cau.car.DeleteCoachAthlete(userId, coachAthleteId); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CheckAccess は actorId が athleteId のデータに permission の操作をできるかチェックする
+// 本人はすべての操作ができ、承認されたコーチは coachPermissions の操作ができる
+func (cau *coachAthleteUsecase) CheckAccess(actorId uint, athleteId uint, permission string) error {
+	if actorId == athleteId {
+		return nil
+	}
+	if !coachPermissions[permission] {
+		return ErrForbidden
+	}
+	ok, err := cau.car.IsAcceptedCoach(actorId, athleteId)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrForbidden
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"fmt"
+	"go_vdot_api/model"
+	"go_vdot_api/pkg"
+	"go_vdot_api/pkg/csvfile"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// importCSVRows は行ごとに変換・チェックし、問題のない行を保存する
+// allOrNothing の場合は1行でも問題があれば何も保存せず、問題がなければ createAll で1つのトランザクションとして保存する
+func importCSVRows[T any](rows []csvfile.Row, allOrNothing bool, parse func(csvfile.Row) (T, error), create func(*T) error, createAll func([]T) error) (model.CSVImportResponse, error) {
+	res := model.CSVImportResponse{Total: len(rows), AllOrNothing: allOrNothing, Errors: []model.CSVRowError{}}
+	items := make([]T, 0, len(rows))
+	lines := make([]int, 0, len(rows))
+	for _, row := range rows {
+		item, err := parse(row)
+		if err != nil {
+			res.Errors = append(res.Errors, model.CSVRowError{Row: row.Line, Error: err.Error()})
+			continue
+		}
+		items = append(items, item)
+		lines = append(lines, row.Line)
+	}
+
+	if allOrNothing {
+		if len(res.Errors) == 0 && len(items) > 0 {
+			if err := createAll(items); err != nil {
+				return model.CSVImportResponse{}, err
+			}
+			res.Imported = len(items)
+		}
+	} else {
+		for i := range items {
+			if err := create(&items[i]); err != nil {
+				res.Errors = append(res.Errors, model.CSVRowError{Row: lines[i], Error: err.Error()})
+				continue
+			}
+			res.Imported++
+		}
+	}
+	sort.Slice(res.Errors, func(i, j int) bool { return res.Errors[i].Row < res.Errors[j].Row })
+	res.Failed = len(res.Errors)
+	return res, nil
+}
+
+// csvDate は YYYY-MM-DD 形式の日付を読む（空の場合は zero 値）
+func csvDate(row csvfile.Row, column string) (pkg.DateOnly, error) {
+	s := row.Get(column)
+	if s == "" {
+		return pkg.DateOnly{}, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return pkg.DateOnly{}, fmt.Errorf("%s must be in YYYY-MM-DD format", column)
+	}
+	return pkg.DateOnly{Time: t}, nil
+}
+
+func csvFloat(row csvfile.Row, column string) (float64, error) {
+	s := row.Get(column)
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number", column)
+	}
+	return v, nil
+}
+
+// csvOptionalFloat は空の場合に nil を返す
+func csvOptionalFloat(row csvfile.Row, column string) (*float64, error) {
+	if row.Get(column) == "" {
+		return nil, nil
+	}
+	v, err := csvFloat(row, column)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+func formatCSVDate(d pkg.DateOnly) string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format("2006-01-02")
+}
+
+func formatCSVFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'f', -1, 64)
+}
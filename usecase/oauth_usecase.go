@@ -0,0 +1,259 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go_vdot_api/model"
+	"go_vdot_api/pkg/logger"
+	"go_vdot_api/pkg/metrics"
+	"go_vdot_api/pkg/oauth"
+	"go_vdot_api/repository"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
+)
+
+// 認可画面からコールバックまでの有効期限
+const OAuthStateTTL = 10 * time.Minute
+
+var (
+	ErrInvalidOAuthState = errors.New("login request is invalid or expired. Please try again")
+	ErrIdentityLinked    = errors.New("this account is already linked to another user")
+	ErrProviderLinked    = errors.New("another account of this provider is already linked. Unlink it first")
+	ErrEmailInUse        = errors.New("a user with this email already exists. Log in with your password and link this account from your profile")
+	ErrEmailRequired     = errors.New("this provider did not share an email address. Sign up with your email and link this account from your profile")
+	ErrLastLoginMethod   = errors.New("you cannot unlink your only login method. Set a password first")
+)
+
+type IOAuthUsecase interface {
+	StartLogin(provider string, linkUserId uint) (authURL string, stateToken string, err error)
+	CompleteLogin(ctx context.Context, provider string, stateToken string, state string, code string, userAgent string, ipAddress string) (model.OAuthResult, error)
+	GetIdentities(userId uint) (model.UserIdentitiesResponse, error)
+	UnlinkIdentity(userId uint, provider string) error
+}
+
+type oauthUsecase struct {
+	ur        repository.IUserRepository
+	uir       repository.IUserIdentityRepository
+	sr        repository.ISessionRepository
+	providers map[string]*oauth.Provider
+}
+
+func NewOAuthUsecase(ur repository.IUserRepository, uir repository.IUserIdentityRepository, sr repository.ISessionRepository, providers map[string]*oauth.Provider) IOAuthUsecase {
+	return &oauthUsecase{ur, uir, sr, providers}
+}
+
+func (ou *oauthUsecase) provider(name string) (*oauth.Provider, error) {
+	p, ok := ou.providers[name]
+	if !ok {
+		return nil, oauth.ErrUnknownProvider
+	}
+	return p, nil
+}
+
+// StartLogin は認可画面の URL と、コールバックで確認する値を署名したトークン（Cookie に保存する）を返す
+// linkUserId が 0 でない場合は、ログイン中のそのユーザーにアカウントを紐づける
+func (ou *oauthUsecase) StartLogin(provider string, linkUserId uint) (string, string, error) {
+	p, err := ou.provider(provider)
+	if err != nil {
+		return "", "", err
+	}
+	req, err := oauth.NewAuthRequest()
+	if err != nil {
+		return "", "", err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"typ":           "oauth_state",
+		"provider":      provider,
+		"state":         req.State,
+		"nonce":         req.Nonce,
+		"code_verifier": req.CodeVerifier,
+		"link_user_id":  linkUserId,
+		"exp":           time.Now().Add(OAuthStateTTL).Unix(),
+	})
+	stateToken, err := token.SignedString([]byte(os.Getenv("SECRET_KEY")))
+	if err != nil {
+		return "", "", err
+	}
+	return p.AuthCodeURL(req), stateToken, nil
+}
+
+type oauthState struct {
+	req        oauth.AuthRequest
+	linkUserId uint
+}
+
+// parseState は StartLogin のトークンを検証し、コールバックの state と同じか確認する
+func parseState(stateToken string, provider string, state string) (oauthState, error) {
+	claims := jwt.MapClaims{}
+	parser := jwt.Parser{ValidMethods: []string{"HS256"}}
+	if _, err := parser.ParseWithClaims(stateToken, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(os.Getenv("SECRET_KEY")), nil
+	}); err != nil {
+		return oauthState{}, ErrInvalidOAuthState
+	}
+	if claims["typ"] != "oauth_state" || claims["provider"] != provider || state == "" || claims["state"] != state {
+		return oauthState{}, ErrInvalidOAuthState
+	}
+	nonce, _ := claims["nonce"].(string)
+	verifier, _ := claims["code_verifier"].(string)
+	linkUserId, _ := claims["link_user_id"].(float64)
+	return oauthState{
+		req:        oauth.AuthRequest{State: state, Nonce: nonce, CodeVerifier: verifier},
+		linkUserId: uint(linkUserId),
+	}, nil
+}
+
+// CompleteLogin はコールバックで受け取った認可コードでアカウントを取得し、ログイン・登録・紐づけをする
+func (ou *oauthUsecase) CompleteLogin(ctx context.Context, provider string, stateToken string, state string, code string, userAgent string, ipAddress string) (model.OAuthResult, error) {
+	p, err := ou.provider(provider)
+	if err != nil {
+		return model.OAuthResult{}, err
+	}
+	st, err := parseState(stateToken, provider, state)
+	if err != nil {
+		return model.OAuthResult{}, err
+	}
+	identity, err := p.Exchange(ctx, code, st.req)
+	if err != nil {
+		return model.OAuthResult{}, err
+	}
+	if st.linkUserId != 0 {
+		return ou.link(st.linkUserId, identity)
+	}
+	return ou.logIn(identity, userAgent, ipAddress)
+}
+
+// logIn は紐づけたユーザーでログインする
+// 紐づけがない場合は、確認済みのメールアドレスでユーザーを新しく作る
+// （同じメールアドレスのユーザーがいる場合は、乗っ取りを防ぐため自動では紐づけず、パスワードでログインしてから紐づけてもらう）
+func (ou *oauthUsecase) logIn(identity oauth.Identity, userAgent string, ipAddress string) (model.OAuthResult, error) {
+	result := model.OAuthResult{Status: model.OAuthLoggedIn, Provider: identity.Provider}
+	user := model.User{}
+
+	linked := model.UserIdentity{}
+	err := ou.uir.GetIdentity(&linked, identity.Provider, identity.Subject)
+	switch {
+	case err == nil:
+		if err := ou.ur.GetUserByID(&user, linked.UserId); err != nil {
+			return model.OAuthResult{}, err
+		}
+		if user.DisabledAt != nil {
+			metrics.LoginAttempts.Inc("disabled")
+			return model.OAuthResult{}, ErrAccountDisabled
+		}
+		if err := ou.uir.UpdateLastLogin(linked.ID, identity.Email); err != nil {
+			return model.OAuthResult{}, err
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if identity.Email == "" || !identity.EmailVerified {
+			return model.OAuthResult{}, ErrEmailRequired
+		}
+		existing := model.User{}
+		if err := ou.ur.GetUserByEmail(&existing, identity.Email); err == nil {
+			return model.OAuthResult{}, ErrEmailInUse
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.OAuthResult{}, err
+		}
+		now := time.Now()
+		// パスワードは設定しない（パスワードの再設定で後から設定できる）
+		user = model.User{
+			Name:            truncate(identity.Name, 30),
+			Email:           identity.Email,
+			EmailVerifiedAt: &now,
+		}
+		newIdentity := model.UserIdentity{Provider: identity.Provider, Subject: identity.Subject, Email: identity.Email, LastLoginAt: &now}
+		if err := ou.uir.CreateUserWithIdentity(&user, &newIdentity); err != nil {
+			return model.OAuthResult{}, err
+		}
+		result.Status = model.OAuthSignedUp
+		logger.Info("user signed up with %s: user_id=%d", identity.Provider, user.ID)
+	default:
+		return model.OAuthResult{}, err
+	}
+
+	tokens, err := startSession(ou.sr, user, userAgent, ipAddress)
+	if err != nil {
+		return model.OAuthResult{}, err
+	}
+	metrics.LoginAttempts.Inc("oauth_" + identity.Provider)
+	result.Tokens = tokens
+	return result, nil
+}
+
+// link はログイン中のユーザーにアカウントを紐づける（プロバイダーごとに1つまで）
+func (ou *oauthUsecase) link(userId uint, identity oauth.Identity) (model.OAuthResult, error) {
+	linked := model.UserIdentity{}
+	err := ou.uir.GetIdentity(&linked, identity.Provider, identity.Subject)
+	if err == nil {
+		if linked.UserId != userId {
+			return model.OAuthResult{}, ErrIdentityLinked
+		}
+		return model.OAuthResult{Status: model.OAuthLinked, Provider: identity.Provider}, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.OAuthResult{}, err
+	}
+
+	identities, err := ou.uir.GetIdentities(userId)
+	if err != nil {
+		return model.OAuthResult{}, err
+	}
+	for _, i := range identities {
+		if i.Provider == identity.Provider {
+			return model.OAuthResult{}, ErrProviderLinked
+		}
+	}
+	newIdentity := model.UserIdentity{UserId: userId, Provider: identity.Provider, Subject: identity.Subject, Email: identity.Email}
+	if err := ou.uir.CreateIdentity(&newIdentity); err != nil {
+		return model.OAuthResult{}, err
+	}
+	logger.Info("identity linked: user_id=%d provider=%s", userId, identity.Provider)
+	return model.OAuthResult{Status: model.OAuthLinked, Provider: identity.Provider}, nil
+}
+
+// GetIdentities は紐づけたアカウントと、ログインに使えるプロバイダーを返す
+func (ou *oauthUsecase) GetIdentities(userId uint) (model.UserIdentitiesResponse, error) {
+	user := model.User{}
+	if err := ou.ur.GetUserByID(&user, userId); err != nil {
+		return model.UserIdentitiesResponse{}, err
+	}
+	identities, err := ou.uir.GetIdentities(userId)
+	if err != nil {
+		return model.UserIdentitiesResponse{}, err
+	}
+	res := model.UserIdentitiesResponse{
+		Identities:  make([]model.UserIdentityResponse, len(identities)),
+		Providers:   oauth.Names(ou.providers),
+		HasPassword: user.Password != "",
+	}
+	for i, identity := range identities {
+		res.Identities[i] = model.UserIdentityResponse{
+			Provider:    identity.Provider,
+			Email:       identity.Email,
+			LastLoginAt: identity.LastLoginAt,
+			CreatedAt:   identity.CreatedAt,
+		}
+	}
+	return res, nil
+}
+
+// UnlinkIdentity はアカウントの紐づけを解除する（パスワードも他の紐づけもない場合はログインできなくなるため解除しない）
+func (ou *oauthUsecase) UnlinkIdentity(userId uint, provider string) error {
+	provider = strings.ToLower(provider)
+	user := model.User{}
+	if err := ou.ur.GetUserByID(&user, userId); err != nil {
+		return err
+	}
+	identities, err := ou.uir.GetIdentities(userId)
+	if err != nil {
+		return err
+	}
+	if user.Password == "" && len(identities) <= 1 {
+		return ErrLastLoginMethod
+	}
+	return ou.uir.DeleteIdentity(userId, provider)
+}
@@ -0,0 +1,275 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"go_vdot_api/model"
+	"go_vdot_api/pkg/oauth"
+	"go_vdot_api/pkg/oauth/oauthtest"
+	"go_vdot_api/repository"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
+)
+
+// fakeUserIdentityRepository は unique_provider_subject と同じく、同じアカウントを1件までにする
+type fakeUserIdentityRepository struct {
+	ur         *fakeUserRepository
+	identities []model.UserIdentity
+}
+
+func (r *fakeUserIdentityRepository) GetIdentity(identity *model.UserIdentity, provider string, subject string) error {
+	for _, i := range r.identities {
+		if i.Provider == provider && i.Subject == subject {
+			*identity = i
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserIdentityRepository) GetIdentities(userId uint) ([]model.UserIdentity, error) {
+	var identities []model.UserIdentity
+	for _, i := range r.identities {
+		if i.UserId == userId {
+			identities = append(identities, i)
+		}
+	}
+	return identities, nil
+}
+
+func (r *fakeUserIdentityRepository) CreateIdentity(identity *model.UserIdentity) error {
+	if err := r.GetIdentity(&model.UserIdentity{}, identity.Provider, identity.Subject); err == nil {
+		return errors.New("Error 1062 (23000): Duplicate entry for key 'unique_provider_subject'")
+	}
+	identity.ID = uint(len(r.identities) + 1)
+	r.identities = append(r.identities, *identity)
+	return nil
+}
+
+func (r *fakeUserIdentityRepository) CreateUserWithIdentity(user *model.User, identity *model.UserIdentity) error {
+	if err := r.ur.CreateUser(user); err != nil {
+		return err
+	}
+	identity.UserId = user.ID
+	return r.CreateIdentity(identity)
+}
+
+func (r *fakeUserIdentityRepository) UpdateLastLogin(identityId uint, email string) error {
+	return nil
+}
+
+func (r *fakeUserIdentityRepository) DeleteIdentity(userId uint, provider string) error {
+	for i, identity := range r.identities {
+		if identity.UserId == userId && identity.Provider == provider {
+			r.identities = append(r.identities[:i], r.identities[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+type fakeSessionRepository struct {
+	repository.ISessionRepository
+	sessions []model.Session
+}
+
+func (r *fakeSessionRepository) CreateSession(session *model.Session) error {
+	session.ID = uint(len(r.sessions) + 1)
+	r.sessions = append(r.sessions, *session)
+	return nil
+}
+
+type oauthTest struct {
+	idp *oauthtest.IdP
+	ur  *fakeUserRepository
+	uir *fakeUserIdentityRepository
+	sr  *fakeSessionRepository
+	ou  IOAuthUsecase
+}
+
+// newOAuthTest は Google のエンドポイントを fake の認可サーバーに向けて usecase を作る
+func newOAuthTest(t *testing.T, users ...model.User) *oauthTest {
+	t.Helper()
+	idp := oauthtest.NewIdP(t)
+	t.Setenv("SECRET_KEY", "test-secret")
+	t.Setenv("OAUTH_GOOGLE_CLIENT_ID", oauthtest.ClientID)
+	t.Setenv("OAUTH_GOOGLE_TOKEN_URL", idp.TokenURL())
+	t.Setenv("OAUTH_GOOGLE_JWKS_URL", idp.JWKSURL())
+	t.Setenv("OAUTH_GOOGLE_ISSUER", idp.Issuer)
+
+	ur := &fakeUserRepository{users: users}
+	uir := &fakeUserIdentityRepository{ur: ur}
+	sr := &fakeSessionRepository{}
+	return &oauthTest{idp: idp, ur: ur, uir: uir, sr: sr, ou: NewOAuthUsecase(ur, uir, sr, oauth.ProvidersFromEnv())}
+}
+
+// callback は認可画面でアカウント（subject・email）を選んでコールバックに戻った場合の結果を返す
+func (ot *oauthTest) callback(t *testing.T, linkUserId uint, subject string, email string) (model.OAuthResult, error) {
+	t.Helper()
+	authURL, stateToken, err := ot.ou.StartLogin("google", linkUserId)
+	if err != nil {
+		t.Fatalf("StartLogin error = %v", err)
+	}
+	state, nonce := authQuery(t, authURL)
+	code := ot.idp.Issue(ot.idp.Sign(t, oauthtest.KeyID, ot.idp.Claims(subject, email, nonce)))
+	return ot.ou.CompleteLogin(context.Background(), "google", stateToken, state, code, "test", "127.0.0.1")
+}
+
+func authQuery(t *testing.T, authURL string) (state string, nonce string) {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("auth url %q: %v", authURL, err)
+	}
+	return u.Query().Get("state"), u.Query().Get("nonce")
+}
+
+func TestOAuthSignUpAndLogIn(t *testing.T) {
+	ot := newOAuthTest(t)
+
+	res, err := ot.callback(t, 0, "google-1", "runner@example.com")
+	if err != nil {
+		t.Fatalf("first login error = %v", err)
+	}
+	if res.Status != model.OAuthSignedUp || res.Tokens.AccessToken == "" {
+		t.Fatalf("first login = %+v, want signed up with tokens", res)
+	}
+	if len(ot.ur.users) != 1 || ot.ur.users[0].Password != "" || ot.ur.users[0].EmailVerifiedAt == nil {
+		t.Fatalf("users = %+v, want one verified user without password", ot.ur.users)
+	}
+
+	res, err = ot.callback(t, 0, "google-1", "runner@example.com")
+	if err != nil {
+		t.Fatalf("second login error = %v", err)
+	}
+	if res.Status != model.OAuthLoggedIn || len(ot.ur.users) != 1 || len(ot.sr.sessions) != 2 {
+		t.Errorf("second login = %+v, users = %d, sessions = %d", res, len(ot.ur.users), len(ot.sr.sessions))
+	}
+}
+
+func TestOAuthLogInEmailInUse(t *testing.T) {
+	ot := newOAuthTest(t, model.User{ID: 1, Email: "runner@example.com", Password: "hash"})
+
+	if _, err := ot.callback(t, 0, "google-1", "runner@example.com"); !errors.Is(err, ErrEmailInUse) {
+		t.Fatalf("error = %v, want ErrEmailInUse", err)
+	}
+	if len(ot.uir.identities) != 0 || len(ot.sr.sessions) != 0 {
+		t.Errorf("identities = %+v, sessions = %d, want none", ot.uir.identities, len(ot.sr.sessions))
+	}
+}
+
+func TestOAuthLinkIdentity(t *testing.T) {
+	ot := newOAuthTest(t,
+		model.User{ID: 1, Email: "runner@example.com", Password: "hash"},
+		model.User{ID: 2, Email: "other@example.com", Password: "hash"},
+	)
+	ot.uir.identities = []model.UserIdentity{{ID: 1, UserId: 2, Provider: "google", Subject: "google-2"}}
+
+	if _, err := ot.callback(t, 1, "google-2", "other@example.com"); !errors.Is(err, ErrIdentityLinked) {
+		t.Fatalf("linking another user's account: error = %v, want ErrIdentityLinked", err)
+	}
+
+	res, err := ot.callback(t, 1, "google-1", "runner@example.com")
+	if err != nil || res.Status != model.OAuthLinked {
+		t.Fatalf("link = %+v, %v", res, err)
+	}
+	if _, err := ot.callback(t, 1, "google-3", "runner@example.com"); !errors.Is(err, ErrProviderLinked) {
+		t.Errorf("linking a second google account: error = %v, want ErrProviderLinked", err)
+	}
+	if len(ot.sr.sessions) != 0 {
+		t.Errorf("sessions = %d, linking must not log in", len(ot.sr.sessions))
+	}
+}
+
+func TestOAuthInvalidState(t *testing.T) {
+	ot := newOAuthTest(t, model.User{ID: 1, Email: "runner@example.com", Password: "hash"})
+	authURL, stateToken, err := ot.ou.StartLogin("google", 0)
+	if err != nil {
+		t.Fatalf("StartLogin error = %v", err)
+	}
+	state, nonce := authQuery(t, authURL)
+
+	// 署名はそのままで、紐づけるユーザーを書き換える
+	parts := strings.Split(stateToken, ".")
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	claims := map[string]interface{}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatalf("state payload: %v", err)
+	}
+	claims["link_user_id"] = 1
+	payload, _ = json.Marshal(claims)
+	tampered := parts[0] + "." + base64.RawURLEncoding.EncodeToString(payload) + "." + parts[2]
+
+	expired, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"typ":           "oauth_state",
+		"provider":      "google",
+		"state":         state,
+		"nonce":         nonce,
+		"code_verifier": "verifier",
+		"link_user_id":  0,
+		"exp":           time.Now().Add(-time.Minute).Unix(),
+	}).SignedString([]byte(os.Getenv("SECRET_KEY")))
+	if err != nil {
+		t.Fatalf("sign expired state: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		stateToken string
+		state      string
+	}{
+		{"tampered", tampered, state},
+		{"expired", expired, state},
+		{"state mismatch", stateToken, "another-state"},
+		{"missing cookie", "", state},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := ot.idp.Issue(ot.idp.Sign(t, oauthtest.KeyID, ot.idp.Claims("google-1", "new@example.com", nonce)))
+			_, err := ot.ou.CompleteLogin(context.Background(), "google", tt.stateToken, tt.state, code, "test", "127.0.0.1")
+			if !errors.Is(err, ErrInvalidOAuthState) {
+				t.Fatalf("error = %v, want ErrInvalidOAuthState", err)
+			}
+		})
+	}
+	if len(ot.uir.identities) != 0 || len(ot.sr.sessions) != 0 {
+		t.Errorf("identities = %+v, sessions = %d, want none", ot.uir.identities, len(ot.sr.sessions))
+	}
+}
+
+func TestUnlinkIdentity(t *testing.T) {
+	ot := newOAuthTest(t,
+		model.User{ID: 1, Email: "oauth@example.com"},
+		model.User{ID: 2, Email: "runner@example.com", Password: "hash"},
+	)
+	ot.uir.identities = []model.UserIdentity{
+		{ID: 1, UserId: 1, Provider: "google", Subject: "google-1"},
+		{ID: 2, UserId: 2, Provider: "google", Subject: "google-2"},
+	}
+
+	if err := ot.ou.UnlinkIdentity(1, "google"); !errors.Is(err, ErrLastLoginMethod) {
+		t.Fatalf("unlinking the only login method: error = %v, want ErrLastLoginMethod", err)
+	}
+
+	ot.uir.identities = append(ot.uir.identities, model.UserIdentity{ID: 3, UserId: 1, Provider: "strava", Subject: "strava-1"})
+	if err := ot.ou.UnlinkIdentity(1, "google"); err != nil {
+		t.Errorf("unlinking with another account linked: error = %v", err)
+	}
+	if err := ot.ou.UnlinkIdentity(1, "strava"); !errors.Is(err, ErrLastLoginMethod) {
+		t.Errorf("unlinking the remaining account: error = %v, want ErrLastLoginMethod", err)
+	}
+	if err := ot.ou.UnlinkIdentity(2, "Google"); err != nil {
+		t.Errorf("unlinking with a password: error = %v", err)
+	}
+	if len(ot.uir.identities) != 1 || ot.uir.identities[0].Provider != "strava" {
+		t.Errorf("identities = %+v, want only user 1's strava account", ot.uir.identities)
+	}
+}
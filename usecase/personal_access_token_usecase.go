@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"errors"
+	"go_vdot_api/model"
+	"go_vdot_api/repository"
+	"go_vdot_api/validator"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// 個人用アクセストークンの形式（先頭で種類が分かるようにする）
+const personalAccessTokenPrefix = "vdot_pat_"
+
+// 1人あたりの有効なトークンの上限
+const MaxPersonalAccessTokens = 20
+
+// 最後に使われた日時を更新する間隔（リクエストごとに書き込まないため）
+const tokenTouchInterval = time.Minute
+
+var ErrInvalidAccessToken = errors.New("access token is invalid, revoked or expired")
+
+type IPersonalAccessTokenUsecase interface {
+	CreateToken(userId uint, req model.PersonalAccessTokenRequest) (model.PersonalAccessTokenCreatedResponse, error)
+	GetTokens(userId uint) ([]model.PersonalAccessTokenResponse, error)
+	RevokeToken(userId uint, tokenId uint) error
+	AuthenticateToken(token string) (model.User, model.TokenScopes, error)
+}
+
+type personalAccessTokenUsecase struct {
+	patr repository.IPersonalAccessTokenRepository
+	patv validator.IPersonalAccessTokenValidator
+}
+
+func NewPersonalAccessTokenUsecase(patr repository.IPersonalAccessTokenRepository, patv validator.IPersonalAccessTokenValidator) IPersonalAccessTokenUsecase {
+	return &personalAccessTokenUsecase{patr, patv}
+}
+
+func toPersonalAccessTokenResponse(token model.PersonalAccessToken) model.PersonalAccessTokenResponse {
+	return model.PersonalAccessTokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		Prefix:     token.Prefix,
+		Scopes:     token.Scopes,
+		LastUsedAt: token.LastUsedAt,
+		ExpiresAt:  token.ExpiresAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+// CreateToken はトークンを作る。トークンはハッシュのみ保存するため、レスポンスでのみ返す
+func (patu *personalAccessTokenUsecase) CreateToken(userId uint, req model.PersonalAccessTokenRequest) (model.PersonalAccessTokenCreatedResponse, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if err := patu.patv.PersonalAccessTokenValidate(req); err != nil {
+		return model.PersonalAccessTokenCreatedResponse{}, err
+	}
+	tokens, err := patu.patr.GetActiveTokens(userId)
+	if err != nil {
+		return model.PersonalAccessTokenCreatedResponse{}, err
+	}
+	if len(tokens) >= MaxPersonalAccessTokens {
+		return model.PersonalAccessTokenCreatedResponse{}, errors.New("too many access tokens. Revoke unused tokens first")
+	}
+
+	secret, err := generateSecretToken()
+	if err != nil {
+		return model.PersonalAccessTokenCreatedResponse{}, err
+	}
+	plain := personalAccessTokenPrefix + secret
+	// write は read を含むため重複を除いて保存する
+	scopes := model.TokenScopes{}
+	for _, scope := range []string{model.TokenScopeRead, model.TokenScopeWrite} {
+		for _, s := range req.Scopes {
+			if s == scope {
+				scopes = append(scopes, scope)
+				break
+			}
+		}
+	}
+	token := model.PersonalAccessToken{
+		UserId:    userId,
+		Name:      req.Name,
+		TokenHash: hashToken(plain),
+		Prefix:    plain[:len(personalAccessTokenPrefix)+4],
+		Scopes:    scopes,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := patu.patr.CreateToken(&token); err != nil {
+		return model.PersonalAccessTokenCreatedResponse{}, err
+	}
+	return model.PersonalAccessTokenCreatedResponse{
+		PersonalAccessTokenResponse: toPersonalAccessTokenResponse(token),
+		Token:                       plain,
+	}, nil
+}
+
+func (patu *personalAccessTokenUsecase) GetTokens(userId uint) ([]model.PersonalAccessTokenResponse, error) {
+	tokens, err := patu.patr.GetActiveTokens(userId)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]model.PersonalAccessTokenResponse, len(tokens))
+	for i, t := range tokens {
+		res[i] = toPersonalAccessTokenResponse(t)
+	}
+	return res, nil
+}
+
+func (patu *personalAccessTokenUsecase) RevokeToken(userId uint, tokenId uint) error {
+	if err := patu.patr.RevokeToken(userId, tokenId); err != nil {
+		return err
+	}
+	return nil
+}
+
+// AuthenticateToken は Authorization: Bearer のトークンを検証し、ユーザーと権限を返す
+// 無効にされたユーザーのトークンも使えない
+func (patu *personalAccessTokenUsecase) AuthenticateToken(plain string) (model.User, model.TokenScopes, error) {
+	if !strings.HasPrefix(plain, personalAccessTokenPrefix) {
+		return model.User{}, nil, ErrInvalidAccessToken
+	}
+	token := model.PersonalAccessToken{}
+	if err := patu.patr.GetTokenByHash(&token, hashToken(plain)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.User{}, nil, ErrInvalidAccessToken
+		}
+		return model.User{}, nil, err
+	}
+	now := time.Now()
+	if token.RevokedAt != nil || (token.ExpiresAt != nil && !token.ExpiresAt.After(now)) || token.User.DisabledAt != nil {
+		return model.User{}, nil, ErrInvalidAccessToken
+	}
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= tokenTouchInterval {
+		if err := patu.patr.TouchToken(token.ID); err != nil {
+			return model.User{}, nil, err
+		}
+	}
+	return token.User, token.Scopes, nil
+}
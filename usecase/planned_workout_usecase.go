@@ -0,0 +1,328 @@
+package usecase
+
+import (
+	"go_vdot_api/model"
+	"go_vdot_api/pkg"
+	"go_vdot_api/pkg/workoutparser"
+	"go_vdot_api/repository"
+	"go_vdot_api/validator"
+	"math"
+	"time"
+)
+
+// 予定の状態
+const (
+	PlannedCompleted = "completed" // 同じ日の練習記録と対応付いた
+	PlannedMissed    = "missed"    // 予定日を過ぎても練習記録がない
+	PlannedUpcoming  = "upcoming"  // 予定日が今日以降で、まだ練習記録がない
+)
+
+type IPlannedWorkoutUsecase interface {
+	CreatePlannedWorkout(plannedWorkout model.PlannedWorkout) (model.PlannedWorkoutResponse, error)
+	GetPlannedWorkoutById(userId uint, plannedWorkoutId uint) (model.PlannedWorkoutResponse, error)
+	UpdatePlannedWorkout(plannedWorkout model.PlannedWorkout, userId uint, plannedWorkoutId uint) (model.PlannedWorkoutResponse, error)
+	DeletePlannedWorkout(userId uint, plannedWorkoutId uint) error
+	GetCalendar(userId uint, year int, month int) (model.CalendarResponse, error)
+}
+
+type plannedWorkoutUsecase struct {
+	pwr repository.IPlannedWorkoutRepository
+	wr  repository.IWorkoutRepository
+	vr  repository.IVdotRepository
+	tpr repository.ITrainingPlanRepository
+	zpr repository.IZoneProfileRepository
+	pwv validator.IPlannedWorkoutValidator
+}
+
+func NewPlannedWorkoutUsecase(pwr repository.IPlannedWorkoutRepository, wr repository.IWorkoutRepository, vr repository.IVdotRepository, tpr repository.ITrainingPlanRepository, zpr repository.IZoneProfileRepository, pwv validator.IPlannedWorkoutValidator) IPlannedWorkoutUsecase {
+	return &plannedWorkoutUsecase{pwr, wr, vr, tpr, zpr, pwv}
+}
+
+func (pwu *plannedWorkoutUsecase) CreatePlannedWorkout(plannedWorkout model.PlannedWorkout) (model.PlannedWorkoutResponse, error) {
+	if err := pwu.pwv.PlannedWorkoutValidate(plannedWorkout); err != nil {
+		return model.PlannedWorkoutResponse{}, err
+	}
+	// 個別に登録する予定は練習計画に含めない
+	plannedWorkout.TrainingPlanId = nil
+	fillPlannedMileage(&plannedWorkout)
+	if err := pwu.pwr.CreatePlannedWorkout(&plannedWorkout); err != nil {
+		return model.PlannedWorkoutResponse{}, err
+	}
+	return pwu.GetPlannedWorkoutById(plannedWorkout.UserId, plannedWorkout.ID)
+}
+
+// GetPlannedWorkoutById は予定と、同じ日の練習記録との対応・一致度を返す
+func (pwu *plannedWorkoutUsecase) GetPlannedWorkoutById(userId uint, plannedWorkoutId uint) (model.PlannedWorkoutResponse, error) {
+	plannedWorkout := model.PlannedWorkout{}
+	if err := pwu.pwr.GetPlannedWorkoutById(&plannedWorkout, userId, plannedWorkoutId); err != nil {
+		return model.PlannedWorkoutResponse{}, err
+	}
+
+	// 同じ日に複数の予定がある場合の対応付けを一覧と揃えるため、その日の予定と練習記録をまとめて対応付ける
+	date := plannedWorkout.Date.Time
+	days, err := pwu.matchRange(userId, date, date)
+	if err != nil {
+		return model.PlannedWorkoutResponse{}, err
+	}
+	for _, day := range days {
+		for _, p := range day.Planned {
+			if p.ID == plannedWorkout.ID {
+				return p, nil
+			}
+		}
+	}
+	return complianceCalculator{}.response(plannedWorkout, nil, time.Now()), nil
+}
+
+func (pwu *plannedWorkoutUsecase) UpdatePlannedWorkout(plannedWorkout model.PlannedWorkout, userId uint, plannedWorkoutId uint) (model.PlannedWorkoutResponse, error) {
+	if err := pwu.pwv.PlannedWorkoutValidate(plannedWorkout); err != nil {
+		return model.PlannedWorkoutResponse{}, err
+	}
+	fillPlannedMileage(&plannedWorkout)
+	if err := pwu.pwr.UpdatePlannedWorkout(&plannedWorkout, userId, plannedWorkoutId); err != nil {
+		return model.PlannedWorkoutResponse{}, err
+	}
+	return pwu.GetPlannedWorkoutById(userId, plannedWorkoutId)
+}
+
+func (pwu *plannedWorkoutUsecase) DeletePlannedWorkout(userId uint, plannedWorkoutId uint) error {
+	return pwu.pwr.DeletePlannedWorkout(userId, plannedWorkoutId)
+}
+
+// GetCalendar は1か月分の予定と練習記録を日ごとに返す
+func (pwu *plannedWorkoutUsecase) GetCalendar(userId uint, year int, month int) (model.CalendarResponse, error) {
+	from := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, -1)
+
+	days, err := pwu.matchRange(userId, from, to)
+	if err != nil {
+		return model.CalendarResponse{}, err
+	}
+
+	res := model.CalendarResponse{Year: year, Month: month, Days: days}
+	totalScore := 0.0
+	for _, day := range days {
+		for _, p := range day.Planned {
+			res.Planned++
+			switch p.Status {
+			case PlannedCompleted:
+				res.Completed++
+				totalScore += p.Compliance.Overall
+			case PlannedMissed:
+				res.Missed++
+			}
+		}
+	}
+	if res.Completed > 0 {
+		average := math.Round(totalScore/float64(res.Completed)*10) / 10
+		res.AverageScore = &average
+	}
+	return res, nil
+}
+
+// matchRange は from〜to の予定と練習記録を日ごとに対応付ける
+func (pwu *plannedWorkoutUsecase) matchRange(userId uint, from time.Time, to time.Time) ([]model.CalendarDay, error) {
+	plannedWorkouts, err := pwu.pwr.GetPlannedWorkoutsInRange(userId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	workouts, err := pwu.wr.GetWorkoutsInRange(userId, from, to, nil, false, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	planned := map[string][]model.PlannedWorkout{}
+	for _, p := range plannedWorkouts {
+		key := p.Date.Format("2006-01-02")
+		planned[key] = append(planned[key], p)
+	}
+	done := map[string][]model.Workout{}
+	for _, w := range workouts {
+		key := w.Date.Format("2006-01-02")
+		done[key] = append(done[key], w)
+	}
+
+	c := pwu.compliance(userId, plannedWorkouts)
+	now := time.Now()
+	days := []model.CalendarDay{}
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		key := d.Format("2006-01-02")
+		day := model.CalendarDay{
+			Date:      pkg.DateOnly{Time: d},
+			Planned:   []model.PlannedWorkoutResponse{},
+			Done:      []model.WorkoutResponse{},
+			Unplanned: []uint{},
+		}
+		// 予定は登録順、練習記録は開始時刻順に1件ずつ対応付ける
+		for i, p := range planned[key] {
+			var matched *model.Workout
+			if i < len(done[key]) {
+				matched = &done[key][i]
+			}
+			day.Planned = append(day.Planned, c.response(p, matched, now))
+		}
+		for i, w := range done[key] {
+			day.Done = append(day.Done, toWorkoutResponse(w))
+			if i >= len(planned[key]) {
+				day.Unplanned = append(day.Unplanned, w.ID)
+			}
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// complianceCalculator は予定と練習記録の一致度を計算する
+type complianceCalculator struct {
+	velocity     *float64 // ペースゾーンの基準の速度（VDOTの記録がない場合は nil）
+	zoneProfile  model.ZoneProfile
+	planProfiles map[uint]model.ZoneProfile // 練習計画のIDごとの、計画で指定したゾーン定義
+}
+
+// compliance は現在のVDOTで一致度を計算する
+// ゾーン定義は、練習計画から作られた予定は計画の profile（計画を作ったときと同じもの）、それ以外は組み込みの Daniels のゾーン定義を使う
+func (pwu *plannedWorkoutUsecase) compliance(userId uint, plannedWorkouts []model.PlannedWorkout) complianceCalculator {
+	c := complianceCalculator{zoneProfile: DefaultZoneProfile(), planProfiles: map[uint]model.ZoneProfile{}}
+	for _, p := range plannedWorkouts {
+		if p.TrainingPlanId == nil {
+			continue
+		}
+		if _, ok := c.planProfiles[*p.TrainingPlanId]; ok {
+			continue
+		}
+		// 計画やゾーン定義を取得できない場合（削除された場合など）は組み込みのゾーン定義を使う
+		zoneProfile := c.zoneProfile
+		trainingPlan := model.TrainingPlan{}
+		if err := pwu.tpr.GetTrainingPlanById(&trainingPlan, userId, *p.TrainingPlanId); err == nil {
+			if planProfile, err := resolveZoneProfile(pwu.zpr, userId, trainingPlan.Profile); err == nil {
+				zoneProfile = planProfile
+			}
+		}
+		c.planProfiles[*p.TrainingPlanId] = zoneProfile
+	}
+
+	vdot, err := selectVdot(pwu.vr, userId, "", 0)
+	if err != nil {
+		return c
+	}
+	detail, err := ComputeVdot(vdot)
+	if err != nil {
+		return c
+	}
+	velocity := ZoneReferenceVelocity(detail.PreciseVdot, Conditions{})
+	c.velocity = &velocity
+	return c
+}
+
+func (c complianceCalculator) response(p model.PlannedWorkout, matched *model.Workout, now time.Time) model.PlannedWorkoutResponse {
+	res := model.PlannedWorkoutResponse{
+		ID:             p.ID,
+		Date:           p.Date,
+		Workout:        p.Workout,
+		Mileage:        p.Mileage,
+		MileageUnit:    p.MileageUnit,
+		Note:           p.Note,
+		TrainingPlanId: p.TrainingPlanId,
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch {
+	case matched != nil:
+		res.Status = PlannedCompleted
+		res.WorkoutID = &matched.ID
+		score := c.score(p, *matched)
+		res.Compliance = &score
+	case p.Date.Before(today):
+		res.Status = PlannedMissed
+	default:
+		res.Status = PlannedUpcoming
+	}
+	return res
+}
+
+// score は距離とペースの一致度を計算する。ペースは予定の練習内容を基準にラップを評価する
+func (c complianceCalculator) score(p model.PlannedWorkout, w model.Workout) model.ComplianceScore {
+	planned := toKm(p.Mileage, p.MileageUnit)
+	done := toKm(w.Mileage, w.MileageUnit)
+	distance := 0.0
+	if planned > 0 && done > 0 {
+		distance = math.Min(planned, done) / math.Max(planned, done) * 100
+	}
+	score := model.ComplianceScore{Distance: math.Round(distance*10) / 10, Overall: math.Round(distance*10) / 10}
+
+	if pace, ok := c.paceScore(p, w); ok {
+		pace = math.Round(pace*10) / 10
+		score.Pace = &pace
+		score.Overall = math.Round((distance+pace)/2*10) / 10
+	}
+	return score
+}
+
+// zoneProfileFor は予定のペースの評価に使うゾーン定義を返す
+func (c complianceCalculator) zoneProfileFor(p model.PlannedWorkout) model.ZoneProfile {
+	if p.TrainingPlanId != nil {
+		if zoneProfile, ok := c.planProfiles[*p.TrainingPlanId]; ok {
+			return zoneProfile
+		}
+	}
+	return c.zoneProfile
+}
+
+func (c complianceCalculator) paceScore(p model.PlannedWorkout, w model.Workout) (float64, bool) {
+	if c.velocity == nil || w.LapTime == nil {
+		return 0, false
+	}
+	segments, err := workoutparser.Parse(p.Workout)
+	if err != nil {
+		return 0, false
+	}
+	laps, err := workoutparser.ParseLaps(*w.LapTime)
+	if err != nil {
+		return 0, false
+	}
+	reps, err := matchLaps(segments, laps)
+	if err != nil {
+		return 0, false
+	}
+
+	onTarget, comparable := 0, 0
+	for i, rep := range reps {
+		lap := evaluateLap(rep, laps[i], *c.velocity, c.zoneProfileFor(p))
+		if lap.Status == LapNotComparable {
+			continue
+		}
+		comparable++
+		if lap.Status == LapOnTarget {
+			onTarget++
+		}
+	}
+	if comparable == 0 {
+		return 0, false
+	}
+	return float64(onTarget) / float64(comparable) * 100, true
+}
+
+// fillPlannedMileage は予定距離が未指定（0）の場合に練習内容の距離の合計を km で設定する
+func fillPlannedMileage(plannedWorkout *model.PlannedWorkout) {
+	if plannedWorkout.Mileage > 0 {
+		return
+	}
+	segments, err := workoutparser.Parse(plannedWorkout.Workout)
+	if err != nil {
+		return
+	}
+	meters := 0.0
+	for _, s := range segments {
+		if s.DistanceMeters != nil {
+			meters += *s.DistanceMeters * float64(s.Repetitions)
+		}
+	}
+	plannedWorkout.Mileage = math.Round(meters/100) / 10
+	plannedWorkout.MileageUnit = "km"
+}
+
+func toKm(mileage float64, unit string) float64 {
+	if unit == "mile" {
+		return mileage * kmPerMile
+	}
+	return mileage
+}
@@ -1,15 +1,38 @@
 package usecase
 
 import (
+	"errors"
+	"fmt"
 	"go_vdot_api/model"
+	"go_vdot_api/pkg"
+	"go_vdot_api/pkg/csvfile"
 	"go_vdot_api/repository"
 	"go_vdot_api/validator"
 )
 
+// 種目ごとの距離（m）。障害物のある 3000mSC はVDOTの算出対象外
+var specialtyEventDistances = map[string]float64{
+	"800m":    800,
+	"1500m":   1500,
+	"1mile":   1609.34,
+	"3000m":   3000,
+	"2mile":   3218.69,
+	"5000m":   5000,
+	"10000m":  10000,
+	"ハーフマラソン": 21097.5,
+	"フルマラソン":  42195,
+}
+
+// ErrNoSpecialtyEventVdot はVDOTを算出できる種目（3000mSC 以外で自己ベストのあるもの）が登録されていない場合のエラー
+var ErrNoSpecialtyEventVdot = errors.New("no specialty event to calculate vdot")
+
 type ISpecialtyEventUsecase interface {
-	CreateSpecialtyEvent(specialtyEvent model.SpecialtyEvent) (model.SpecialtyEvent, error)
-	GetSpecialtyEvent(userId uint) ([]model.SpecialtyEvent, error)
-	UpdateSpecialtyEvent(specialtyEvent model.SpecialtyEvent, userId uint, specialtyEventId uint) (model.SpecialtyEvent, error)
+	CreateSpecialtyEvent(specialtyEvent model.SpecialtyEvent) (model.SpecialtyEventResponse, error)
+	GetSpecialtyEvent(userId uint) ([]model.SpecialtyEventResponse, error)
+	GetSpecialtyEventSummary(userId uint) (model.SpecialtyEventSummaryResponse, error)
+	UpdateSpecialtyEvent(specialtyEvent model.SpecialtyEvent, userId uint, specialtyEventId uint) (model.SpecialtyEventResponse, error)
+	ImportSpecialtyEventsCSV(userId uint, data []byte, allOrNothing bool) (model.CSVImportResponse, error)
+	ExportSpecialtyEventsCSV(userId uint) ([]byte, error)
 }
 
 type specialtyEventUsecase struct {
@@ -21,49 +44,167 @@ func NewSpecialtyEventUsecase(ser repository.ISpecialtyEventRepository, sev vali
 	return &specialtyEventUsecase{ser, sev}
 }
 
-func (seu *specialtyEventUsecase) CreateSpecialtyEvent(specialtyEvent model.SpecialtyEvent) (model.SpecialtyEvent, error) {
+func (seu *specialtyEventUsecase) CreateSpecialtyEvent(specialtyEvent model.SpecialtyEvent) (model.SpecialtyEventResponse, error) {
 	if err := seu.sev.SpecialtyEventValidate(specialtyEvent); err != nil {
-		return model.SpecialtyEvent{}, err
+		return model.SpecialtyEventResponse{}, err
 	}
 
 	if err := seu.ser.CreateSpecialtyEvent(&specialtyEvent); err != nil {
-		return model.SpecialtyEvent{}, err
+		return model.SpecialtyEventResponse{}, err
 	}
 
-	return specialtyEvent, nil
+	return toSpecialtyEventResponse(specialtyEvent), nil
 }
 
-func (seu *specialtyEventUsecase) GetSpecialtyEvent(userId uint) ([]model.SpecialtyEvent, error) {
+func (seu *specialtyEventUsecase) GetSpecialtyEvent(userId uint) ([]model.SpecialtyEventResponse, error) {
 	specialtyEvents, err := seu.ser.GetSpecialtyEvent(userId)
 	if err != nil {
 		return nil, err
 	}
-	resSpecialtyEvents := make([]model.SpecialtyEvent, len(specialtyEvents))
+	resSpecialtyEvents := make([]model.SpecialtyEventResponse, len(specialtyEvents))
 	for i, se := range specialtyEvents {
-		resSpecialtyEvents[i] = model.SpecialtyEvent{
-			ID:         se.ID,
-			EventName:  se.EventName,
-			BestTime:   se.BestTime,
-			RecordedAt: se.RecordedAt,
+		resSpecialtyEvents[i] = toSpecialtyEventResponse(se)
+	}
+	return resSpecialtyEvents, nil
+}
+
+// GetSpecialtyEventSummary は最もVDOTの高い種目を現在のVDOTとして、ペースゾーンと予測タイムを返す
+func (seu *specialtyEventUsecase) GetSpecialtyEventSummary(userId uint) (model.SpecialtyEventSummaryResponse, error) {
+	resSpecialtyEvents, err := seu.GetSpecialtyEvent(userId)
+	if err != nil {
+		return model.SpecialtyEventSummaryResponse{}, err
+	}
+
+	bestIndex := -1
+	for i, se := range resSpecialtyEvents {
+		if se.Vdot == nil {
+			continue
 		}
+		if bestIndex < 0 || *se.Vdot > *resSpecialtyEvents[bestIndex].Vdot {
+			bestIndex = i
+		}
+	}
+	if bestIndex < 0 {
+		return model.SpecialtyEventSummaryResponse{}, ErrNoSpecialtyEventVdot
 	}
-	return specialtyEvents, nil
+
+	best := resSpecialtyEvents[bestIndex]
+	detail, _ := specialtyEventVdot(best.EventName, best.BestTime)
+	return model.SpecialtyEventSummaryResponse{
+		BestEvent:       best,
+		Vdot:            detail.Vdot,
+		PaceZones:       CalculatePaceZoneList(detail.PreciseVdot, Conditions{}, DefaultZoneProfile()),
+		RacePredictions: best.RacePredictions,
+		Events:          resSpecialtyEvents,
+	}, nil
 }
 
-func (seu *specialtyEventUsecase) UpdateSpecialtyEvent(specialtyEvent model.SpecialtyEvent, userId uint, specialtyEventId uint) (model.SpecialtyEvent, error) {
+func (seu *specialtyEventUsecase) UpdateSpecialtyEvent(specialtyEvent model.SpecialtyEvent, userId uint, specialtyEventId uint) (model.SpecialtyEventResponse, error) {
 	if err := seu.sev.SpecialtyEventValidate(specialtyEvent); err != nil {
-		return model.SpecialtyEvent{}, err
+		return model.SpecialtyEventResponse{}, err
 	}
 
 	if err := seu.ser.UpdateSpecialtyEvent(&specialtyEvent, userId, specialtyEventId); err != nil {
-		return model.SpecialtyEvent{}, err
+		return model.SpecialtyEventResponse{}, err
+	}
+
+	return toSpecialtyEventResponse(specialtyEvent), nil
+}
+
+// toSpecialtyEventResponse はベストタイムから算出したVDOTと相当タイムを含めたレスポンスを作る
+func toSpecialtyEventResponse(specialtyEvent model.SpecialtyEvent) model.SpecialtyEventResponse {
+	res := model.SpecialtyEventResponse{
+		ID:              specialtyEvent.ID,
+		EventName:       specialtyEvent.EventName,
+		BestTime:        specialtyEvent.BestTime,
+		RecordedAt:      specialtyEvent.RecordedAt,
+		RacePredictions: []model.RacePrediction{},
+	}
+	if detail, ok := specialtyEventVdot(specialtyEvent.EventName, specialtyEvent.BestTime); ok {
+		res.Vdot = &detail.Vdot
+		res.RacePredictions = PredictRaces(detail.PreciseVdot)
+	}
+	return res
+}
+
+// specialtyEventVdot は種目とベストタイムからVDOTを算出する（算出できない場合は false）
+func specialtyEventVdot(eventName string, bestTime string) (VdotDetail, bool) {
+	distance, ok := specialtyEventDistances[eventName]
+	if !ok {
+		return VdotDetail{}, false
+	}
+	timeInMinutes, err := pkg.ParseRaceTime(bestTime)
+	if err != nil || timeInMinutes <= 0 {
+		return VdotDetail{}, false
+	}
+	return CalculateVdotDetail(distance, timeInMinutes, Conditions{}), true
+}
+
+// 専門種目の CSV の列（model.SpecialtyEvent のフィールドと同じ名前）
+// best_time: hh:mm:ss または m'ss"SS, recorded_at: YYYY-MM-DD（空でも可）
+var SpecialtyEventCSVColumns = []string{"event_name", "best_time", "recorded_at"}
+
+var specialtyEventCSVRequired = []string{"event_name", "best_time"}
+
+// ImportSpecialtyEventsCSV は CSV の行ごとに種目をチェックして保存し、行ごとの結果を返す
+// 種目はユーザーごとに1件のため、登録済みの種目の行とファイル内で2回目以降に出てきた種目の行は失敗になる
+func (seu *specialtyEventUsecase) ImportSpecialtyEventsCSV(userId uint, data []byte, allOrNothing bool) (model.CSVImportResponse, error) {
+	rows, err := csvfile.Read(data, SpecialtyEventCSVColumns, specialtyEventCSVRequired)
+	if err != nil {
+		return model.CSVImportResponse{}, err
+	}
+	// 登録済みの種目とファイル内で重複した種目は、保存する前に行のエラーにする（unique_user_event の違反にしない）
+	existing, err := seu.ser.GetSpecialtyEvent(userId)
+	if err != nil {
+		return model.CSVImportResponse{}, err
 	}
+	registered := make(map[string]bool, len(existing))
+	for _, se := range existing {
+		registered[se.EventName] = true
+	}
+	seen := map[string]int{} // 種目ごとの、ファイル内で最初に出てきた行
+	parse := func(row csvfile.Row) (model.SpecialtyEvent, error) {
+		recordedAt, err := csvDate(row, "recorded_at")
+		if err != nil {
+			return model.SpecialtyEvent{}, err
+		}
+		specialtyEvent := model.SpecialtyEvent{
+			EventName:  row.Get("event_name"),
+			BestTime:   row.Get("best_time"),
+			RecordedAt: recordedAt,
+			UserId:     userId,
+		}
+		if err := seu.sev.SpecialtyEventValidate(specialtyEvent); err != nil {
+			return model.SpecialtyEvent{}, err
+		}
+		if registered[specialtyEvent.EventName] {
+			return model.SpecialtyEvent{}, fmt.Errorf("event %s is already registered. Update it instead", specialtyEvent.EventName)
+		}
+		if line, ok := seen[specialtyEvent.EventName]; ok {
+			return model.SpecialtyEvent{}, fmt.Errorf("event %s is duplicated in the file (row %d)", specialtyEvent.EventName, line)
+		}
+		seen[specialtyEvent.EventName] = row.Line
+		return specialtyEvent, nil
+	}
+	return importCSVRows(rows, allOrNothing, parse, seu.ser.CreateSpecialtyEvent, seu.ser.CreateSpecialtyEvents)
+}
 
-	resSpecialEvent := model.SpecialtyEvent{
-		ID:         specialtyEvent.ID,
-		EventName:  specialtyEvent.EventName,
-		BestTime:   specialtyEvent.BestTime,
-		RecordedAt: specialtyEvent.RecordedAt,
+func (seu *specialtyEventUsecase) ExportSpecialtyEventsCSV(userId uint) ([]byte, error) {
+	specialtyEvents, err := seu.ser.GetSpecialtyEvent(userId)
+	if err != nil {
+		return nil, err
+	}
+	resSpecialtyEvents := make([]model.SpecialtyEventResponse, len(specialtyEvents))
+	for i, se := range specialtyEvents {
+		resSpecialtyEvents[i] = toSpecialtyEventResponse(se)
+	}
+	return csvfile.Write(SpecialtyEventCSVColumns, specialtyEventCSVRecords(resSpecialtyEvents))
+}
+
+func specialtyEventCSVRecords(specialtyEvents []model.SpecialtyEventResponse) [][]string {
+	records := make([][]string, len(specialtyEvents))
+	for i, se := range specialtyEvents {
+		records[i] = []string{se.EventName, se.BestTime, formatCSVDate(se.RecordedAt)}
 	}
-	return resSpecialEvent, nil
+	return records
 }
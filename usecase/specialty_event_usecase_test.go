@@ -0,0 +1,81 @@
+package usecase
+
+import (
+	"errors"
+	"go_vdot_api/model"
+	"go_vdot_api/validator"
+	"strings"
+	"testing"
+)
+
+// fakeSpecialtyEventRepository は unique_user_event と同じく、ユーザーごとに種目を1件までにする
+type fakeSpecialtyEventRepository struct {
+	events []model.SpecialtyEvent
+}
+
+func (r *fakeSpecialtyEventRepository) CreateSpecialtyEvent(specialtyEvent *model.SpecialtyEvent) error {
+	return r.CreateSpecialtyEvents([]model.SpecialtyEvent{*specialtyEvent})
+}
+
+func (r *fakeSpecialtyEventRepository) CreateSpecialtyEvents(specialtyEvents []model.SpecialtyEvent) error {
+	added := append([]model.SpecialtyEvent(nil), r.events...)
+	for _, se := range specialtyEvents {
+		for _, e := range added {
+			if e.UserId == se.UserId && e.EventName == se.EventName {
+				return errors.New("Error 1062 (23000): Duplicate entry for key 'unique_user_event'")
+			}
+		}
+		added = append(added, se)
+	}
+	r.events = added
+	return nil
+}
+
+func (r *fakeSpecialtyEventRepository) GetSpecialtyEvent(userId uint) ([]model.SpecialtyEvent, error) {
+	var events []model.SpecialtyEvent
+	for _, e := range r.events {
+		if e.UserId == userId {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (r *fakeSpecialtyEventRepository) UpdateSpecialtyEvent(*model.SpecialtyEvent, uint, uint) error {
+	return nil
+}
+
+func TestImportSpecialtyEventsCSVDuplicates(t *testing.T) {
+	csv := "event_name,best_time,recorded_at\n" +
+		"5000m,00:18:30,2024-05-01\n" + // 2行目: 登録済み
+		"10000m,00:38:30,2024-06-01\n" +
+		"1500m,00:04:50,\n" +
+		"10000m,00:38:00,2024-07-01\n" // 5行目: ファイル内で重複
+
+	for _, allOrNothing := range []bool{true, false} {
+		repo := &fakeSpecialtyEventRepository{events: []model.SpecialtyEvent{{EventName: "5000m", BestTime: "00:19:00", UserId: 1}}}
+		seu := NewSpecialtyEventUsecase(repo, validator.NewSpecialtyEventValidator())
+
+		res, err := seu.ImportSpecialtyEventsCSV(1, []byte(csv), allOrNothing)
+		if err != nil {
+			t.Fatalf("allOrNothing=%v: error = %v", allOrNothing, err)
+		}
+		if res.Failed != 2 || len(res.Errors) != 2 {
+			t.Fatalf("allOrNothing=%v: errors = %+v, want rows 2 and 5", allOrNothing, res.Errors)
+		}
+		if res.Errors[0].Row != 2 || !strings.Contains(res.Errors[0].Error, "already registered") {
+			t.Errorf("allOrNothing=%v: errors[0] = %+v", allOrNothing, res.Errors[0])
+		}
+		if res.Errors[1].Row != 5 || !strings.Contains(res.Errors[1].Error, "duplicated in the file (row 3)") {
+			t.Errorf("allOrNothing=%v: errors[1] = %+v", allOrNothing, res.Errors[1])
+		}
+
+		wantImported, wantStored := 2, 3
+		if allOrNothing {
+			wantImported, wantStored = 0, 1
+		}
+		if res.Imported != wantImported || len(repo.events) != wantStored {
+			t.Errorf("allOrNothing=%v: imported = %d, stored = %d, want %d and %d", allOrNothing, res.Imported, len(repo.events), wantImported, wantStored)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"go_vdot_api/model"
+	"go_vdot_api/pkg"
+	"go_vdot_api/pkg/workoutparser"
+	"go_vdot_api/repository"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ゾーンごとの1分あたりのポイント（Daniels の Running Formula の intensity points）
+var zonePointsPerMinute = map[string]float64{
+	"E": 0.2,
+	"M": 0.4,
+	"T": 0.6,
+	"I": 1.0,
+	"R": 1.5,
+}
+
+// 急性負荷・慢性負荷の日数
+const (
+	AcuteLoadDays   = 7
+	ChronicLoadDays = 28
+)
+
+// 一度に取得できる期間の最大日数
+const MaxTrainingLoadDays = 366
+
+// TrainingLoadThresholds は警告を出すしきい値（0 の項目はデフォルト値を使う）
+type TrainingLoadThresholds struct {
+	AcwrHigh     float64 // ACWR がこれより大きい場合に警告（急な負荷の増加）
+	AcwrLow      float64 // ACWR がこれより小さい場合に警告（負荷の急な低下）
+	MonotonyHigh float64 // monotony がこれより大きい場合に警告（単調な練習）
+	StrainHigh   float64 // strain がこれより大きい場合に警告
+}
+
+// DefaultTrainingLoadThresholds はデフォルトのしきい値を返す
+// 環境変数（TRAINING_LOAD_ACWR_HIGH, TRAINING_LOAD_ACWR_LOW, TRAINING_LOAD_MONOTONY_HIGH, TRAINING_LOAD_STRAIN_HIGH）で変更できる
+func DefaultTrainingLoadThresholds() TrainingLoadThresholds {
+	return TrainingLoadThresholds{
+		AcwrHigh:     envFloat("TRAINING_LOAD_ACWR_HIGH", 1.5),
+		AcwrLow:      envFloat("TRAINING_LOAD_ACWR_LOW", 0.8),
+		MonotonyHigh: envFloat("TRAINING_LOAD_MONOTONY_HIGH", 2.0),
+		StrainHigh:   envFloat("TRAINING_LOAD_STRAIN_HIGH", 300),
+	}
+}
+
+func envFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// merge は 0 でない項目で上書きしたしきい値を返す
+func (t TrainingLoadThresholds) merge(override TrainingLoadThresholds) TrainingLoadThresholds {
+	if override.AcwrHigh > 0 {
+		t.AcwrHigh = override.AcwrHigh
+	}
+	if override.AcwrLow > 0 {
+		t.AcwrLow = override.AcwrLow
+	}
+	if override.MonotonyHigh > 0 {
+		t.MonotonyHigh = override.MonotonyHigh
+	}
+	if override.StrainHigh > 0 {
+		t.StrainHigh = override.StrainHigh
+	}
+	return t
+}
+
+type ITrainingLoadUsecase interface {
+	GetTrainingLoad(userId uint, from time.Time, to time.Time, rule string, days int, profile string, thresholds TrainingLoadThresholds) (model.TrainingLoadResponse, error)
+}
+
+type trainingLoadUsecase struct {
+	wr         repository.IWorkoutRepository
+	vr         repository.IVdotRepository
+	zpr        repository.IZoneProfileRepository
+	thresholds TrainingLoadThresholds
+}
+
+func NewTrainingLoadUsecase(wr repository.IWorkoutRepository, vr repository.IVdotRepository, zpr repository.IZoneProfileRepository, thresholds TrainingLoadThresholds) ITrainingLoadUsecase {
+	return &trainingLoadUsecase{wr, vr, zpr, thresholds}
+}
+
+// GetTrainingLoad は from〜to の日ごとの負荷と、急性負荷（7日）・慢性負荷（28日）・ACWR・monotony・strain を算出する
+// 練習の時間は、VDOT（rule, days で選ぶ）とゾーン定義（profile）から求めた各ゾーンのペースで推定する
+func (tlu *trainingLoadUsecase) GetTrainingLoad(userId uint, from time.Time, to time.Time, rule string, days int, profile string, thresholds TrainingLoadThresholds) (model.TrainingLoadResponse, error) {
+	if to.Before(from) {
+		return model.TrainingLoadResponse{}, errors.New("from must be before to")
+	}
+	if to.Sub(from) > MaxTrainingLoadDays*24*time.Hour {
+		return model.TrainingLoadResponse{}, fmt.Errorf("range must be %d days or less", MaxTrainingLoadDays)
+	}
+	thresholds = tlu.thresholds.merge(thresholds)
+
+	vdot, err := selectVdot(tlu.vr, userId, rule, days)
+	if err != nil {
+		return model.TrainingLoadResponse{}, fmt.Errorf("vdot data not found: %v", err)
+	}
+	detail, err := ComputeVdot(vdot)
+	if err != nil {
+		return model.TrainingLoadResponse{}, err
+	}
+	zoneProfile, err := resolveZoneProfile(tlu.zpr, userId, profile)
+	if err != nil {
+		return model.TrainingLoadResponse{}, err
+	}
+	velocity := ZoneReferenceVelocity(detail.PreciseVdot, Conditions{})
+
+	// 慢性負荷の算出のため from の27日前から取得する
+	windowStart := from.AddDate(0, 0, -(ChronicLoadDays - 1))
+	workouts, err := tlu.wr.GetWorkoutsInRange(userId, windowStart, to, nil, false, 0)
+	if err != nil {
+		return model.TrainingLoadResponse{}, err
+	}
+
+	sessions := map[string][]model.TrainingLoadSession{}
+	for _, w := range workouts {
+		key := w.Date.Format("2006-01-02")
+		sessions[key] = append(sessions[key], sessionLoad(w, velocity, zoneProfile))
+	}
+
+	// 期間の先頭から1日ずつ負荷を並べる
+	var loads []float64
+	for d := windowStart; !d.After(to); d = d.AddDate(0, 0, 1) {
+		load := 0.0
+		for _, s := range sessions[d.Format("2006-01-02")] {
+			load += s.Load
+		}
+		loads = append(loads, load)
+	}
+
+	res := model.TrainingLoadResponse{
+		From: pkg.DateOnly{Time: from},
+		To:   pkg.DateOnly{Time: to},
+		Vdot: detail.AdjustedVdot,
+		Thresholds: model.TrainingLoadThresholdsResponse{
+			AcwrHigh:     thresholds.AcwrHigh,
+			AcwrLow:      thresholds.AcwrLow,
+			MonotonyHigh: thresholds.MonotonyHigh,
+			StrainHigh:   thresholds.StrainHigh,
+		},
+		Days:     []model.TrainingLoadDay{},
+		Warnings: []model.TrainingLoadWarning{},
+	}
+	for i := ChronicLoadDays - 1; i < len(loads); i++ {
+		date := pkg.DateOnly{Time: windowStart.AddDate(0, 0, i)}
+		day := dailyLoad(loads[i-AcuteLoadDays+1:i+1], loads[i-ChronicLoadDays+1:i+1])
+		day.Date = date
+		day.Sessions = sessions[date.Format("2006-01-02")]
+		if day.Sessions == nil {
+			day.Sessions = []model.TrainingLoadSession{}
+		}
+		res.Days = append(res.Days, day)
+		res.Warnings = append(res.Warnings, loadWarnings(day, thresholds)...)
+	}
+	return res, nil
+}
+
+// dailyLoad は直近7日間・28日間の負荷から1日分の指標を算出する（最後の要素がその日）
+func dailyLoad(acute []float64, chronic []float64) model.TrainingLoadDay {
+	acuteSum := sum(acute)
+	acuteMean := acuteSum / float64(len(acute))
+	chronicMean := sum(chronic) / float64(len(chronic))
+
+	day := model.TrainingLoadDay{
+		Load:        roundLoad(acute[len(acute)-1]),
+		AcuteLoad:   roundLoad(acuteMean),
+		ChronicLoad: roundLoad(chronicMean),
+	}
+	if chronicMean > 0 {
+		acwr := math.Round(acuteMean/chronicMean*100) / 100
+		day.Acwr = &acwr
+	}
+
+	variance := 0.0
+	for _, l := range acute {
+		variance += (l - acuteMean) * (l - acuteMean)
+	}
+	sd := math.Sqrt(variance / float64(len(acute)))
+	if sd > 0 {
+		monotony := math.Round(acuteMean/sd*100) / 100
+		strain := roundLoad(acuteSum * acuteMean / sd)
+		day.Monotony = &monotony
+		day.Strain = &strain
+	}
+	return day
+}
+
+func loadWarnings(day model.TrainingLoadDay, t TrainingLoadThresholds) []model.TrainingLoadWarning {
+	var warnings []model.TrainingLoadWarning
+	add := func(metric string, value float64, threshold float64) {
+		warnings = append(warnings, model.TrainingLoadWarning{Date: day.Date, Metric: metric, Value: value, Threshold: threshold})
+	}
+	if day.Acwr != nil {
+		if *day.Acwr > t.AcwrHigh {
+			add("acwr_high", *day.Acwr, t.AcwrHigh)
+		}
+		if *day.Acwr < t.AcwrLow {
+			add("acwr_low", *day.Acwr, t.AcwrLow)
+		}
+	}
+	if day.Monotony != nil && *day.Monotony > t.MonotonyHigh {
+		add("monotony_high", *day.Monotony, t.MonotonyHigh)
+	}
+	if day.Strain != nil && *day.Strain > t.StrainHigh {
+		add("strain_high", *day.Strain, t.StrainHigh)
+	}
+	return warnings
+}
+
+// sessionLoad は1回の練習の時間と負荷を推定する
+// 練習内容で指定した距離が mileage に満たない分は E で走ったものとする。解析できない場合は mileage 全てを E とする
+func sessionLoad(w model.Workout, velocity float64, zoneProfile model.ZoneProfile) model.TrainingLoadSession {
+	totalMeters := w.Mileage * 1000
+	if w.MileageUnit == "mile" {
+		totalMeters = w.Mileage * kmPerMile * 1000
+	}
+
+	session := model.TrainingLoadSession{WorkoutID: w.ID}
+	minutes, load, meters := 0.0, 0.0, 0.0
+	addRun := func(zone string, distance *float64, duration *float64) {
+		zoneVelocity := velocity * zoneIntensity(zoneProfile, zone) / 100
+		var m, d float64
+		switch {
+		case distance != nil:
+			d = *distance
+			m = d / zoneVelocity
+		case duration != nil:
+			m = *duration / 60
+			d = zoneVelocity * m
+		}
+		minutes += m
+		meters += d
+		load += m * zonePointsPerMinute[zone]
+	}
+
+	segments, err := workoutparser.Parse(w.Workout)
+	if err == nil {
+		session.Parsed = true
+		for _, s := range segments {
+			for r := 0; r < s.Repetitions; r++ {
+				addRun(s.Zone, s.DistanceMeters, s.DurationSeconds)
+				// 最後の本のあとの休息は数えない
+				if s.Recovery == nil || r == s.Repetitions-1 {
+					continue
+				}
+				if s.Recovery.Kind == workoutparser.RecoveryJog {
+					addRun("E", s.Recovery.DistanceMeters, s.Recovery.DurationSeconds)
+				} else if s.Recovery.DurationSeconds != nil {
+					minutes += *s.Recovery.DurationSeconds / 60
+				}
+			}
+		}
+	}
+	if rest := totalMeters - meters; rest > 0 {
+		addRun("E", &rest, nil)
+	}
+
+	session.Minutes = math.Round(minutes*10) / 10
+	session.Load = roundLoad(load)
+	return session
+}
+
+// zoneIntensity はゾーンの代表の%（上限がある場合は中央）を返す
+// ゾーン定義にないゾーンは組み込みのゾーン定義の値を使う
+func zoneIntensity(zoneProfile model.ZoneProfile, zoneName string) float64 {
+	zone, ok := findZone(zoneProfile, zoneName)
+	if !ok {
+		zone, ok = findZone(DefaultZoneProfile(), zoneName)
+		if !ok {
+			zone, _ = findZone(DefaultZoneProfile(), "E")
+		}
+	}
+	if zone.Upper != nil {
+		return (zone.Lower + *zone.Upper) / 2
+	}
+	return zone.Lower
+}
+
+func sum(values []float64) float64 {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func roundLoad(load float64) float64 {
+	return math.Round(load*10) / 10
+}
@@ -0,0 +1,618 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"go_vdot_api/model"
+	"go_vdot_api/pkg"
+	"go_vdot_api/pkg/workoutparser"
+	"go_vdot_api/repository"
+	"go_vdot_api/validator"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// 計画の期分け
+const (
+	PlanPhaseBase    = "base"    // E とウィンドスプリントで走行距離を作る
+	PlanPhaseQuality = "quality" // 週2回の質の高い練習を入れる
+	PlanPhasePeak    = "peak"    // 目標レースに近い強度の練習を中心にする
+	PlanPhaseTaper   = "taper"   // 距離を落としてレースに備える
+)
+
+// 計画の練習の種類
+const (
+	PlanSessionEasy    = "easy"
+	PlanSessionLong    = "long"
+	PlanSessionQuality = "quality"
+	PlanSessionRace    = "race"
+)
+
+// 計画の週数の範囲
+const (
+	MinPlanWeeks = 4
+	MaxPlanWeeks = 30
+)
+
+// 曜日の表記（月曜日始まり）
+var planWeekdays = []string{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+// 目標レースの距離ごとの、quality 期と peak 期の質の高い練習（優先順）
+type planEmphasis struct {
+	maxDistance float64
+	quality     []string
+	peak        []string
+	raceZone    string // レースのペースに近いゾーン
+}
+
+var planEmphases = []planEmphasis{
+	{maxDistance: 3000, quality: []string{"R", "I"}, peak: []string{"R", "I"}, raceZone: "R"},
+	{maxDistance: 5000, quality: []string{"R", "I"}, peak: []string{"I", "T"}, raceZone: "I"},
+	{maxDistance: 15000, quality: []string{"I", "T"}, peak: []string{"T", "I"}, raceZone: "T"},
+	{maxDistance: 25000, quality: []string{"T", "I"}, peak: []string{"T", "M"}, raceZone: "T"},
+	{maxDistance: math.Inf(1), quality: []string{"T", "M"}, peak: []string{"M", "T"}, raceZone: "M"},
+}
+
+type ITrainingPlanUsecase interface {
+	CreateTrainingPlan(trainingPlan model.TrainingPlan) (model.TrainingPlanResponse, error)
+	GetTrainingPlans(userId uint) ([]model.TrainingPlanResponse, error)
+	GetTrainingPlanById(userId uint, trainingPlanId uint) (model.TrainingPlanResponse, error)
+	RegenerateTrainingPlan(userId uint, trainingPlanId uint) (model.TrainingPlanResponse, error)
+	DeleteTrainingPlan(userId uint, trainingPlanId uint) error
+}
+
+type trainingPlanUsecase struct {
+	tpr repository.ITrainingPlanRepository
+	vr  repository.IVdotRepository
+	zpr repository.IZoneProfileRepository
+	tpv validator.ITrainingPlanValidator
+}
+
+func NewTrainingPlanUsecase(tpr repository.ITrainingPlanRepository, vr repository.IVdotRepository, zpr repository.IZoneProfileRepository, tpv validator.ITrainingPlanValidator) ITrainingPlanUsecase {
+	return &trainingPlanUsecase{tpr, vr, zpr, tpv}
+}
+
+func toTrainingPlanResponse(trainingPlan model.TrainingPlan, currentVdot *float64) model.TrainingPlanResponse {
+	return model.TrainingPlanResponse{
+		ID:            trainingPlan.ID,
+		Name:          trainingPlan.Name,
+		GoalDistance:  trainingPlan.GoalDistance,
+		GoalDate:      trainingPlan.GoalDate,
+		StartDate:     trainingPlan.StartDate,
+		WeeklyMileage: trainingPlan.WeeklyMileage,
+		MileageUnit:   trainingPlan.MileageUnit,
+		AvailableDays: trainingPlan.AvailableDays,
+		Profile:       trainingPlan.Profile,
+		Vdot:          trainingPlan.Vdot,
+		CurrentVdot:   currentVdot,
+		VdotChanged:   currentVdot != nil && *currentVdot != trainingPlan.Vdot,
+		Weeks:         trainingPlan.Weeks,
+		CreatedAt:     trainingPlan.CreatedAt,
+		UpdatedAt:     trainingPlan.UpdatedAt,
+	}
+}
+
+func (tpu *trainingPlanUsecase) CreateTrainingPlan(trainingPlan model.TrainingPlan) (model.TrainingPlanResponse, error) {
+	if err := tpu.tpv.TrainingPlanValidate(trainingPlan); err != nil {
+		return model.TrainingPlanResponse{}, err
+	}
+	if trainingPlan.StartDate.IsZero() {
+		trainingPlan.StartDate = pkg.DateOnly{Time: nextMonday(time.Now())}
+	}
+	if trainingPlan.Profile == "" {
+		trainingPlan.Profile = DefaultZoneProfileName
+	}
+
+	if err := tpu.generate(&trainingPlan); err != nil {
+		return model.TrainingPlanResponse{}, err
+	}
+	if err := tpu.tpr.CreateTrainingPlan(&trainingPlan, planWorkouts(trainingPlan, trainingPlan.StartDate.Time)); err != nil {
+		return model.TrainingPlanResponse{}, err
+	}
+	return toTrainingPlanResponse(trainingPlan, &trainingPlan.Vdot), nil
+}
+
+func (tpu *trainingPlanUsecase) GetTrainingPlans(userId uint) ([]model.TrainingPlanResponse, error) {
+	trainingPlans, err := tpu.tpr.GetTrainingPlans(userId)
+	if err != nil {
+		return nil, err
+	}
+	currentVdot := tpu.currentVdot(userId)
+	res := make([]model.TrainingPlanResponse, len(trainingPlans))
+	for i, p := range trainingPlans {
+		res[i] = toTrainingPlanResponse(p, currentVdot)
+	}
+	return res, nil
+}
+
+func (tpu *trainingPlanUsecase) GetTrainingPlanById(userId uint, trainingPlanId uint) (model.TrainingPlanResponse, error) {
+	trainingPlan := model.TrainingPlan{}
+	if err := tpu.tpr.GetTrainingPlanById(&trainingPlan, userId, trainingPlanId); err != nil {
+		return model.TrainingPlanResponse{}, err
+	}
+	return toTrainingPlanResponse(trainingPlan, tpu.currentVdot(userId)), nil
+}
+
+// RegenerateTrainingPlan は保存されている条件のまま、現在のVDOTで計画を作り直す
+func (tpu *trainingPlanUsecase) RegenerateTrainingPlan(userId uint, trainingPlanId uint) (model.TrainingPlanResponse, error) {
+	trainingPlan := model.TrainingPlan{}
+	if err := tpu.tpr.GetTrainingPlanById(&trainingPlan, userId, trainingPlanId); err != nil {
+		return model.TrainingPlanResponse{}, err
+	}
+	if err := tpu.generate(&trainingPlan); err != nil {
+		return model.TrainingPlanResponse{}, err
+	}
+	// 過ぎた日の予定は実績と比べるため残し、今日以降の予定だけを作り直す
+	today := time.Now()
+	today = time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)
+	if err := tpu.tpr.UpdateTrainingPlan(&trainingPlan, userId, trainingPlanId, today, planWorkouts(trainingPlan, today)); err != nil {
+		return model.TrainingPlanResponse{}, err
+	}
+	return toTrainingPlanResponse(trainingPlan, &trainingPlan.Vdot), nil
+}
+
+func (tpu *trainingPlanUsecase) DeleteTrainingPlan(userId uint, trainingPlanId uint) error {
+	return tpu.tpr.DeleteTrainingPlan(userId, trainingPlanId)
+}
+
+// generate は現在のVDOTとゾーン定義から週ごとの練習を作り、trainingPlan に設定する
+func (tpu *trainingPlanUsecase) generate(trainingPlan *model.TrainingPlan) error {
+	vdot, err := selectVdot(tpu.vr, trainingPlan.UserId, "", 0)
+	if err != nil {
+		return fmt.Errorf("vdot data not found: %v", err)
+	}
+	detail, err := ComputeVdot(vdot)
+	if err != nil {
+		return err
+	}
+	zoneProfile, err := resolveZoneProfile(tpu.zpr, trainingPlan.UserId, trainingPlan.Profile)
+	if err != nil {
+		return err
+	}
+
+	weeks, err := GeneratePlanWeeks(*trainingPlan, detail.PreciseVdot, zoneProfile)
+	if err != nil {
+		return err
+	}
+	trainingPlan.Vdot = detail.AdjustedVdot
+	trainingPlan.Weeks = weeks
+	return nil
+}
+
+// planWorkouts は計画の from 以降の練習を予定（PlannedWorkout）にする（計画のIDは保存するときに設定する）
+func planWorkouts(trainingPlan model.TrainingPlan, from time.Time) []model.PlannedWorkout {
+	var plannedWorkouts []model.PlannedWorkout
+	for _, week := range trainingPlan.Weeks {
+		for _, s := range week.Sessions {
+			if s.Date.Before(from) {
+				continue
+			}
+			plannedWorkouts = append(plannedWorkouts, model.PlannedWorkout{
+				Date:        s.Date,
+				Workout:     s.Workout,
+				Mileage:     s.DistanceKm,
+				MileageUnit: "km",
+				UserId:      trainingPlan.UserId,
+			})
+		}
+	}
+	return plannedWorkouts
+}
+
+// currentVdot は計画の生成に使うVDOTの現在の値を返す（記録がない場合は nil）
+func (tpu *trainingPlanUsecase) currentVdot(userId uint) *float64 {
+	vdot, err := selectVdot(tpu.vr, userId, "", 0)
+	if err != nil {
+		return nil
+	}
+	detail, err := ComputeVdot(vdot)
+	if err != nil {
+		return nil
+	}
+	return &detail.AdjustedVdot
+}
+
+// nextMonday は t の次の月曜日（t が月曜日の場合は t）の日付を返す
+func nextMonday(t time.Time) time.Time {
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return date.AddDate(0, 0, (7-weekdayIndex(date))%7)
+}
+
+// weekdayIndex は月曜日を 0 とした曜日の番号を返す
+func weekdayIndex(t time.Time) int {
+	return (int(t.Weekday()) + 6) % 7
+}
+
+// GeneratePlanWeeks は開始日から目標レースの週までの練習を生成する。
+// 週間走行距離は週5%ずつ（最大で現在の1.2倍まで）増やし、4週ごとに2割落とす。
+// 練習の表記は Workout.Workout と同じで、ペースは vdotValue とゾーン定義から算出する。
+func GeneratePlanWeeks(trainingPlan model.TrainingPlan, vdotValue float64, zoneProfile model.ZoneProfile) (model.PlanWeeks, error) {
+	start := trainingPlan.StartDate.Time
+	goal := trainingPlan.GoalDate.Time
+	if goal.Before(start) {
+		return nil, errors.New("goal_date must be after start_date")
+	}
+	weekStart := start.AddDate(0, 0, -weekdayIndex(start))
+	weekCount := int(goal.Sub(weekStart).Hours()/24)/7 + 1
+	if weekCount < MinPlanWeeks || weekCount > MaxPlanWeeks {
+		return nil, fmt.Errorf("plan must be %d to %d weeks (got %d)", MinPlanWeeks, MaxPlanWeeks, weekCount)
+	}
+
+	weeklyKm := trainingPlan.WeeklyMileage
+	if trainingPlan.MileageUnit == "mile" {
+		weeklyKm *= kmPerMile
+	}
+
+	emphasis := planEmphases[len(planEmphases)-1]
+	for _, e := range planEmphases {
+		if trainingPlan.GoalDistance <= e.maxDistance {
+			emphasis = e
+			break
+		}
+	}
+
+	days := make([]int, 0, len(trainingPlan.AvailableDays))
+	for i, d := range planWeekdays {
+		for _, available := range trainingPlan.AvailableDays {
+			if d == available {
+				days = append(days, i)
+			}
+		}
+	}
+	if len(days) < 3 {
+		return nil, errors.New("available_days must have 3 or more days")
+	}
+	longDay, qualityDays := planLayout(days)
+
+	phases := planPhases(weekCount, trainingPlan.GoalDistance)
+	mileages := planMileages(phases, weeklyKm)
+
+	g := planGenerator{vdot: vdotValue, zoneProfile: zoneProfile, emphasis: emphasis}
+	weeks := make(model.PlanWeeks, 0, weekCount)
+	for w := 0; w < weekCount; w++ {
+		monday := weekStart.AddDate(0, 0, 7*w)
+		raceWeek := w == weekCount-1
+
+		sessions, err := g.weekSessions(phases[w], mileages[w], monday, days, longDay, qualityDays, raceWeek, goal, trainingPlan.GoalDistance)
+		if err != nil {
+			return nil, err
+		}
+
+		week := model.PlanWeek{
+			Week:      w + 1,
+			Phase:     phases[w],
+			StartDate: pkg.DateOnly{Time: monday},
+			Sessions:  []model.PlanSession{},
+		}
+		mileage := 0.0
+		for _, s := range sessions {
+			// 開始日より前と目標レースより後の練習は入れない
+			if s.Date.Before(start) || s.Date.After(goal) {
+				continue
+			}
+			week.Sessions = append(week.Sessions, s)
+			mileage += s.DistanceKm
+		}
+		week.MileageKm = math.Round(mileage*10) / 10
+		weeks = append(weeks, week)
+	}
+	return weeks, nil
+}
+
+// planPhases は週ごとの期分けを返す（taper は10km程度までは1週、それより長いレースは2週、マラソンで12週以上の計画は3週）
+func planPhases(weekCount int, goalDistance float64) []string {
+	taper := 1
+	if goalDistance > 15000 {
+		taper = 2
+	}
+	if goalDistance >= 42195 && weekCount >= 12 {
+		taper = 3
+	}
+	rest := weekCount - taper
+	peak := int(math.Round(float64(rest) * 0.25))
+	quality := int(math.Round(float64(rest) * 0.35))
+	base := rest - peak - quality
+	// base は最低1週
+	for base < 1 {
+		if quality > 0 {
+			quality--
+		} else {
+			peak--
+		}
+		base++
+	}
+
+	phases := make([]string, 0, weekCount)
+	for _, p := range []struct {
+		phase string
+		weeks int
+	}{{PlanPhaseBase, base}, {PlanPhaseQuality, quality}, {PlanPhasePeak, peak}, {PlanPhaseTaper, taper}} {
+		for i := 0; i < p.weeks; i++ {
+			phases = append(phases, p.phase)
+		}
+	}
+	return phases
+}
+
+// taper 期の週間走行距離（最大の週に対する割合）
+var taperFactors = map[int][]float64{
+	1: {0.6},
+	2: {0.75, 0.5},
+	3: {0.8, 0.65, 0.5},
+}
+
+// planMileages は週ごとの予定の走行距離（km）を返す
+func planMileages(phases []string, weeklyKm float64) []float64 {
+	maxKm := weeklyKm * 1.2
+	mileages := make([]float64, len(phases))
+	taperWeeks := 0
+	for _, p := range phases {
+		if p == PlanPhaseTaper {
+			taperWeeks++
+		}
+	}
+
+	highest := weeklyKm
+	taper := 0
+	for i, p := range phases {
+		if p == PlanPhaseTaper {
+			mileages[i] = highest * taperFactors[taperWeeks][taper]
+			taper++
+			continue
+		}
+		km := math.Min(weeklyKm*(1+0.05*float64(i)), maxKm)
+		highest = math.Max(highest, km)
+		// 4週ごとに回復の週を入れる
+		if i%4 == 3 {
+			km *= 0.8
+		}
+		mileages[i] = km
+	}
+	return mileages
+}
+
+// planLayout はロング走の曜日（日曜日、なければ土曜日、なければ最後の曜日）と、
+// 質の高い練習を入れる曜日（ロング走と互いにできるだけ離れた順）を返す
+func planLayout(days []int) (int, []int) {
+	longDay := days[len(days)-1]
+	for _, preferred := range []int{6, 5} {
+		if containsDay(days, preferred) {
+			longDay = preferred
+			break
+		}
+	}
+
+	chosen := []int{longDay}
+	var qualityDays []int
+	for len(qualityDays) < 2 {
+		best, bestGap := -1, -1
+		for _, d := range days {
+			if containsDay(chosen, d) {
+				continue
+			}
+			// 週をまたいだ間隔も考える
+			gap := 7
+			for _, c := range chosen {
+				diff := int(math.Abs(float64(d - c)))
+				gap = min(gap, min(diff, 7-diff))
+			}
+			if gap > bestGap {
+				best, bestGap = d, gap
+			}
+		}
+		if best < 0 {
+			break
+		}
+		chosen = append(chosen, best)
+		qualityDays = append(qualityDays, best)
+	}
+	return longDay, qualityDays
+}
+
+func containsDay(days []int, day int) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+type planGenerator struct {
+	vdot        float64
+	zoneProfile model.ZoneProfile
+	emphasis    planEmphasis
+}
+
+// weekSessions は1週間分の練習を作る
+func (g planGenerator) weekSessions(phase string, weekKm float64, monday time.Time, days []int, longDay int, qualityDays []int, raceWeek bool, goal time.Time, goalDistance float64) ([]model.PlanSession, error) {
+	var zones []string
+	switch phase {
+	case PlanPhaseQuality:
+		zones = g.emphasis.quality
+	case PlanPhasePeak, PlanPhaseTaper:
+		zones = g.emphasis.peak
+	}
+	// 質の高い練習は taper 期は1回、週3日しか走れない場合も1回
+	qualityCount := min(len(zones), len(qualityDays))
+	if phase == PlanPhaseTaper || len(days) == 3 {
+		qualityCount = min(qualityCount, 1)
+	}
+
+	notations := map[int]string{}
+	types := map[int]string{}
+	remainingKm := weekKm
+
+	placed := 0
+	for _, day := range qualityDays {
+		if placed >= qualityCount {
+			break
+		}
+		// レースの週はレースの3日前までに入れる
+		if raceWeek && monday.AddDate(0, 0, day).After(goal.AddDate(0, 0, -3)) {
+			continue
+		}
+		notation, km := qualityNotation(zones[placed], weekKm, phase == PlanPhaseTaper)
+		notations[day] = notation
+		types[day] = PlanSessionQuality
+		remainingKm -= km
+		placed++
+	}
+
+	// ロング走は週の25%（最低5km）。E の日はロング走より長くしない
+	longKm := roundHalf(math.Max(weekKm*0.25, 5))
+	if raceWeek {
+		remainingKm -= goalDistance / 1000
+	} else {
+		notations[longDay] = fmt.Sprintf("E%skm", formatKm(longKm))
+		types[longDay] = PlanSessionLong
+		remainingKm -= longKm
+	}
+
+	var easyDays []int
+	for _, d := range days {
+		if _, ok := notations[d]; ok {
+			continue
+		}
+		if raceWeek && !monday.AddDate(0, 0, d).Before(goal) {
+			continue
+		}
+		easyDays = append(easyDays, d)
+	}
+	// 1回3km未満になる場合は走る日を減らす
+	easyCount := min(len(easyDays), int(remainingKm/3))
+	for i := 0; i < easyCount; i++ {
+		day := easyDays[i]
+		notation := fmt.Sprintf("E%skm", formatKm(math.Min(roundHalf(remainingKm/float64(easyCount)), longKm)))
+		// base 期はウィンドスプリントを1回入れる
+		if phase == PlanPhaseBase && i == 0 {
+			notation += ", 6x(R200m・ジョグ200m)"
+		}
+		notations[day] = notation
+		types[day] = PlanSessionEasy
+	}
+
+	var sessions []model.PlanSession
+	for day, notation := range notations {
+		session, err := g.session(monday.AddDate(0, 0, day), types[day], notation)
+		if err != nil {
+			return nil, err
+		}
+		if raceWeek && !session.Date.Before(goal) {
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+
+	if raceWeek {
+		race, err := g.raceSession(goal, goalDistance)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, race)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Date.Before(sessions[j].Date.Time) })
+	return sessions, nil
+}
+
+// qualityNotation は週間走行距離に応じた質の高い練習の表記と、その距離（km、ジョグでつなぐ分は除く）を返す。
+// 1回の量は Daniels の上限（T は週の10%、I は8%、R は5%、M は20%）に合わせる。
+func qualityNotation(zone string, weekKm float64, taper bool) (string, float64) {
+	scale := 1.0
+	if taper {
+		scale = 0.6
+	}
+	const warmUp, coolDown = 3.0, 2.0
+	var work string
+	var workKm float64
+	switch zone {
+	case "T":
+		reps := int(clamp(math.Round(weekKm*0.10*scale), 3, 10))
+		work, workKm = fmt.Sprintf("%dx(T1km・ジョグ1分)", reps), float64(reps)
+	case "I":
+		reps := int(clamp(math.Round(weekKm*0.08*scale), 3, 10))
+		work, workKm = fmt.Sprintf("%dx(I1000m・ジョグ3分)", reps), float64(reps)
+	case "R":
+		reps := int(clamp(math.Round(weekKm*0.05*scale*1000/400), 4, 20))
+		work, workKm = fmt.Sprintf("%dx(R400m・ジョグ400m)", reps), float64(reps)*0.4
+	default:
+		km := clamp(math.Round(weekKm*0.20*scale), 6, 29)
+		work, workKm = fmt.Sprintf("M%skm", formatKm(km)), km
+	}
+	return fmt.Sprintf("E%skm, %s, E%skm", formatKm(warmUp), work, formatKm(coolDown)), warmUp + workKm + coolDown
+}
+
+// raceSession は目標レースの練習を作る（ペースはレースに近いゾーン、予測タイムはVDOTから算出）
+func (g planGenerator) raceSession(goal time.Time, goalDistance float64) (model.PlanSession, error) {
+	notation := fmt.Sprintf("%s%skm", g.emphasis.raceZone, formatKm(math.Round(goalDistance/100)/10))
+	session, err := g.session(goal, PlanSessionRace, notation)
+	if err != nil {
+		return model.PlanSession{}, err
+	}
+	targetTime := FormatRaceTime(SolveRaceTime(g.vdot, goalDistance))
+	session.TargetTime = &targetTime
+	return session, nil
+}
+
+// session は練習の表記を解析して、距離と各ゾーンの目標ペースを付けた練習を作る
+func (g planGenerator) session(date time.Time, sessionType string, notation string) (model.PlanSession, error) {
+	segments, err := workoutparser.Parse(notation)
+	if err != nil {
+		return model.PlanSession{}, err
+	}
+
+	profile := model.ZoneProfile{Distances: model.ZoneDistances{{Label: "1Km", Meters: 1000}}}
+	meters := 0.0
+	for _, s := range segments {
+		if s.DistanceMeters != nil {
+			meters += *s.DistanceMeters * float64(s.Repetitions)
+		}
+		if _, ok := findZone(profile, s.Zone); !ok {
+			zone, ok := findZone(g.zoneProfile, s.Zone)
+			if !ok {
+				zone, _ = findZone(DefaultZoneProfile(), s.Zone)
+			}
+			profile.Zones = append(profile.Zones, zone)
+		}
+		// 1本の距離が1km未満の場合はその距離のペースも出す
+		if s.DistanceMeters != nil && *s.DistanceMeters < 1000 && !containsDistance(profile.Distances, *s.DistanceMeters) {
+			profile.Distances = append(profile.Distances, model.ZoneDistance{
+				Label:  strconv.FormatFloat(*s.DistanceMeters, 'f', -1, 64) + "m",
+				Meters: *s.DistanceMeters,
+			})
+		}
+	}
+
+	return model.PlanSession{
+		Date:        pkg.DateOnly{Time: date},
+		Type:        sessionType,
+		Workout:     notation,
+		DistanceKm:  math.Round(meters/100) / 10,
+		TargetPaces: CalculatePaceZoneList(g.vdot, Conditions{}, profile),
+	}, nil
+}
+
+func containsDistance(distances model.ZoneDistances, meters float64) bool {
+	for _, d := range distances {
+		if d.Meters == meters {
+			return true
+		}
+	}
+	return false
+}
+
+func clamp(value float64, lower float64, upper float64) float64 {
+	return math.Max(lower, math.Min(upper, value))
+}
+
+// roundHalf は 0.5km 単位に丸める
+func roundHalf(km float64) float64 {
+	return math.Round(km*2) / 2
+}
+
+func formatKm(km float64) string {
+	return strconv.FormatFloat(km, 'f', -1, 64)
+}
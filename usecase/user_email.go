@@ -0,0 +1,223 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"go_vdot_api/model"
+	"go_vdot_api/pkg/logger"
+	"go_vdot_api/pkg/mailer"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// メールで送るトークンの有効期限
+const (
+	EmailVerificationTTL = 24 * time.Hour
+	PasswordResetTTL     = time.Hour
+	AccountDeletionTTL   = time.Hour
+)
+
+var (
+	ErrInvalidToken         = errors.New("token is invalid or expired")
+	ErrEmailAlreadyVerified = errors.New("email is already verified")
+)
+
+// frontendURL はメールに載せるフロントエンドのページの URL（token はクエリで渡す）
+func frontendURL(path string, token string) string {
+	return strings.TrimRight(os.Getenv("FE_URL"), "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// issueUserToken は用途ごとの未使用のトークンを無効にしてから、新しいトークンを発行する
+func (uu *userUsecase) issueUserToken(user model.User, purpose string, ttl time.Duration) (string, error) {
+	if err := uu.utr.ExpireUserTokens(user.ID, purpose); err != nil {
+		return "", err
+	}
+	token, err := generateSecretToken()
+	if err != nil {
+		return "", err
+	}
+	userToken := model.UserToken{
+		UserId:    user.ID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := uu.utr.CreateUserToken(&userToken); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// useUserToken は未使用で期限内のトークンを使用済みにして返す
+func (uu *userUsecase) useUserToken(purpose string, token string) (model.UserToken, error) {
+	if token == "" {
+		return model.UserToken{}, ErrInvalidToken
+	}
+	userToken := model.UserToken{}
+	if err := uu.utr.GetUserToken(&userToken, purpose, hashToken(token)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.UserToken{}, ErrInvalidToken
+		}
+		return model.UserToken{}, err
+	}
+	if userToken.UsedAt != nil || !userToken.ExpiresAt.After(time.Now()) {
+		return model.UserToken{}, ErrInvalidToken
+	}
+	if err := uu.utr.UseUserToken(userToken.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.UserToken{}, ErrInvalidToken
+		}
+		return model.UserToken{}, err
+	}
+	return userToken, nil
+}
+
+func (uu *userUsecase) sendVerificationEmail(user model.User) error {
+	token, err := uu.issueUserToken(user, model.TokenEmailVerification, EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+	return uu.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "メールアドレスの確認",
+		Body: fmt.Sprintf("以下のリンクを開いてメールアドレスを確認してください（%d時間有効）。\n\n%s\n\nお心当たりがない場合はこのメールを破棄してください。\n",
+			int(EmailVerificationTTL.Hours()), frontendURL("/verify-email", token)),
+	})
+}
+
+// SendVerificationEmail は確認メールを送り直す（以前に送ったリンクは使えなくなる）
+func (uu *userUsecase) SendVerificationEmail(userId uint) error {
+	user := model.User{}
+	if err := uu.ur.GetUserByID(&user, userId); err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+	return uu.sendVerificationEmail(user)
+}
+
+// VerifyEmail は確認メールのトークンでメールアドレスを確認済みにする
+func (uu *userUsecase) VerifyEmail(token string) error {
+	userToken, err := uu.useUserToken(model.TokenEmailVerification, token)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if err := uu.ur.SetEmailVerified(userToken.UserId, userToken.Email, &now); err != nil {
+		// 確認メールを送った後にメールアドレスが変更された場合
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidToken
+		}
+		return err
+	}
+	return nil
+}
+
+// ForgotPassword はパスワードの再設定のメールを送る
+// 登録されているメールアドレスか推測されないよう、ユーザーの検索・トークンの発行・メールの送信はリクエストとは別に行い、
+// 存在しない・無効なユーザーでも送信に失敗しても同じ結果を返す
+func (uu *userUsecase) ForgotPassword(email string) error {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return errors.New("email is required")
+	}
+	go func() {
+		if err := uu.sendPasswordResetEmail(email); err != nil {
+			logger.Error("sendPasswordResetEmail error: %v", err)
+		}
+	}()
+	return nil
+}
+
+// sendPasswordResetEmail は email のユーザーに再設定のメールを送る（存在しない・無効なユーザーには送らない）
+func (uu *userUsecase) sendPasswordResetEmail(email string) error {
+	user := model.User{}
+	if err := uu.ur.GetUserByEmail(&user, email); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.DisabledAt != nil {
+		logger.Info("password reset requested for disabled account: user_id=%d", user.ID)
+		return nil
+	}
+	token, err := uu.issueUserToken(user, model.TokenPasswordReset, PasswordResetTTL)
+	if err != nil {
+		return err
+	}
+	if err := uu.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "パスワードの再設定",
+		Body: fmt.Sprintf("以下のリンクを開いて新しいパスワードを設定してください（%d分有効）。\n\n%s\n\nお心当たりがない場合はこのメールを破棄してください。パスワードは変更されません。\n",
+			int(PasswordResetTTL.Minutes()), frontendURL("/reset-password", token)),
+	}); err != nil {
+		return fmt.Errorf("user_id=%d %w", user.ID, err)
+	}
+	return nil
+}
+
+// SendAccountDeletionEmail はアカウント削除の確認メールを送る（以前に送ったリンクは使えなくなる）
+// パスワードのないユーザーは、リンクのトークンで DeleteUser の本人確認をする
+func (uu *userUsecase) SendAccountDeletionEmail(userId uint) error {
+	user := model.User{}
+	if err := uu.ur.GetUserByID(&user, userId); err != nil {
+		return err
+	}
+	token, err := uu.issueUserToken(user, model.TokenAccountDeletion, AccountDeletionTTL)
+	if err != nil {
+		return err
+	}
+	return uu.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "アカウントの削除の確認",
+		Body: fmt.Sprintf("以下のリンクを開くとアカウントとすべてのデータを削除します（%d分有効）。削除したデータは元に戻せません。\n\n%s\n\nお心当たりがない場合はこのメールを破棄し、パスワードの変更やセッションの確認をしてください。\n",
+			int(AccountDeletionTTL.Minutes()), frontendURL("/delete-account", token)),
+	})
+}
+
+// ResetPassword は再設定のメールのトークンでパスワードを変更し、すべてのセッションを無効にする（ログインのロックも解除する）
+// メールを受け取れたことになるため、メールアドレスが変わっていなければ確認済みにする
+func (uu *userUsecase) ResetPassword(token string, password string) error {
+	if err := uu.uv.PasswordValidate(password); err != nil {
+		return err
+	}
+	userToken, err := uu.useUserToken(model.TokenPasswordReset, token)
+	if err != nil {
+		return err
+	}
+	user := model.User{}
+	if err := uu.ur.GetUserByID(&user, userToken.UserId); err != nil {
+		return err
+	}
+	if user.Email != userToken.Email || user.DisabledAt != nil {
+		return ErrInvalidToken
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), 10)
+	if err != nil {
+		return err
+	}
+	if err := uu.ur.UpdatePassword(user.ID, string(hash)); err != nil {
+		return err
+	}
+	if err := uu.ur.ResetLoginFailures(user.ID); err != nil {
+		return err
+	}
+	if err := uu.sr.RevokeUserSessions(user.ID, 0); err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt == nil {
+		now := time.Now()
+		if err := uu.ur.SetEmailVerified(user.ID, user.Email, &now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"errors"
+	"go_vdot_api/model"
+	"go_vdot_api/pkg/logger"
+	"go_vdot_api/pkg/metrics"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 続けてパスワードを間違えた場合のロック
+// LoginLockoutThreshold 回目の失敗で LoginLockoutBase ロックし、以降は失敗するたびに2倍にする（上限 LoginLockoutMax）
+const (
+	LoginLockoutThreshold = 5
+	LoginLockoutBase      = time.Minute
+	LoginLockoutMax       = time.Hour
+)
+
+// ErrInvalidCredentials はメールアドレスが存在しない場合もパスワードが違う場合もロック中の場合も同じエラーにする
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// lockoutDuration は続けて failures 回失敗した場合のロックの長さ（しきい値未満は 0）
+func lockoutDuration(failures int) time.Duration {
+	if failures < LoginLockoutThreshold {
+		return 0
+	}
+	d := LoginLockoutBase
+	for i := LoginLockoutThreshold; i < failures && d < LoginLockoutMax; i++ {
+		d *= 2
+	}
+	return min(d, LoginLockoutMax)
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// compareDummyPassword は存在しないメールアドレスでもパスワードの確認と同じ時間をかけ、応答時間から登録の有無を推測されないようにする
+func compareDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password-for-timing"), 10)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+// recordLoginFailure はログインの失敗を記録し、しきい値を超えた場合はアカウントをロックする
+// ロックしたことは応答に出さず、ErrInvalidCredentials を返す
+func (uu *userUsecase) recordLoginFailure(user model.User) error {
+	metrics.LoginAttempts.Inc("invalid_credentials")
+	failures, err := uu.ur.RecordLoginFailure(user.ID)
+	if err != nil {
+		return err
+	}
+	d := lockoutDuration(failures)
+	if d == 0 {
+		return ErrInvalidCredentials
+	}
+	until := time.Now().Add(d)
+	if err := uu.ur.LockUser(user.ID, until); err != nil {
+		return err
+	}
+	metrics.AccountLockouts.Inc("locked")
+	logger.Warn("account locked: user_id=%d failures=%d until=%s", user.ID, failures, until.Format(time.RFC3339))
+	return ErrInvalidCredentials
+}
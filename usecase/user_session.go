@@ -0,0 +1,228 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"go_vdot_api/model"
+	"go_vdot_api/pkg/logger"
+	"go_vdot_api/repository"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
+)
+
+// アクセストークンは短く、リフレッシュトークンで更新する（ACCESS_TOKEN_TTL / REFRESH_TOKEN_TTL で変更できる）
+const (
+	DefaultAccessTokenTTL  = 15 * time.Minute
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("refresh token is invalid or expired")
+	ErrSessionRevoked      = errors.New("session is revoked or expired")
+)
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// hashToken はリフレッシュトークン・メールで送るトークンを保存・検索するためのハッシュ（トークン自体は保存しない）
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateSecretToken は推測できないランダムなトークンを作る
+func generateSecretToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// signAccessToken はセッションの ID（sid）を含むアクセストークンを作る
+func signAccessToken(user model.User, sessionId uint, expiresAt time.Time) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id":  user.ID,
+		"name":     user.Name,
+		"email":    user.Email,
+		"is_admin": user.IsAdmin,
+		"sid":      sessionId,
+		"exp":      expiresAt.Unix(),
+	})
+	return token.SignedString([]byte(os.Getenv("SECRET_KEY")))
+}
+
+// startSession はセッションを作り、アクセストークンとリフレッシュトークンを発行する（パスワード・外部のアカウントのログインで共通）
+func startSession(sr repository.ISessionRepository, user model.User, userAgent string, ipAddress string) (model.AuthTokens, error) {
+	refreshToken, err := generateSecretToken()
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+	now := time.Now()
+	session := model.Session{
+		UserId:           user.ID,
+		RefreshTokenHash: hashToken(refreshToken),
+		UserAgent:        truncate(userAgent, 255),
+		IPAddress:        ipAddress,
+		LastSeenAt:       now,
+		ExpiresAt:        now.Add(envDuration("REFRESH_TOKEN_TTL", DefaultRefreshTokenTTL)),
+	}
+	if err := sr.CreateSession(&session); err != nil {
+		return model.AuthTokens{}, err
+	}
+	accessExpiresAt := now.Add(envDuration("ACCESS_TOKEN_TTL", DefaultAccessTokenTTL))
+	accessToken, err := signAccessToken(user, session.ID, accessExpiresAt)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+	return model.AuthTokens{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// Refresh はリフレッシュトークンを新しいものに交換し、アクセストークンを発行し直す
+// 交換済みのトークンが再び使われた場合は盗まれたとみなし、そのセッションを無効にする
+func (uu *userUsecase) Refresh(refreshToken string, userAgent string, ipAddress string) (model.AuthTokens, error) {
+	if refreshToken == "" {
+		return model.AuthTokens{}, ErrInvalidRefreshToken
+	}
+	tokenHash := hashToken(refreshToken)
+	session := model.Session{}
+	if err := uu.sr.GetSessionByTokenHash(&session, tokenHash); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.AuthTokens{}, err
+		}
+		reused := model.Session{}
+		if err := uu.sr.GetSessionByPreviousTokenHash(&reused, tokenHash); err == nil && reused.RevokedAt == nil {
+			logger.Warn("refresh token reused, revoking session: user_id=%d session_id=%d", reused.UserId, reused.ID)
+			if err := uu.sr.RevokeSession(reused.UserId, reused.ID); err != nil {
+				return model.AuthTokens{}, err
+			}
+		}
+		return model.AuthTokens{}, ErrInvalidRefreshToken
+	}
+	now := time.Now()
+	if session.RevokedAt != nil || !session.ExpiresAt.After(now) {
+		return model.AuthTokens{}, ErrInvalidRefreshToken
+	}
+
+	user := model.User{}
+	if err := uu.ur.GetUserByID(&user, session.UserId); err != nil {
+		return model.AuthTokens{}, err
+	}
+	if user.DisabledAt != nil {
+		if err := uu.sr.RevokeSession(user.ID, session.ID); err != nil {
+			return model.AuthTokens{}, err
+		}
+		return model.AuthTokens{}, ErrAccountDisabled
+	}
+
+	newRefreshToken, err := generateSecretToken()
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+	refreshExpiresAt := now.Add(envDuration("REFRESH_TOKEN_TTL", DefaultRefreshTokenTTL))
+	if err := uu.sr.RotateSession(session.ID, tokenHash, hashToken(newRefreshToken), truncate(userAgent, 255), ipAddress, refreshExpiresAt); err != nil {
+		// 同じトークンで同時に更新され、先に交換された場合
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.AuthTokens{}, ErrInvalidRefreshToken
+		}
+		return model.AuthTokens{}, err
+	}
+	accessExpiresAt := now.Add(envDuration("ACCESS_TOKEN_TTL", DefaultAccessTokenTTL))
+	accessToken, err := signAccessToken(user, session.ID, accessExpiresAt)
+	if err != nil {
+		return model.AuthTokens{}, err
+	}
+	return model.AuthTokens{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  accessExpiresAt,
+		RefreshToken:     newRefreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// LogOut はリフレッシュトークンのセッションを無効にする（無効なトークンの場合は何もしない）
+func (uu *userUsecase) LogOut(refreshToken string) error {
+	if refreshToken == "" {
+		return nil
+	}
+	session := model.Session{}
+	if err := uu.sr.GetSessionByTokenHash(&session, hashToken(refreshToken)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if session.RevokedAt != nil {
+		return nil
+	}
+	return uu.sr.RevokeSession(session.UserId, session.ID)
+}
+
+// GetSessions はログイン中のセッションを最後に使われた順に返す（currentSessionId のセッションに current を付ける）
+func (uu *userUsecase) GetSessions(userId uint, currentSessionId uint) ([]model.SessionResponse, error) {
+	sessions, err := uu.sr.GetActiveSessions(userId)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]model.SessionResponse, len(sessions))
+	for i, s := range sessions {
+		res[i] = model.SessionResponse{
+			ID:         s.ID,
+			UserAgent:  s.UserAgent,
+			IPAddress:  s.IPAddress,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    s.ID == currentSessionId,
+		}
+	}
+	return res, nil
+}
+
+func (uu *userUsecase) RevokeSession(userId uint, sessionId uint) error {
+	if err := uu.sr.RevokeSession(userId, sessionId); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ValidateSession はアクセストークンのセッションが無効にされておらず期限内かチェックする
+// （パスワードの変更・アカウントの無効化ではセッションが無効にされるため、発行済みのトークンも使えなくなる）
+func (uu *userUsecase) ValidateSession(userId uint, sessionId uint) error {
+	session := model.Session{}
+	if err := uu.sr.GetSessionById(&session, userId, sessionId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+	if session.RevokedAt != nil || !session.ExpiresAt.After(time.Now()) {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// truncate は列の長さに収まるよう文字数を切り詰める
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
@@ -1,31 +1,61 @@
 package usecase
 
 import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
 	"go_vdot_api/model"
+	"go_vdot_api/pkg/csvfile"
+	"go_vdot_api/pkg/logger"
+	"go_vdot_api/pkg/mailer"
+	"go_vdot_api/pkg/metrics"
 	"go_vdot_api/repository"
 	"go_vdot_api/validator"
-	"os"
+	"io"
 	"time"
 
-	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type IUserUsecase interface {
 	SignUp(user model.User) (model.UserResponse, error)
-	LogIn(user model.User) (string, error)
+	LogIn(user model.User, userAgent string, ipAddress string) (model.AuthTokens, error)
+	Refresh(refreshToken string, userAgent string, ipAddress string) (model.AuthTokens, error)
+	LogOut(refreshToken string) error
+	GetSessions(userId uint, currentSessionId uint) ([]model.SessionResponse, error)
+	RevokeSession(userId uint, sessionId uint) error
+	ValidateSession(userId uint, sessionId uint) error
+	SendVerificationEmail(userId uint) error
+	VerifyEmail(token string) error
+	ForgotPassword(email string) error
+	ResetPassword(token string, password string) error
 
-	UpdateUser(user model.User) (model.UserResponse, error)
-	DeleteUser(userId uint) error
+	UpdateUser(user model.User, sessionId uint) (model.UserResponse, error)
+	SendAccountDeletionEmail(userId uint) error
+	DeleteUser(userId uint, password string, token string, ipAddress string) (model.AccountDeletionResponse, error)
+	ExportAccount(userId uint) (model.AccountExportResponse, error)
 }
 
 type userUsecase struct {
-	ur repository.IUserRepository
-	uv validator.IUserValidator
+	ur     repository.IUserRepository
+	vr     repository.IVdotRepository
+	wr     repository.IWorkoutRepository
+	ser    repository.ISpecialtyEventRepository
+	zpr    repository.IZoneProfileRepository
+	tpr    repository.ITrainingPlanRepository
+	pwr    repository.IPlannedWorkoutRepository
+	car    repository.ICoachAthleteRepository
+	sr     repository.ISessionRepository
+	utr    repository.IUserTokenRepository
+	uir    repository.IUserIdentityRepository
+	patr   repository.IPersonalAccessTokenRepository
+	uv     validator.IUserValidator
+	mailer mailer.Mailer
 }
 
-func NewUserUsecase(ur repository.IUserRepository, uv validator.IUserValidator) IUserUsecase {
-	return &userUsecase{ur, uv}
+func NewUserUsecase(ur repository.IUserRepository, vr repository.IVdotRepository, wr repository.IWorkoutRepository, ser repository.ISpecialtyEventRepository, zpr repository.IZoneProfileRepository, tpr repository.ITrainingPlanRepository, pwr repository.IPlannedWorkoutRepository, car repository.ICoachAthleteRepository, sr repository.ISessionRepository, utr repository.IUserTokenRepository, uir repository.IUserIdentityRepository, patr repository.IPersonalAccessTokenRepository, uv validator.IUserValidator, m mailer.Mailer) IUserUsecase {
+	return &userUsecase{ur, vr, wr, ser, zpr, tpr, pwr, car, sr, utr, uir, patr, uv, m}
 }
 
 func (uu *userUsecase) SignUp(user model.User) (model.UserResponse, error) {
@@ -40,40 +70,60 @@ func (uu *userUsecase) SignUp(user model.User) (model.UserResponse, error) {
 	if err := uu.ur.CreateUser(&newUser); err != nil {
 		return model.UserResponse{}, err
 	}
+	// 確認メールを送れなくても登録は完了させる（後で送り直せる）
+	if err := uu.sendVerificationEmail(newUser); err != nil {
+		logger.Error("sendVerificationEmail error: user_id=%d %v", newUser.ID, err)
+	}
 	resUser := model.UserResponse{
-		ID:    newUser.ID,
-		Name:  newUser.Name,
-		Email: newUser.Email,
+		ID:            newUser.ID,
+		Name:          newUser.Name,
+		Email:         newUser.Email,
+		EmailVerified: false,
 	}
 	return resUser, nil
 }
 
-func (uu *userUsecase) LogIn(user model.User) (string, error) {
+// LogIn はパスワードを確認してセッションを作り、アクセストークンとリフレッシュトークンを返す
+// メールアドレスの有無が分からないよう、存在しない場合もパスワードが違う場合もロック中の場合も ErrInvalidCredentials を返す
+func (uu *userUsecase) LogIn(user model.User, userAgent string, ipAddress string) (model.AuthTokens, error) {
 	if err := uu.uv.UserValidate(user); err != nil {
-		return "", err
+		return model.AuthTokens{}, err
 	}
 	storedUser := model.User{}
 	if err := uu.ur.GetUserByEmail(&storedUser, user.Email); err != nil {
-		return "", err
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			compareDummyPassword(user.Password)
+			metrics.LoginAttempts.Inc("invalid_credentials")
+			return model.AuthTokens{}, ErrInvalidCredentials
+		}
+		return model.AuthTokens{}, err
+	}
+	// ロック中は正しいパスワードでも受け付けず、アカウントの有無が分からないよう同じエラー・同じ時間で返す
+	if storedUser.LockedUntil != nil && storedUser.LockedUntil.After(time.Now()) {
+		compareDummyPassword(user.Password)
+		metrics.LoginBlocked.Inc("account_locked")
+		return model.AuthTokens{}, ErrInvalidCredentials
 	}
 	err := bcrypt.CompareHashAndPassword([]byte(storedUser.Password), []byte(user.Password))
 	if err != nil {
-		return "", err
+		return model.AuthTokens{}, uu.recordLoginFailure(storedUser)
+	}
+	if storedUser.FailedLoginAttempts > 0 || storedUser.LockedUntil != nil {
+		if err := uu.ur.ResetLoginFailures(storedUser.ID); err != nil {
+			return model.AuthTokens{}, err
+		}
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": storedUser.ID,
-		"name":    storedUser.Name,
-		"email":   storedUser.Email,
-		"exp":     time.Now().Add(time.Hour * 12).Unix(),
-	})
-	tokenString, errerr := token.SignedString([]byte(os.Getenv("SECRET_KEY")))
-	if errerr != nil {
-		return "", errerr
+	if storedUser.DisabledAt != nil {
+		metrics.LoginAttempts.Inc("disabled")
+		return model.AuthTokens{}, ErrAccountDisabled
 	}
-	return tokenString, nil
+	metrics.LoginAttempts.Inc("success")
+	return startSession(uu.sr, storedUser, userAgent, ipAddress)
 }
 
-func (uu *userUsecase) UpdateUser(user model.User) (model.UserResponse, error) {
+// UpdateUser はユーザー情報を更新する
+// パスワードを変更した場合は、sessionId（変更したセッション）以外のセッションをすべて無効にする
+func (uu *userUsecase) UpdateUser(user model.User, sessionId uint) (model.UserResponse, error) {
 	storedUser := model.User{}
 	if err := uu.ur.GetUserByID(&storedUser, user.ID); err != nil {
 		return model.UserResponse{}, err
@@ -83,6 +133,7 @@ func (uu *userUsecase) UpdateUser(user model.User) (model.UserResponse, error) {
 	if user.Name != "" {
 		storedUser.Name = user.Name
 	}
+	emailChanged := user.Email != "" && user.Email != storedUser.Email
 	if user.Email != "" {
 		storedUser.Email = user.Email
 	}
@@ -98,20 +149,227 @@ func (uu *userUsecase) UpdateUser(user model.User) (model.UserResponse, error) {
 	if err := uu.ur.UpdateUser(&storedUser); err != nil {
 		return model.UserResponse{}, err
 	}
+	if user.Password != "" {
+		if err := uu.sr.RevokeUserSessions(storedUser.ID, sessionId); err != nil {
+			return model.UserResponse{}, err
+		}
+	}
+	// メールアドレスを変更した場合は未確認に戻し、新しいアドレスに確認メールを送る
+	if emailChanged {
+		if storedUser.EmailVerifiedAt != nil {
+			if err := uu.ur.SetEmailVerified(storedUser.ID, storedUser.Email, nil); err != nil {
+				return model.UserResponse{}, err
+			}
+			storedUser.EmailVerifiedAt = nil
+		}
+		if err := uu.sendVerificationEmail(storedUser); err != nil {
+			logger.Error("sendVerificationEmail error: user_id=%d %v", storedUser.ID, err)
+		}
+	}
 
 	// 更新後のユーザー情報を返す
 	resUser := model.UserResponse{
-		ID:    storedUser.ID,
-		Name:  storedUser.Name,
-		Email: storedUser.Email,
+		ID:            storedUser.ID,
+		Name:          storedUser.Name,
+		Email:         storedUser.Email,
+		EmailVerified: storedUser.EmailVerifiedAt != nil,
 	}
 	return resUser, nil
 }
 
+var (
+	ErrInvalidPassword = errors.New("password is incorrect")
+	ErrAccountDisabled = errors.New("this account is disabled")
+	ErrPasswordNotSet  = errors.New("this account has no password. Confirm the deletion with the link sent by email")
+)
+
+// DeleteUser は本人のパスワードを確認してから、ユーザーとそのすべてのデータを削除する
+// 外部のアカウントでのみログインしていてパスワードがない場合は、SendAccountDeletionEmail で送ったトークンで確認する
+// 削除したことは監査ログに残し、テーブルごとに削除した件数を返す
+func (uu *userUsecase) DeleteUser(userId uint, password string, token string, ipAddress string) (model.AccountDeletionResponse, error) {
+	storedUser := model.User{}
+	if err := uu.ur.GetUserByID(&storedUser, userId); err != nil {
+		return model.AccountDeletionResponse{}, err
+	}
+	switch {
+	case token != "":
+		userToken, err := uu.useUserToken(model.TokenAccountDeletion, token)
+		if err != nil {
+			return model.AccountDeletionResponse{}, err
+		}
+		// 他のユーザーのトークンや、送った後にメールアドレスが変更された場合は使えない
+		if userToken.UserId != userId || userToken.Email != storedUser.Email {
+			return model.AccountDeletionResponse{}, ErrInvalidToken
+		}
+	case password == "":
+		return model.AccountDeletionResponse{}, errors.New("password or token is required")
+	case storedUser.Password == "":
+		return model.AccountDeletionResponse{}, ErrPasswordNotSet
+	default:
+		if err := bcrypt.CompareHashAndPassword([]byte(storedUser.Password), []byte(password)); err != nil {
+			return model.AccountDeletionResponse{}, ErrInvalidPassword
+		}
+	}
+
+	auditLog := model.AuditLog{
+		UserId:    userId,
+		ActorId:   userId,
+		Action:    model.AuditAccountDeleted,
+		IPAddress: ipAddress,
+	}
+	counts, err := uu.ur.DeleteUser(userId, &auditLog)
+	if err != nil {
+		return model.AccountDeletionResponse{}, err
+	}
+	logger.Info("account deleted: user_id=%d audit_log_id=%d", userId, auditLog.ID)
+	return model.AccountDeletionResponse{
+		UserID:    userId,
+		DeletedAt: auditLog.CreatedAt,
+		Removed:   counts,
+	}, nil
+}
+
+// ExportAccount はプロフィールとユーザーのすべてのデータを返す（トークンのハッシュは含めない）
+func (uu *userUsecase) ExportAccount(userId uint) (model.AccountExportResponse, error) {
+	storedUser := model.User{}
+	if err := uu.ur.GetUserByID(&storedUser, userId); err != nil {
+		return model.AccountExportResponse{}, err
+	}
+	vdots, err := uu.vr.GetVdotHistory(userId)
+	if err != nil {
+		return model.AccountExportResponse{}, err
+	}
+	workouts, err := uu.wr.GetWorkoutsInRange(userId, time.Time{}, time.Time{}, nil, false, 0)
+	if err != nil {
+		return model.AccountExportResponse{}, err
+	}
+	specialtyEvents, err := uu.ser.GetSpecialtyEvent(userId)
+	if err != nil {
+		return model.AccountExportResponse{}, err
+	}
+	zoneProfiles, err := uu.zpr.GetZoneProfiles(userId)
+	if err != nil {
+		return model.AccountExportResponse{}, err
+	}
+	trainingPlans, err := uu.tpr.GetTrainingPlans(userId)
+	if err != nil {
+		return model.AccountExportResponse{}, err
+	}
+	plannedWorkouts, err := uu.pwr.GetPlannedWorkouts(userId)
+	if err != nil {
+		return model.AccountExportResponse{}, err
+	}
+	athletes, err := uu.car.GetAthletes(userId)
+	if err != nil {
+		return model.AccountExportResponse{}, err
+	}
+	coaches, err := uu.car.GetCoaches(userId)
+	if err != nil {
+		return model.AccountExportResponse{}, err
+	}
+	sessions, err := uu.sr.GetSessions(userId)
+	if err != nil {
+		return model.AccountExportResponse{}, err
+	}
+	userTokens, err := uu.utr.GetUserTokens(userId)
+	if err != nil {
+		return model.AccountExportResponse{}, err
+	}
+	identities, err := uu.uir.GetIdentities(userId)
+	if err != nil {
+		return model.AccountExportResponse{}, err
+	}
+	accessTokens, err := uu.patr.GetTokens(userId)
+	if err != nil {
+		return model.AccountExportResponse{}, err
+	}
+
+	res := model.AccountExportResponse{
+		ExportedAt: time.Now(),
+		Profile: model.AccountProfile{
+			ID:        storedUser.ID,
+			Name:      storedUser.Name,
+			Email:     storedUser.Email,
+			CreatedAt: storedUser.CreatedAt,
+		},
+		Vdots:           make([]model.VdotResponse, len(vdots)),
+		Workouts:        make([]model.WorkoutResponse, len(workouts)),
+		SpecialtyEvents: make([]model.SpecialtyEventResponse, len(specialtyEvents)),
+		ZoneProfiles:    make([]model.ZoneProfileResponse, len(zoneProfiles)),
+		TrainingPlans:   make([]model.TrainingPlanResponse, len(trainingPlans)),
+		PlannedWorkouts: make([]model.AccountPlannedWorkout, len(plannedWorkouts)),
+		CoachLinks:      toCoachLinksResponse(athletes, coaches),
+		Sessions:        sessions,
+		UserTokens:      userTokens,
+		Identities:      identities,
+		AccessTokens:    accessTokens,
+	}
+	// 記録一覧は新しい順のため逆順にする
+	for i, v := range vdots {
+		res.Vdots[len(vdots)-1-i] = toVdotResponse(v)
+	}
+	for i, w := range workouts {
+		res.Workouts[i] = toWorkoutResponse(w)
+	}
+	for i, se := range specialtyEvents {
+		res.SpecialtyEvents[i] = toSpecialtyEventResponse(se)
+	}
+	for i, zp := range zoneProfiles {
+		res.ZoneProfiles[i] = toZoneProfileResponse(zp)
+	}
+	for i, tp := range trainingPlans {
+		res.TrainingPlans[i] = toTrainingPlanResponse(tp, nil)
+	}
+	for i, pw := range plannedWorkouts {
+		res.PlannedWorkouts[i] = model.AccountPlannedWorkout{
+			ID:             pw.ID,
+			Date:           pw.Date,
+			Workout:        pw.Workout,
+			Mileage:        pw.Mileage,
+			MileageUnit:    pw.MileageUnit,
+			Note:           pw.Note,
+			TrainingPlanId: pw.TrainingPlanId,
+			CreatedAt:      pw.CreatedAt,
+		}
+	}
+	return res, nil
+}
+
+// WriteAccountArchive はエクスポートしたデータを ZIP にする
+// account.json にすべてのデータを、各 CSV には取り込み（/import）と同じ列で記録・練習・専門種目を入れる
+func WriteAccountArchive(w io.Writer, export model.AccountExportResponse) error {
+	files := []struct {
+		name    string
+		columns []string
+		records [][]string
+	}{
+		{"vdots.csv", VdotCSVColumns, vdotCSVRecords(export.Vdots)},
+		{"workouts.csv", WorkoutCSVColumns, workoutCSVRecords(export.Workouts)},
+		{"specialty_events.csv", SpecialtyEventCSVColumns, specialtyEventCSVRecords(export.SpecialtyEvents)},
+	}
 
-func (uu *userUsecase) DeleteUser(userId uint) error {
-	if err := uu.ur.DeleteUser(userId); err != nil {
+	zw := zip.NewWriter(w)
+	fw, err := zw.Create("account.json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(fw)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
 		return err
 	}
-	return nil
+	for _, f := range files {
+		data, err := csvfile.Write(f.columns, f.records)
+		if err != nil {
+			return err
+		}
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
 }
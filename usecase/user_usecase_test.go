@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"errors"
+	"go_vdot_api/model"
+	"go_vdot_api/pkg/mailer"
+	"go_vdot_api/repository"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeUserRepository はメモリ上のユーザー（テストで使わないメソッドは埋め込んだ nil のインターフェースで panic する）
+type fakeUserRepository struct {
+	repository.IUserRepository
+	users []model.User
+}
+
+func (r *fakeUserRepository) GetUserByEmail(user *model.User, email string) error {
+	for _, u := range r.users {
+		if u.Email == email {
+			*user = u
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepository) GetUserByID(user *model.User, userId uint) error {
+	for _, u := range r.users {
+		if u.ID == userId {
+			*user = u
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserRepository) CreateUser(user *model.User) error {
+	user.ID = uint(len(r.users) + 1)
+	r.users = append(r.users, *user)
+	return nil
+}
+
+func (r *fakeUserRepository) DeleteUser(userId uint, auditLog *model.AuditLog) (model.UserRecordCounts, error) {
+	for i, u := range r.users {
+		if u.ID == userId {
+			r.users = append(r.users[:i], r.users[i+1:]...)
+			auditLog.CreatedAt = time.Now()
+			return model.UserRecordCounts{}, nil
+		}
+	}
+	return model.UserRecordCounts{}, gorm.ErrRecordNotFound
+}
+
+// fakeUserTokenRepository はメモリ上のメールで送るトークン
+type fakeUserTokenRepository struct {
+	tokens []model.UserToken
+}
+
+func (r *fakeUserTokenRepository) CreateUserToken(userToken *model.UserToken) error {
+	userToken.ID = uint(len(r.tokens) + 1)
+	r.tokens = append(r.tokens, *userToken)
+	return nil
+}
+
+func (r *fakeUserTokenRepository) GetUserToken(userToken *model.UserToken, purpose string, tokenHash string) error {
+	for _, t := range r.tokens {
+		if t.Purpose == purpose && t.TokenHash == tokenHash {
+			*userToken = t
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserTokenRepository) UseUserToken(userTokenId uint) error {
+	for i, t := range r.tokens {
+		if t.ID == userTokenId && t.UsedAt == nil {
+			now := time.Now()
+			r.tokens[i].UsedAt = &now
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *fakeUserTokenRepository) ExpireUserTokens(userId uint, purpose string) error {
+	for i, t := range r.tokens {
+		if t.UserId == userId && t.Purpose == purpose && t.UsedAt == nil {
+			r.tokens[i].ExpiresAt = time.Now()
+		}
+	}
+	return nil
+}
+
+func (r *fakeUserTokenRepository) GetUserTokens(userId uint) ([]model.UserToken, error) {
+	var tokens []model.UserToken
+	for _, t := range r.tokens {
+		if t.UserId == userId {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens, nil
+}
+
+var mailTokenPattern = regexp.MustCompile(`token=(\S+)`)
+
+// mailToken はメールの本文のリンクからトークンを取り出す
+func mailToken(t *testing.T, msg mailer.Message) string {
+	t.Helper()
+	m := mailTokenPattern.FindStringSubmatch(msg.Body)
+	if m == nil {
+		t.Fatalf("mail body has no token: %q", msg.Body)
+	}
+	token, err := url.QueryUnescape(m[1])
+	if err != nil {
+		t.Fatalf("mail token %q: %v", m[1], err)
+	}
+	return token
+}
+
+func TestDeleteUserWithoutPassword(t *testing.T) {
+	ur := &fakeUserRepository{users: []model.User{
+		{ID: 1, Email: "oauth@example.com"},
+		{ID: 2, Email: "other@example.com"},
+	}}
+	utr := &fakeUserTokenRepository{}
+	m := mailer.NewMemoryMailer()
+	uu := NewUserUsecase(ur, nil, nil, nil, nil, nil, nil, nil, nil, utr, nil, nil, nil, m)
+
+	if _, err := uu.DeleteUser(1, "guess", "", "127.0.0.1"); !errors.Is(err, ErrPasswordNotSet) {
+		t.Fatalf("password for an account without one: error = %v, want ErrPasswordNotSet", err)
+	}
+
+	if err := uu.SendAccountDeletionEmail(2); err != nil {
+		t.Fatalf("SendAccountDeletionEmail(2) error = %v", err)
+	}
+	if err := uu.SendAccountDeletionEmail(1); err != nil {
+		t.Fatalf("SendAccountDeletionEmail(1) error = %v", err)
+	}
+	msgs := m.Messages()
+	if len(msgs) != 2 || msgs[1].To != "oauth@example.com" {
+		t.Fatalf("messages = %+v", msgs)
+	}
+	otherToken, token := mailToken(t, msgs[0]), mailToken(t, msgs[1])
+
+	if _, err := uu.DeleteUser(1, "", otherToken, "127.0.0.1"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("another user's token: error = %v, want ErrInvalidToken", err)
+	}
+	res, err := uu.DeleteUser(1, "", token, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("DeleteUser error = %v", err)
+	}
+	if res.UserID != 1 || len(ur.users) != 1 || ur.users[0].ID != 2 {
+		t.Errorf("response = %+v, users = %+v, want only user 2 left", res, ur.users)
+	}
+	if _, err := uu.DeleteUser(2, "", otherToken, "127.0.0.1"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token used by another user: error = %v, want ErrInvalidToken", err)
+	}
+}
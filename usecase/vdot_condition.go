@@ -0,0 +1,53 @@
+package usecase
+
+// 暑さ・高地の補正の基準値
+const (
+	HeatThresholdTemperature float64 = 15      // これを超える気温（℃）からタイムが落ち始める
+	HeatPenaltyPerDegree     float64 = 0.003   // 1℃あたりのタイムの悪化率
+	AltitudeThreshold        float64 = 1000    // これを超える標高（m）からタイムが落ち始める
+	AltitudePenaltyPerMeter  float64 = 0.00003 // 標高1mあたりのタイムの悪化率（100mで0.3%）
+)
+
+// Conditions は走った（走る）環境。nil の項目は補正しない
+type Conditions struct {
+	Temperature *float64 // 気温（℃）
+	Elevation   *float64 // 標高（m）
+}
+
+func (c Conditions) IsSet() bool {
+	return c.Temperature != nil || c.Elevation != nil
+}
+
+// HeatPenalty は気温によるタイムの悪化率を返す（例：0.03 は3%遅くなる）
+func HeatPenalty(temperature *float64) float64 {
+	if temperature == nil || *temperature <= HeatThresholdTemperature {
+		return 0
+	}
+	return (*temperature - HeatThresholdTemperature) * HeatPenaltyPerDegree
+}
+
+// AltitudePenalty は標高によるタイムの悪化率を返す
+func AltitudePenalty(elevation *float64) float64 {
+	if elevation == nil || *elevation <= AltitudeThreshold {
+		return 0
+	}
+	return (*elevation - AltitudeThreshold) * AltitudePenaltyPerMeter
+}
+
+// ConditionFactor は環境によるタイムの倍率を返す（1.0 は補正なし）
+func ConditionFactor(c Conditions) float64 {
+	return (1 + HeatPenalty(c.Temperature)) * (1 + AltitudePenalty(c.Elevation))
+}
+
+// AdjustTimeToStandard は暑さ・高地で走ったタイムを平地・涼しい環境での相当タイムに換算する
+func AdjustTimeToStandard(timeInMinutes float64, c Conditions) float64 {
+	return timeInMinutes / ConditionFactor(c)
+}
+
+// AdjustPace はペース（分）を指定した環境で走る場合のペースに換算する
+func AdjustPace(pace float64, c Conditions) float64 {
+	if pace <= 0 {
+		return pace
+	}
+	return pace * ConditionFactor(c)
+}
@@ -1,31 +1,71 @@
 package usecase
 
 import (
+	"errors"
 	"fmt"
 	"go_vdot_api/model"
+	"go_vdot_api/pkg/csvfile"
 	"go_vdot_api/repository"
 	"go_vdot_api/validator"
 	"math"
 	"strconv"
 	"strings"
+	"time"
 
 	"go_vdot_api/pkg/logger"
 )
 
+// VDOT算出に使う記録の選び方
+const (
+	VdotRuleLatest = "latest" // 最新の記録
+	VdotRuleBest   = "best"   // 直近N日間で最もVDOTが高い記録
+	VdotRulePinned = "pinned" // ユーザーが固定した記録
+)
+
+// DefaultBestDays は rule=best で日数が指定されなかった場合の期間
+const DefaultBestDays = 90
+
 type IVdotUsecase interface {
 	CreateVdot(vdot model.Vdot) (model.VdotResponse, error)
 	GetVdot(userId uint) (model.VdotResponse, error)
+	GetVdotHistory(userId uint) ([]model.VdotHistoryResponse, error)
 	UpdateVdot(vdot model.Vdot, userId uint, vdotId uint) (model.VdotResponse, error)
-	GetUserVdotValue(userId uint) (map[string]interface{}, error)
+	PinVdot(userId uint, vdotId uint) error
+	UnpinVdot(userId uint) error
+	GetUserVdotResult(userId uint, rule string, days int, opts VdotOptions) (model.VdotValueResponse, error)
+	CalculateVdotResult(vdot model.Vdot, opts VdotOptions) (model.VdotValueResponse, error)
+	CalculateResultByVdotValue(userId uint, vdotValue float64, opts VdotOptions) (model.VdotValueResponse, error)
+	ImportVdotsCSV(userId uint, data []byte, allOrNothing bool) (model.CSVImportResponse, error)
+	ExportVdotsCSV(userId uint) ([]byte, error)
+}
+
+// VdotOptions はペースゾーンの出し方の指定
+type VdotOptions struct {
+	Training Conditions // 練習する環境（指定した場合はその環境向けに補正したペースを返す）
+	Profile  string     // ゾーン定義（空または "daniels" は組み込み、数値はユーザーの定義のID）
 }
 
 type vdotUsecase struct {
-	vr repository.IVdotRepository
-	vv validator.IVdotValidator
+	vr  repository.IVdotRepository
+	zpr repository.IZoneProfileRepository
+	vv  validator.IVdotValidator
 }
 
-func NewVdotUsecase(vr repository.IVdotRepository, vv validator.IVdotValidator) IVdotUsecase {
-	return &vdotUsecase{vr, vv}
+func NewVdotUsecase(vr repository.IVdotRepository, zpr repository.IZoneProfileRepository, vv validator.IVdotValidator) IVdotUsecase {
+	return &vdotUsecase{vr, zpr, vv}
+}
+
+func toVdotResponse(vdot model.Vdot) model.VdotResponse {
+	return model.VdotResponse{
+		ID:            vdot.ID,
+		DistanceValue: vdot.DistanceValue,
+		DistanceUnit:  vdot.DistanceUnit,
+		Time:          vdot.Time,
+		Elevation:     vdot.Elevation,
+		Temperature:   vdot.Temperature,
+		RecordedAt:    vdot.RecordedAt,
+		IsPinned:      vdot.IsPinned,
+	}
 }
 
 func (vu *vdotUsecase) CreateVdot(vdot model.Vdot) (model.VdotResponse, error) {
@@ -33,19 +73,17 @@ func (vu *vdotUsecase) CreateVdot(vdot model.Vdot) (model.VdotResponse, error) {
 		return model.VdotResponse{}, err
 	}
 
+	// 記録日が未指定の場合は登録日を記録日とする
+	if vdot.RecordedAt.IsZero() {
+		vdot.RecordedAt.Time = time.Now()
+	}
+	// 固定は専用のエンドポイントでのみ行う
+	vdot.IsPinned = false
+
 	if err := vu.vr.CreateVdot(&vdot); err != nil {
 		return model.VdotResponse{}, err
 	}
-	
-	resVdot := model.VdotResponse{
-		ID:            vdot.ID,
-		DistanceValue: vdot.DistanceValue,
-		DistanceUnit:  vdot.DistanceUnit,
-		Time:          vdot.Time,
-		Elevation:     vdot.Elevation,
-		Temperature:   vdot.Temperature,
-	}
-	return resVdot, nil
+	return toVdotResponse(vdot), nil
 }
 
 func (vu *vdotUsecase) GetVdot(userId uint) (model.VdotResponse, error) {
@@ -53,15 +91,27 @@ func (vu *vdotUsecase) GetVdot(userId uint) (model.VdotResponse, error) {
 	if err := vu.vr.GetVdot(&vdot, userId); err != nil {
 		return model.VdotResponse{}, err
 	}
-	resVdot := model.VdotResponse{
-		ID:            vdot.ID,
-		DistanceValue: vdot.DistanceValue,
-		DistanceUnit:  vdot.DistanceUnit,
-		Time:          vdot.Time,
-		Elevation:     vdot.Elevation,
-		Temperature:   vdot.Temperature,
+	return toVdotResponse(vdot), nil
+}
+
+func (vu *vdotUsecase) GetVdotHistory(userId uint) ([]model.VdotHistoryResponse, error) {
+	vdots, err := vu.vr.GetVdotHistory(userId)
+	if err != nil {
+		return nil, err
 	}
-	return resVdot, nil
+	resVdots := make([]model.VdotHistoryResponse, len(vdots))
+	for i, v := range vdots {
+		detail, err := ComputeVdot(v)
+		if err != nil {
+			return nil, err
+		}
+		resVdots[i] = model.VdotHistoryResponse{
+			VdotResponse: toVdotResponse(v),
+			Vdot:         detail.Vdot,
+			AdjustedVdot: detail.AdjustedVdot,
+		}
+	}
+	return resVdots, nil
 }
 
 func (vu *vdotUsecase) UpdateVdot(vdot model.Vdot, userId uint, vdotId uint) (model.VdotResponse, error) {
@@ -72,15 +122,57 @@ func (vu *vdotUsecase) UpdateVdot(vdot model.Vdot, userId uint, vdotId uint) (mo
 	if err := vu.vr.UpdateVdot(&vdot, userId, vdotId); err != nil {
 		return model.VdotResponse{}, err
 	}
-	resVdot := model.VdotResponse{
-		ID:            vdot.ID,
-		DistanceValue: vdot.DistanceValue,
-		DistanceUnit:  vdot.DistanceUnit,
-		Time:          vdot.Time,
-		Elevation:     vdot.Elevation,
-		Temperature:   vdot.Temperature,
+	return toVdotResponse(vdot), nil
+}
+
+func (vu *vdotUsecase) PinVdot(userId uint, vdotId uint) error {
+	return vu.vr.PinVdot(userId, vdotId)
+}
+
+func (vu *vdotUsecase) UnpinVdot(userId uint) error {
+	return vu.vr.UnpinVdot(userId)
+}
+
+// selectVdot はルールに従ってVDOT算出に使う記録を1件選ぶ
+func selectVdot(vr repository.IVdotRepository, userId uint, rule string, days int) (model.Vdot, error) {
+	vdot := model.Vdot{}
+	switch rule {
+	case "", VdotRuleLatest:
+		if err := vr.GetVdot(&vdot, userId); err != nil {
+			return model.Vdot{}, err
+		}
+	case VdotRulePinned:
+		if err := vr.GetPinnedVdot(&vdot, userId); err != nil {
+			return model.Vdot{}, err
+		}
+	case VdotRuleBest:
+		if days <= 0 {
+			days = DefaultBestDays
+		}
+		since := time.Now().AddDate(0, 0, -days)
+		vdots, err := vr.GetVdotsSince(userId, since)
+		if err != nil {
+			return model.Vdot{}, err
+		}
+		if len(vdots) == 0 {
+			return model.Vdot{}, fmt.Errorf("no vdot records in the last %d days", days)
+		}
+		// 暑さ・高地を補正したVDOTで比較する
+		best := -1.0
+		for _, v := range vdots {
+			detail, err := ComputeVdot(v)
+			if err != nil {
+				return model.Vdot{}, err
+			}
+			if detail.PreciseVdot > best {
+				best = detail.PreciseVdot
+				vdot = v
+			}
+		}
+	default:
+		return model.Vdot{}, errors.New("invalid rule. Use latest, best or pinned")
 	}
-	return resVdot, nil
+	return vdot, nil
 }
 
 const COEFF1 float64 = 0.1894393
@@ -88,61 +180,184 @@ const COEFF2 float64 = -0.012788
 const COEFF3 float64 = 0.2989558
 const COEFF4 float64 = -0.1932605
 
-func (vu *vdotUsecase) GetUserVdotValue(userId uint) (map[string]interface{}, error) {
-	// ユーザーIDに基づいてVDOT値を取得
-	vdot := model.Vdot{}
-	if err := vu.vr.GetVdot(&vdot, userId); err != nil {
-		return nil, fmt.Errorf("vdot data not found: %v", err)
+// ペースゾーンの基準とするレース距離（m）
+const zoneReferenceDistance float64 = 5000
+
+func (vu *vdotUsecase) GetUserVdotResult(userId uint, rule string, days int, opts VdotOptions) (model.VdotValueResponse, error) {
+	zoneProfile, err := resolveZoneProfile(vu.zpr, userId, opts.Profile)
+	if err != nil {
+		return model.VdotValueResponse{}, err
 	}
-	logger.Info("vdot", "vdot", vdot)
 
-	// 距離と時間の変換
-	distance, err := DistanceUnitConvert(vdot)
+	// ルールに基づいてVDOT算出に使う記録を取得
+	vdot, err := selectVdot(vu.vr, userId, rule, days)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert distance: %v", err)
+		return model.VdotValueResponse{}, fmt.Errorf("vdot data not found: %v", err)
 	}
-	logger.Info("distance", "distance", distance)
+	logger.Info("vdot: %+v", vdot)
 
-	timeInMinutes, err := TimeUnitConvert(vdot)
+	return buildVdotResult(vdot, opts.Training, zoneProfile)
+}
+
+// CalculateVdotResult は記録を保存せずにVDOT・ペースゾーン・予測タイムを算出する
+func (vu *vdotUsecase) CalculateVdotResult(vdot model.Vdot, opts VdotOptions) (model.VdotValueResponse, error) {
+	if err := vu.vv.VdotValidate(vdot); err != nil {
+		return model.VdotValueResponse{}, err
+	}
+	zoneProfile, err := resolveZoneProfile(vu.zpr, vdot.UserId, opts.Profile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert time: %v", err)
-	}
-	logger.Info("timeInMinutes", "timeInMinutes", timeInMinutes)
-
-	// 各種計算
-    velocity := CalculateVelocity(distance, timeInMinutes)
-    vo2max := CalculateVo2Max(timeInMinutes)
-    vdotValue := CalculateVdot(vo2max, velocity)
-    paceZones := CalculatePaceZones(velocity)
-    raceTimes := PredictRaceTimes(vdot)
-	
-	// 結果をマップにまとめる
+		return model.VdotValueResponse{}, err
+	}
+	return buildVdotResult(vdot, opts.Training, zoneProfile)
+}
+
+// VDOTの指定範囲（コーチが直接指定する場合）
+const (
+	MinVdotValue float64 = 20
+	MaxVdotValue float64 = 90
+)
+
+// CalculateResultByVdotValue はVDOTの値から直接ペースゾーンと予測タイムを算出する
+func (vu *vdotUsecase) CalculateResultByVdotValue(userId uint, vdotValue float64, opts VdotOptions) (model.VdotValueResponse, error) {
+	if vdotValue < MinVdotValue || vdotValue > MaxVdotValue {
+		return model.VdotValueResponse{}, fmt.Errorf("vdot must be between %.0f and %.0f", MinVdotValue, MaxVdotValue)
+	}
+	zoneProfile, err := resolveZoneProfile(vu.zpr, userId, opts.Profile)
+	if err != nil {
+		return model.VdotValueResponse{}, err
+	}
+
+	return model.VdotValueResponse{
+		Vdot:               RoundVdot(vdotValue),
+		AdjustedVdot:       RoundVdot(vdotValue),
+		PaceZones:          CalculatePaceZoneList(vdotValue, opts.Training, zoneProfile),
+		RacePredictions:    PredictRaces(vdotValue),
+		TrainingConditions: toTrainingConditionsResponse(opts.Training),
+	}, nil
+}
+
+func buildVdotResult(vdot model.Vdot, training Conditions, zoneProfile model.ZoneProfile) (model.VdotValueResponse, error) {
+	detail, err := ComputeVdot(vdot)
+	if err != nil {
+		return model.VdotValueResponse{}, err
+	}
+	logger.Info("vdot detail: %+v", detail)
+
+	record := toVdotResponse(vdot)
+	// ペース・予測タイムは暑さ・高地を補正したVDOTから算出する
+	return model.VdotValueResponse{
+		Record:             &record,
+		Vdot:               detail.Vdot,
+		AdjustedVdot:       detail.AdjustedVdot,
+		Vo2:                &detail.Vo2,
+		PercentVo2max:      &detail.PercentVo2max,
+		Velocity:           &detail.Velocity,
+		PaceZones:          CalculatePaceZoneList(detail.PreciseVdot, training, zoneProfile),
+		RacePredictions:    PredictRaces(detail.PreciseVdot),
+		TrainingConditions: toTrainingConditionsResponse(training),
+	}, nil
+}
+
+func toTrainingConditionsResponse(training Conditions) *model.TrainingConditionsResponse {
+	if !training.IsSet() {
+		return nil
+	}
+	return &model.TrainingConditionsResponse{
+		Temperature: training.Temperature,
+		Elevation:   training.Elevation,
+		PaceFactor:  math.Round(ConditionFactor(training)*1000) / 1000,
+	}
+}
+
+// LegacyVdotValue は /api（v1）のレスポンス形式（キー名の混在したマップ）に変換する
+func LegacyVdotValue(res model.VdotValueResponse) map[string]interface{} {
 	data := map[string]interface{}{
-        "id":            vdot.ID,
-        "distanceValue": vdot.DistanceValue,
-        "distanceUnit":  vdot.DistanceUnit,
-        "time":          vdot.Time,
-        "elevation":     vdot.Elevation,
-        "temperature":   vdot.Temperature,
-        "pace_zones":    paceZones,
-        "VDOT":          vdotValue,
-        "race_times":    raceTimes,		
+		"pace_zones": LegacyPaceZones(res.PaceZones),
+		"VDOT":       res.Vdot,
+		"race_times": LegacyRaceTimes(res.RacePredictions),
+	}
+	if res.Record != nil {
+		data["id"] = res.Record.ID
+		data["distanceValue"] = res.Record.DistanceValue
+		data["distanceUnit"] = res.Record.DistanceUnit
+		data["time"] = res.Record.Time
+		data["elevation"] = res.Record.Elevation
+		data["temperature"] = res.Record.Temperature
+		data["recorded_at"] = res.Record.RecordedAt
+		data["is_pinned"] = res.Record.IsPinned
+		data["adjusted_vdot"] = res.AdjustedVdot
+		data["vo2"] = res.Vo2
+		data["percent_vo2max"] = res.PercentVo2max
+		data["velocity"] = res.Velocity
 	}
+	if res.TrainingConditions != nil {
+		data["training_conditions"] = map[string]interface{}{
+			"temperature": res.TrainingConditions.Temperature,
+			"elevation":   res.TrainingConditions.Elevation,
+			"pace_factor": res.TrainingConditions.PaceFactor,
+		}
+	}
+	return data
+}
 
-	return data, nil
+// VdotDetail はVDOT算出の途中で得られる生理学的な値
+type VdotDetail struct {
+	Vdot          float64 // 小数第1位に丸めたVDOT（実際のタイムから算出）
+	AdjustedVdot  float64 // 暑さ・高地を補正した小数第1位のVDOT
+	PreciseVdot   float64 // 補正後の丸める前のVDOT（ペース・予測タイムの算出に使う）
+	Vo2           float64 // 酸素摂取量（ml/kg/分）
+	PercentVo2max float64 // レース時間を維持できる%VO2max（例：0.95）
+	Velocity      float64 // 速度（m/分）
+}
+
+// CalculateVdotDetail は距離（m）とタイム（分）、走った環境からVDOTを算出する
+func CalculateVdotDetail(distance float64, timeInMinutes float64, race Conditions) VdotDetail {
+	velocity := CalculateVelocity(distance, timeInMinutes)
+	vo2 := CalculateVo2(velocity)
+	vo2max := CalculateVo2Max(timeInMinutes)
+
+	// 平地・涼しい環境で走った場合の相当タイムから補正後のVDOTを求める
+	standardTime := AdjustTimeToStandard(timeInMinutes, race)
+	preciseVdot := CalculateVo2(CalculateVelocity(distance, standardTime)) / CalculateVo2Max(standardTime)
+
+	return VdotDetail{
+		Vdot:          CalculateVdot(vo2max, velocity),
+		AdjustedVdot:  RoundVdot(preciseVdot),
+		PreciseVdot:   preciseVdot,
+		Vo2:           math.Round(vo2*100) / 100,
+		PercentVo2max: math.Round(vo2max*10000) / 10000,
+		Velocity:      math.Round(velocity*100) / 100,
+	}
+}
+
+// ComputeVdot は1件の記録からVDOTを算出する（elevation・temperature が設定されていれば補正する）
+func ComputeVdot(vdot model.Vdot) (VdotDetail, error) {
+	distance, err := DistanceUnitConvert(vdot)
+	if err != nil {
+		return VdotDetail{}, fmt.Errorf("failed to convert distance: %v", err)
+	}
+	timeInMinutes, err := TimeUnitConvert(vdot)
+	if err != nil {
+		return VdotDetail{}, fmt.Errorf("failed to convert time: %v", err)
+	}
+	if distance <= 0 || timeInMinutes <= 0 {
+		return VdotDetail{}, errors.New("distance and time must be greater than 0")
+	}
+	race := Conditions{Temperature: vdot.Temperature, Elevation: vdot.Elevation}
+	return CalculateVdotDetail(distance, timeInMinutes, race), nil
 }
 
 func TimeUnitConvert(vdot model.Vdot) (float64, error) {
 	tokens := strings.Split(vdot.Time, ":")
 	if len(tokens) != 3 {
-		logger.Error("Time parsing failed", "value", vdot.Time)
+		logger.Error("Time parsing failed: %s", vdot.Time)
 		return 0, fmt.Errorf("invalid time format: %s", vdot.Time)
 	}
 	hh, err1 := strconv.Atoi(tokens[0])
 	mm, err2 := strconv.Atoi(tokens[1])
 	ss, err3 := strconv.Atoi(tokens[2])
 	if err1 != nil || err2 != nil || err3 != nil {
-		logger.Error("Time conversion failed", "hh", tokens[0], "mm", tokens[1], "ss", tokens[2])
+		logger.Error("Time conversion failed: hh=%s mm=%s ss=%s", tokens[0], tokens[1], tokens[2])
 		return 0, fmt.Errorf("invalid time values")
 	}
 
@@ -150,7 +365,6 @@ func TimeUnitConvert(vdot model.Vdot) (float64, error) {
 	return totalMinutes, nil
 }
 
-
 func DistanceUnitConvert(vdot model.Vdot) (float64, error) {
 	distance_value := vdot.DistanceValue
 	if distance_value < 0 {
@@ -163,7 +377,7 @@ func DistanceUnitConvert(vdot model.Vdot) (float64, error) {
 	} else if distance_unit == "mile" {
 		return distance_value * 1609.34, nil
 	} else if distance_unit == "m" {
-		return distance_value / 1000, nil
+		return distance_value, nil
 	}
 
 	return distance_value, nil
@@ -175,73 +389,99 @@ func CalculateVelocity(distance float64, timeInMinutes float64) float64 {
 }
 
 func CalculateVo2Max(time_in_minutes float64) float64 {
-	VO2max_percentage := 0.8 + COEFF1 * math.Exp(COEFF2 * time_in_minutes) + COEFF3 * math.Exp(COEFF4 * time_in_minutes)
-    return VO2max_percentage
+	VO2max_percentage := 0.8 + COEFF1*math.Exp(COEFF2*time_in_minutes) + COEFF3*math.Exp(COEFF4*time_in_minutes)
+	return VO2max_percentage
+}
+
+// CalculateVo2 は速度（m/分）で走るときの酸素摂取量（ml/kg/分）を返す
+func CalculateVo2(velocity float64) float64 {
+	return -4.6 + (0.182258 * velocity) + (0.000104 * math.Pow(velocity, 2))
 }
 
 func CalculateVdot(vo2max float64, velocity float64) float64 {
-	vo2 := -4.6 + (0.182258 * velocity) + (0.000104 * math.Pow(velocity, 2))
-	vdot := math.Round(vo2 / vo2max)
+	vdot := RoundVdot(CalculateVo2(velocity) / vo2max)
 	return vdot
 }
 
-func CalculatePaceZones(velocity float64) []map[string][]map[string]map[string]string {
-    zoneOrder := []string{"E", "M", "T", "I", "R"}
-    distanceOrder := []string{"1mi", "1Km", "1200m", "800m", "600m", "400m", "300m", "200m"}
-
-    zones := map[string][2]float64{
-        "E": {70, 77},
-        "M": {88, 0},
-        "T": {92.5, 0},
-        "I": {100.5, 0},
-        "R": {108.25, 0},
-    }
-
-    distances := map[string]float64{
-        "1mi":   1609.34,
-        "1Km":   1000,
-        "1200m": 1200,
-        "800m":  800,
-        "600m":  600,
-        "400m":  400,
-        "300m":  300,
-        "200m":  200,
-    }
-
-    var orderedZones []map[string][]map[string]map[string]string
-
-    for _, zone := range zoneOrder {
-        bounds := zones[zone]
-        lowerBound := bounds[0]
-        upperBound := bounds[1]
-
-        // 距離ごとのデータを順序付きで保持
-        var orderedDistances []map[string]map[string]string
-
-        for _, distance := range distanceOrder {
-            distanceM := distances[distance]
-            lowerPace := CalculatePace(velocity, lowerBound, distanceM)
-            var upperPace float64
-            if upperBound != 0 {
-                upperPace = CalculatePace(velocity, upperBound, distanceM)
-            }
-
-            // このdistanceだけのデータを map としてまとめて配列に追加
-            paceData := map[string]map[string]string{
-                distance: {
-                    "lower_pace": FormatPace(lowerPace),
-                    "upper_pace": FormatPace(upperPace),
-                },
-            }
-            orderedDistances = append(orderedDistances, paceData)
-        }
-
-        orderedZones = append(orderedZones, map[string][]map[string]map[string]string{
-            zone: orderedDistances,
-        })
-    }
-
-    return orderedZones
+// RoundVdot はVDOTを小数第1位に丸める
+func RoundVdot(vdot float64) float64 {
+	return math.Round(vdot*10) / 10
+}
+
+// CalculatePaceZoneList はVDOTからゾーン定義に従ってペースゾーンを算出する。
+// ゾーンの%は、VDOTから逆算した5Kレースの速度に対する割合。
+// training を指定した場合は、その気温・標高で練習するためのペースに補正する。
+func CalculatePaceZoneList(vdotValue float64, training Conditions, zoneProfile model.ZoneProfile) []model.PaceZone {
+	velocity := ZoneReferenceVelocity(vdotValue, training)
+
+	orderedZones := make([]model.PaceZone, 0, len(zoneProfile.Zones))
+
+	for _, zone := range zoneProfile.Zones {
+		// 距離ごとのデータを順序付きで保持
+		paces := make([]model.ZonePace, 0, len(zoneProfile.Distances))
+
+		for _, distance := range zoneProfile.Distances {
+			lowerPace := CalculatePace(velocity, zone.Lower, distance.Meters)
+			zonePace := model.ZonePace{
+				Distance:       distance.Label,
+				DistanceMeters: distance.Meters,
+				LowerPace:      FormatPace(lowerPace),
+				LowerSeconds:   PaceSeconds(lowerPace),
+			}
+			if zone.Upper != nil {
+				upperPace := CalculatePace(velocity, *zone.Upper, distance.Meters)
+				upperPaceStr := FormatPace(upperPace)
+				upperSeconds := PaceSeconds(upperPace)
+				zonePace.UpperPace = &upperPaceStr
+				zonePace.UpperSeconds = &upperSeconds
+			}
+			paces = append(paces, zonePace)
+		}
+
+		orderedZones = append(orderedZones, model.PaceZone{Zone: zone.Zone, Paces: paces})
+	}
+
+	return orderedZones
+}
+
+// ZoneReferenceVelocity はゾーンの%の基準となる速度（m/分）を返す
+func ZoneReferenceVelocity(vdotValue float64, training Conditions) float64 {
+	velocity := CalculateVelocity(zoneReferenceDistance, SolveRaceTime(vdotValue, zoneReferenceDistance))
+	return velocity / ConditionFactor(training)
+}
+
+// CalculatePaceZones は組み込みのゾーン定義で v1 形式のペースゾーンを返す
+func CalculatePaceZones(vdotValue float64, training Conditions) []map[string][]map[string]map[string]string {
+	return LegacyPaceZones(CalculatePaceZoneList(vdotValue, training, DefaultZoneProfile()))
+}
+
+// LegacyPaceZones は v1 のペースゾーン形式（[{ゾーン: [{距離: {lower_pace, upper_pace}}]}]）に変換する
+func LegacyPaceZones(zones []model.PaceZone) []map[string][]map[string]map[string]string {
+	var orderedZones []map[string][]map[string]map[string]string
+	for _, zone := range zones {
+		var orderedDistances []map[string]map[string]string
+		for _, pace := range zone.Paces {
+			upperPace := ""
+			if pace.UpperPace != nil {
+				upperPace = *pace.UpperPace
+			}
+			orderedDistances = append(orderedDistances, map[string]map[string]string{
+				pace.Distance: {
+					"lower_pace": pace.LowerPace,
+					"upper_pace": upperPace,
+				},
+			})
+		}
+		orderedZones = append(orderedZones, map[string][]map[string]map[string]string{
+			zone.Zone: orderedDistances,
+		})
+	}
+	return orderedZones
+}
+
+// PaceSeconds はペース（分）を秒に変換する（小数第1位まで）
+func PaceSeconds(pace float64) float64 {
+	return math.Round(pace*600) / 10
 }
 
 func CalculatePace(velocity, vo2maxPercentage, distance float64) float64 {
@@ -266,17 +506,45 @@ func PacePerKm(timeMinutes, distance float64) string {
 	return fmt.Sprintf("%02d:%02d /km", minutes, seconds)
 }
 
-
 type RaceTime struct {
 	Race          string `json:"race"`
 	PredictedTime string `json:"predicted_time"`
 	PacePerKm     string `json:"pace_per_km"`
 }
 
-func PredictRaceTimes(vdot model.Vdot) []RaceTime {
-	targetTime, _ := TimeUnitConvert(vdot)
-	targetDistance, _ := DistanceUnitConvert(vdot)
+// 予測タイムを求める際の探索範囲（速度 m/分）
+const (
+	minPredictVelocity float64 = 50
+	maxPredictVelocity float64 = 1000
+)
+
+// SolveRaceTime は距離（m）をVDOTどおりの強度で走ったときのタイム（分）を求める。
+// CalculateVo2 / CalculateVo2Max がVDOTと一致するタイムを二分法で探索するため、
+// 求めたタイムから逆算したVDOTは元のVDOTと一致する。
+func SolveRaceTime(vdotValue float64, distance float64) float64 {
+	// タイムが長いほど算出されるVDOTは小さくなる
+	lo := distance / maxPredictVelocity
+	hi := distance / minPredictVelocity
+	for i := 0; i < 100; i++ {
+		mid := (lo + hi) / 2
+		if CalculateVo2(distance/mid)/CalculateVo2Max(mid) > vdotValue {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+func FormatRaceTime(timeInMinutes float64) string {
+	totalSeconds := int(math.Round(timeInMinutes * 60))
+	hours := totalSeconds / 3600
+	minutes := (totalSeconds % 3600) / 60
+	seconds := totalSeconds % 60
+	return fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
+}
 
+func PredictRaces(vdotValue float64) []model.RacePrediction {
 	type Distance struct {
 		Race     string
 		Distance float64
@@ -300,40 +568,124 @@ func PredictRaceTimes(vdot model.Vdot) []RaceTime {
 		{"1500m", 1500},
 	}
 
-	var result []RaceTime
+	result := make([]model.RacePrediction, 0, len(distances))
 
 	for _, d := range distances {
-		var predictedTimeMinutes float64
-		if d.Distance == targetDistance {
-			predictedTimeMinutes = targetTime
-		} else {
-			predictedTimeMinutes = targetTime * math.Pow(d.Distance/targetDistance, 1.06)
-		}
+		predictedTimeMinutes := SolveRaceTime(vdotValue, d.Distance)
+		paceMinutes := predictedTimeMinutes / (d.Distance / 1000)
+
+		result = append(result, model.RacePrediction{
+			Race:             d.Race,
+			DistanceMeters:   d.Distance,
+			PredictedTime:    FormatRaceTime(predictedTimeMinutes),
+			PredictedSeconds: math.Round(predictedTimeMinutes * 60),
+			PacePerKm:        PacePerKm(predictedTimeMinutes, d.Distance),
+			PacePerKmSeconds: PaceSeconds(paceMinutes),
+		})
+	}
 
-		hours := int(predictedTimeMinutes / 60)
-		remainder := math.Mod(predictedTimeMinutes, 60)
-		minutes := int(remainder)
-		seconds := int(math.Round((remainder - float64(minutes)) * 60))
+	return result
+}
+
+// PredictRaceTimes は v1 形式の予測タイムを返す
+func PredictRaceTimes(vdotValue float64) []RaceTime {
+	return LegacyRaceTimes(PredictRaces(vdotValue))
+}
 
-		if seconds >= 60 {
-			seconds -= 60
-			minutes++
+func LegacyRaceTimes(predictions []model.RacePrediction) []RaceTime {
+	var result []RaceTime
+	for _, p := range predictions {
+		result = append(result, RaceTime{
+			Race:          p.Race,
+			PredictedTime: p.PredictedTime,
+			PacePerKm:     p.PacePerKm,
+		})
+	}
+	return result
+}
+
+// 記録（レース・記録会）の CSV の列（model.Vdot のフィールドと同じ名前）
+// recorded_at: YYYY-MM-DD, distance_unit: km, mile, m, time: HH:MM:SS, elevation（m）・temperature（℃）は空でも可
+// 固定（is_pinned）は1件のみのため取り込まない
+var VdotCSVColumns = []string{"recorded_at", "distance_value", "distance_unit", "time", "elevation", "temperature"}
+
+var vdotCSVRequired = []string{"recorded_at", "distance_value", "distance_unit", "time"}
+
+// ImportVdotsCSV は CSV の行ごとに記録をチェックして保存し、行ごとの結果を返す
+func (vu *vdotUsecase) ImportVdotsCSV(userId uint, data []byte, allOrNothing bool) (model.CSVImportResponse, error) {
+	rows, err := csvfile.Read(data, VdotCSVColumns, vdotCSVRequired)
+	if err != nil {
+		return model.CSVImportResponse{}, err
+	}
+	parse := func(row csvfile.Row) (model.Vdot, error) {
+		vdot, err := vdotFromCSV(row)
+		if err != nil {
+			return model.Vdot{}, err
 		}
-		if minutes >= 60 {
-			minutes -= 60
-			hours++
+		vdot.UserId = userId
+		if err := vu.vv.VdotValidate(vdot); err != nil {
+			return model.Vdot{}, err
 		}
+		return vdot, nil
+	}
+	return importCSVRows(rows, allOrNothing, parse, vu.vr.CreateVdot, vu.vr.CreateVdots)
+}
 
-		formattedTime := fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
-		pacePerKm := predictedTimeMinutes / (d.Distance / 1000)
-		formattedPace := fmt.Sprintf("%02d:%02d /km", int(pacePerKm), int((pacePerKm-float64(int(pacePerKm)))*60))
+func vdotFromCSV(row csvfile.Row) (model.Vdot, error) {
+	recordedAt, err := csvDate(row, "recorded_at")
+	if err != nil {
+		return model.Vdot{}, err
+	}
+	if recordedAt.IsZero() {
+		return model.Vdot{}, errors.New("recorded_at is required")
+	}
+	distanceValue, err := csvFloat(row, "distance_value")
+	if err != nil {
+		return model.Vdot{}, err
+	}
+	elevation, err := csvOptionalFloat(row, "elevation")
+	if err != nil {
+		return model.Vdot{}, err
+	}
+	temperature, err := csvOptionalFloat(row, "temperature")
+	if err != nil {
+		return model.Vdot{}, err
+	}
+	return model.Vdot{
+		DistanceValue: distanceValue,
+		DistanceUnit:  row.Get("distance_unit"),
+		Time:          row.Get("time"),
+		Elevation:     elevation,
+		Temperature:   temperature,
+		RecordedAt:    recordedAt,
+	}, nil
+}
 
-		result = append(result, RaceTime{
-			Race:          d.Race,
-			PredictedTime: formattedTime,
-			PacePerKm:     formattedPace,
-		})
+// ExportVdotsCSV は記録を記録日の古い順に CSV にする
+func (vu *vdotUsecase) ExportVdotsCSV(userId uint) ([]byte, error) {
+	vdots, err := vu.vr.GetVdotHistory(userId)
+	if err != nil {
+		return nil, err
 	}
+	// 記録一覧は新しい順のため逆順にする
+	resVdots := make([]model.VdotResponse, len(vdots))
+	for i, v := range vdots {
+		resVdots[len(vdots)-1-i] = toVdotResponse(v)
+	}
+	return csvfile.Write(VdotCSVColumns, vdotCSVRecords(resVdots))
+}
 
-	return result
+func vdotCSVRecords(vdots []model.VdotResponse) [][]string {
+	records := make([][]string, len(vdots))
+	for i, v := range vdots {
+		records[i] = []string{
+			formatCSVDate(v.RecordedAt),
+			strconv.FormatFloat(v.DistanceValue, 'f', -1, 64),
+			v.DistanceUnit,
+			v.Time,
+			formatCSVFloat(v.Elevation),
+			formatCSVFloat(v.Temperature),
+		}
+	}
+	return records
 }
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"fmt"
+	"go_vdot_api/model"
+	"math"
+	"testing"
+)
+
+// parseClock は "H:MM:SS" を秒に変換する
+func parseClock(t *testing.T, s string) float64 {
+	t.Helper()
+	var h, m, sec int
+	if _, err := fmt.Sscanf(s, "%d:%d:%d", &h, &m, &sec); err != nil {
+		t.Fatalf("invalid time %q: %v", s, err)
+	}
+	return float64(h*3600 + m*60 + sec)
+}
+
+// Daniels' Running Formula の VDOT 表のタイム
+var danielsTable = []struct {
+	vdot  float64
+	times map[float64]string // 距離（m）→ タイム
+}{
+	{30, map[float64]string{1500: "0:08:30", 5000: "0:30:40", 10000: "1:03:46", 21097.5: "2:21:04", 42195: "4:49:17"}},
+	{40, map[float64]string{1500: "0:06:35", 5000: "0:24:08", 10000: "0:50:03", 21097.5: "1:50:59", 42195: "3:49:45"}},
+	{50, map[float64]string{1500: "0:05:24", 5000: "0:19:57", 10000: "0:41:21", 21097.5: "1:31:35", 42195: "3:10:49"}},
+	{60, map[float64]string{1500: "0:04:35", 5000: "0:17:03", 10000: "0:35:22", 21097.5: "1:18:09", 42195: "2:43:25"}},
+	{70, map[float64]string{1500: "0:04:00", 5000: "0:14:55", 10000: "0:31:00", 21097.5: "1:08:21", 42195: "2:23:10"}},
+	{85, map[float64]string{1500: "0:03:23", 5000: "0:12:37", 10000: "0:26:19", 21097.5: "0:57:50", 42195: "2:01:10"}},
+}
+
+// 表との差の許容範囲（秒/km）
+const danielsToleranceSecondsPerKm = 2.0
+
+func TestSolveRaceTimeMatchesDanielsTable(t *testing.T) {
+	for _, row := range danielsTable {
+		for distance, want := range row.times {
+			got := SolveRaceTime(row.vdot, distance) * 60
+			wantSeconds := parseClock(t, want)
+			diffPerKm := math.Abs(got-wantSeconds) / (distance / 1000)
+			if diffPerKm > danielsToleranceSecondsPerKm {
+				t.Errorf("VDOT %.0f %.1fm: got %s, want %s (%.2f s/km off)",
+					row.vdot, distance, FormatRaceTime(got/60), want, diffPerKm)
+			}
+		}
+	}
+}
+
+// 予測タイムは秒単位に丸められるため、1マイル前後の短い距離では ±0.5秒で VDOT が 0.2 程度ずれる
+const roundTripVdotTolerance = 0.25
+
+func TestPredictRacesRoundTrip(t *testing.T) {
+	for _, row := range danielsTable {
+		for _, p := range PredictRaces(row.vdot) {
+			detail, err := ComputeVdot(model.Vdot{DistanceValue: p.DistanceMeters, DistanceUnit: "m", Time: p.PredictedTime})
+			if err != nil {
+				t.Fatalf("VDOT %.0f %s: %v", row.vdot, p.Race, err)
+			}
+			if math.Abs(detail.PreciseVdot-row.vdot) > roundTripVdotTolerance {
+				t.Errorf("VDOT %.0f %s (%s): ComputeVdot = %.2f", row.vdot, p.Race, p.PredictedTime, detail.PreciseVdot)
+			}
+		}
+	}
+}
@@ -1,29 +1,73 @@
 package usecase
 
 import (
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"go_vdot_api/model"
+	"go_vdot_api/pkg"
+	"go_vdot_api/pkg/activityfile"
+	"go_vdot_api/pkg/csvfile"
 	"go_vdot_api/pkg/logger"
+	"go_vdot_api/pkg/workoutparser"
 	"go_vdot_api/repository"
 	"go_vdot_api/validator"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
 )
 
 type IWorkoutUsecase interface {
 	CreateWorkout(workout model.Workout) (model.WorkoutResponse, error)
 	GetWorkoutPerMonth(userId uint, year int, month int) ([]model.WorkoutResponse, error)
+	GetWorkouts(userId uint, from time.Time, to time.Time, cursor string, sort string, limit int) (model.WorkoutListResponse, error)
+	GetWorkoutById(userId uint, workoutId uint) (model.WorkoutResponse, error)
 	UpdateWorkout(workout model.Workout, userId uint, workoutId uint) (model.WorkoutResponse, error)
+	DeleteWorkout(userId uint, workoutId uint) error
+	AnalyzeWorkout(userId uint, workoutId uint, rule string, days int, opts VdotOptions) (model.WorkoutAnalysisResponse, error)
+	GetWorkoutStats(userId uint, from time.Time, to time.Time, unit string) (model.WorkoutStatsResponse, error)
+	ImportWorkout(userId uint, filename string, data []byte, opts WorkoutImportOptions) (model.WorkoutImportResponse, error)
+	ImportWorkoutsCSV(userId uint, data []byte, allOrNothing bool) (model.CSVImportResponse, error)
+	ExportWorkoutsCSV(userId uint, from time.Time, to time.Time) ([]byte, error)
 }
 
 type workoutUsecase struct {
-	wr repository.IWorkoutRepository
-	wv validator.IWorkoutValidator
+	wr  repository.IWorkoutRepository
+	vr  repository.IVdotRepository
+	zpr repository.IZoneProfileRepository
+	wv  validator.IWorkoutValidator
+}
+
+func NewWorkoutUsecase(wr repository.IWorkoutRepository, vr repository.IVdotRepository, zpr repository.IZoneProfileRepository, wv validator.IWorkoutValidator) IWorkoutUsecase {
+	return &workoutUsecase{wr, vr, zpr, wv}
 }
 
-func NewWorkoutUsecase(wr repository.IWorkoutRepository, wv validator.IWorkoutValidator) IWorkoutUsecase {
-	return &workoutUsecase{wr, wv}
+// toWorkoutResponse は練習内容を解析した結果を含めたレスポンスを作る
+func toWorkoutResponse(w model.Workout) model.WorkoutResponse {
+	// 表記のチェックを入れる前に保存された練習内容は解析できないことがあるため、その場合は null
+	segments, err := workoutparser.Parse(w.Workout)
+	if err != nil {
+		segments = nil
+	}
+	return model.WorkoutResponse{
+		ID:            w.ID,
+		Date:          w.Date,
+		StartTime:     w.StartTime,
+		Workout:       w.Workout,
+		LapTime:       w.LapTime,
+		Mileage:       w.Mileage,
+		MileageUnit:   w.MileageUnit,
+		Weather:       w.Weather,
+		ElevationGain: w.ElevationGain,
+		Segments:      segments,
+	}
 }
 
 func (wu *workoutUsecase) CreateWorkout(workout model.Workout) (model.WorkoutResponse, error) {
-	logger.Info("workout", "workout", workout)
+	logger.Info("workout: %+v", workout)
 	if err := wu.wv.WorkoutValidate(workout); err != nil {
 		return model.WorkoutResponse{}, err
 	}
@@ -32,11 +76,7 @@ func (wu *workoutUsecase) CreateWorkout(workout model.Workout) (model.WorkoutRes
 		return model.WorkoutResponse{}, err
 	}
 
-	resWorkout := model.WorkoutResponse{
-		ID:   workout.ID,
-		Date: workout.Date,
-	}
-	return resWorkout, nil
+	return toWorkoutResponse(workout), nil
 }
 
 func (wu *workoutUsecase) GetWorkoutPerMonth(userId uint, year int, month int) ([]model.WorkoutResponse, error) {
@@ -46,20 +86,99 @@ func (wu *workoutUsecase) GetWorkoutPerMonth(userId uint, year int, month int) (
 	}
 	resWorkout := make([]model.WorkoutResponse, len(workouts))
 	for i, w := range workouts {
-		resWorkout[i] = model.WorkoutResponse{
-			ID:   w.ID,
-			Date: w.Date,
-			StartTime:    w.StartTime,
-			Workout:      w.Workout,
-			LapTime:      w.LapTime,
-			Mileage:      w.Mileage,
-			MileageUnit:  w.MileageUnit,
-			Weather:      w.Weather,			
-		}
+		resWorkout[i] = toWorkoutResponse(w)
 	}
 	return resWorkout, nil
 }
 
+// 練習一覧の1ページあたりの件数
+const (
+	DefaultWorkoutPageSize = 50
+	MaxWorkoutPageSize     = 200
+)
+
+// GetWorkouts は from〜to の練習を日付・開始時刻順に取得する（sort は asc または desc）
+// cursor には前のページの next_cursor を渡す
+func (wu *workoutUsecase) GetWorkouts(userId uint, from time.Time, to time.Time, cursor string, sort string, limit int) (model.WorkoutListResponse, error) {
+	if sort == "" {
+		sort = "asc"
+	}
+	if sort != "asc" && sort != "desc" {
+		return model.WorkoutListResponse{}, errors.New("sort must be 'asc' or 'desc'")
+	}
+	if limit <= 0 {
+		limit = DefaultWorkoutPageSize
+	}
+	if limit > MaxWorkoutPageSize {
+		limit = MaxWorkoutPageSize
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return model.WorkoutListResponse{}, errors.New("from must be before to")
+	}
+
+	var after *model.Workout
+	if cursor != "" {
+		w, err := decodeWorkoutCursor(cursor)
+		if err != nil {
+			return model.WorkoutListResponse{}, err
+		}
+		after = &w
+	}
+
+	// 次のページがあるかを判定するため1件多く取得する
+	workouts, err := wu.wr.GetWorkoutsInRange(userId, from, to, after, sort == "desc", limit+1)
+	if err != nil {
+		return model.WorkoutListResponse{}, err
+	}
+
+	res := model.WorkoutListResponse{}
+	if len(workouts) > limit {
+		workouts = workouts[:limit]
+		next := encodeWorkoutCursor(workouts[limit-1])
+		res.NextCursor = &next
+	}
+	res.Workouts = make([]model.WorkoutResponse, len(workouts))
+	for i, w := range workouts {
+		res.Workouts[i] = toWorkoutResponse(w)
+	}
+	return res, nil
+}
+
+// カーソルは最後に返した練習の「日付|開始時刻|ID」を base64 にしたもの
+func encodeWorkoutCursor(w model.Workout) string {
+	raw := fmt.Sprintf("%s|%s|%d", w.Date.Format("2006-01-02"), w.StartTime, w.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeWorkoutCursor(cursor string) (model.Workout, error) {
+	invalid := errors.New("invalid cursor")
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return model.Workout{}, invalid
+	}
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return model.Workout{}, invalid
+	}
+	date, err := time.Parse("2006-01-02", parts[0])
+	if err != nil {
+		return model.Workout{}, invalid
+	}
+	id, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return model.Workout{}, invalid
+	}
+	return model.Workout{ID: uint(id), Date: pkg.DateOnly{Time: date}, StartTime: parts[1]}, nil
+}
+
+func (wu *workoutUsecase) GetWorkoutById(userId uint, workoutId uint) (model.WorkoutResponse, error) {
+	workout := model.Workout{}
+	if err := wu.wr.GetWorkoutById(&workout, userId, workoutId); err != nil {
+		return model.WorkoutResponse{}, err
+	}
+	return toWorkoutResponse(workout), nil
+}
+
 func (wu *workoutUsecase) UpdateWorkout(workout model.Workout, userId uint, workoutId uint) (model.WorkoutResponse, error) {
 	if err := wu.wv.WorkoutValidate(workout); err != nil {
 		return model.WorkoutResponse{}, err
@@ -69,9 +188,472 @@ func (wu *workoutUsecase) UpdateWorkout(workout model.Workout, userId uint, work
 		return model.WorkoutResponse{}, err
 	}
 
-	resWorkout := model.WorkoutResponse{
-		ID:   workout.ID,
-		Date: workout.Date,
+	workout.ID = workoutId
+	return toWorkoutResponse(workout), nil
+}
+
+// DeleteWorkout は練習を論理削除する
+func (wu *workoutUsecase) DeleteWorkout(userId uint, workoutId uint) error {
+	if err := wu.wr.DeleteWorkout(userId, workoutId); err != nil {
+		return err
 	}
-	return resWorkout, nil
+	return nil
+}
+
+// 1マイルあたりの km
+const kmPerMile float64 = 1.609344
+
+// GetWorkoutStats は from〜to の練習を ISO週・月・年ごとに集計する（距離は unit（km または mile）で返す）
+func (wu *workoutUsecase) GetWorkoutStats(userId uint, from time.Time, to time.Time, unit string) (model.WorkoutStatsResponse, error) {
+	if unit == "" {
+		unit = "km"
+	}
+	if unit != "km" && unit != "mile" {
+		return model.WorkoutStatsResponse{}, errors.New("unit must be 'km' or 'mile'")
+	}
+	if to.Before(from) {
+		return model.WorkoutStatsResponse{}, errors.New("from must be before to")
+	}
+	convert := func(km float64) float64 {
+		if unit == "mile" {
+			km /= kmPerMile
+		}
+		return math.Round(km*100) / 100
+	}
+
+	res := model.WorkoutStatsResponse{
+		Unit: unit,
+		From: pkg.DateOnly{Time: from},
+		To:   pkg.DateOnly{Time: to},
+	}
+
+	weeks, err := wu.wr.AggregateWorkouts(userId, from, to, repository.AggregateWeek)
+	if err != nil {
+		return model.WorkoutStatsResponse{}, err
+	}
+	months, err := wu.wr.AggregateWorkouts(userId, from, to, repository.AggregateMonth)
+	if err != nil {
+		return model.WorkoutStatsResponse{}, err
+	}
+	years, err := wu.wr.AggregateWorkouts(userId, from, to, repository.AggregateYear)
+	if err != nil {
+		return model.WorkoutStatsResponse{}, err
+	}
+
+	totalKm := 0.0
+	res.Years = make([]model.WorkoutPeriodStats, len(years))
+	for i, y := range years {
+		res.Years[i] = toPeriodStats(y, y.Period, convert)
+		totalKm += y.DistanceKm
+		res.Sessions += y.Sessions
+	}
+	res.TotalDistance = convert(totalKm)
+
+	res.Months = make([]model.WorkoutPeriodStats, len(months))
+	for i, m := range months {
+		res.Months[i] = toPeriodStats(m, m.Period, convert)
+	}
+
+	res.Weeks = weeklyStats(weeks, from, to, convert)
+
+	longest := model.Workout{}
+	if err := wu.wr.GetLongestWorkout(&longest, userId, from, to); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.WorkoutStatsResponse{}, err
+		}
+	} else {
+		km := longest.Mileage
+		if longest.MileageUnit == "mile" {
+			km *= kmPerMile
+		}
+		res.LongestRun = &model.WorkoutLongestRun{
+			WorkoutID: longest.ID,
+			Date:      longest.Date,
+			Distance:  convert(km),
+		}
+	}
+
+	return res, nil
+}
+
+func toPeriodStats(a model.WorkoutAggregate, period string, convert func(float64) float64) model.WorkoutPeriodStats {
+	return model.WorkoutPeriodStats{
+		Period:     period,
+		Distance:   convert(a.DistanceKm),
+		Sessions:   a.Sessions,
+		LongestRun: convert(a.LongestKm),
+	}
+}
+
+// weeklyStats は練習のない週も 0 として埋め、前週比を計算する
+func weeklyStats(weeks []model.WorkoutAggregate, from time.Time, to time.Time, convert func(float64) float64) []model.WorkoutWeekStats {
+	byWeek := map[string]model.WorkoutAggregate{}
+	for _, w := range weeks {
+		// YEARWEEK(date, 3) の値（例：202405）を 2024-W05 の形式にする
+		if yw, err := strconv.Atoi(w.Period); err == nil {
+			byWeek[fmt.Sprintf("%d-W%02d", yw/100, yw%100)] = w
+		}
+	}
+
+	// from を含む週の月曜日から1週ずつ進める
+	start := from.AddDate(0, 0, -((int(from.Weekday()) + 6) % 7))
+	var res []model.WorkoutWeekStats
+	for d := start; !d.After(to); d = d.AddDate(0, 0, 7) {
+		year, week := d.ISOWeek()
+		period := fmt.Sprintf("%d-W%02d", year, week)
+		stats := model.WorkoutWeekStats{
+			WorkoutPeriodStats: toPeriodStats(byWeek[period], period, convert),
+		}
+		if len(res) > 0 {
+			prev := res[len(res)-1].Distance
+			change := math.Round((stats.Distance-prev)*100) / 100
+			stats.Change = &change
+			if prev > 0 {
+				percent := math.Round((stats.Distance-prev)/prev*1000) / 10
+				stats.ChangePercent = &percent
+			}
+		}
+		res = append(res, stats)
+	}
+	return res
+}
+
+// ラップの評価
+const (
+	LapOnTarget      = "on_target"
+	LapTooFast       = "too_fast"
+	LapTooSlow       = "too_slow"
+	LapNotComparable = "not_comparable"
+)
+
+// LapPaceTolerance は上限のないゾーン（M, T, I, R）で目標タイムとみなす幅（±2%）
+const LapPaceTolerance float64 = 0.02
+
+// AnalyzeWorkout はラップタイムを練習内容の各本に対応させ、現在のVDOTのペースゾーンと比較する。
+// ラップの数は、全ての本の数か、E以外の本の数のどちらかと一致している必要がある。
+func (wu *workoutUsecase) AnalyzeWorkout(userId uint, workoutId uint, rule string, days int, opts VdotOptions) (model.WorkoutAnalysisResponse, error) {
+	workout := model.Workout{}
+	if err := wu.wr.GetWorkoutById(&workout, userId, workoutId); err != nil {
+		return model.WorkoutAnalysisResponse{}, err
+	}
+	if workout.LapTime == nil {
+		return model.WorkoutAnalysisResponse{}, errors.New("lap_time is not recorded")
+	}
+
+	segments, err := workoutparser.Parse(workout.Workout)
+	if err != nil {
+		return model.WorkoutAnalysisResponse{}, err
+	}
+	laps, err := workoutparser.ParseLaps(*workout.LapTime)
+	if err != nil {
+		return model.WorkoutAnalysisResponse{}, err
+	}
+
+	vdot, err := selectVdot(wu.vr, userId, rule, days)
+	if err != nil {
+		return model.WorkoutAnalysisResponse{}, fmt.Errorf("vdot data not found: %v", err)
+	}
+	detail, err := ComputeVdot(vdot)
+	if err != nil {
+		return model.WorkoutAnalysisResponse{}, err
+	}
+	zoneProfile, err := resolveZoneProfile(wu.zpr, userId, opts.Profile)
+	if err != nil {
+		return model.WorkoutAnalysisResponse{}, err
+	}
+
+	reps, err := matchLaps(segments, laps)
+	if err != nil {
+		return model.WorkoutAnalysisResponse{}, err
+	}
+
+	res := model.WorkoutAnalysisResponse{
+		WorkoutID: workout.ID,
+		Vdot:      detail.AdjustedVdot,
+		Laps:      make([]model.LapAnalysis, 0, len(reps)),
+	}
+	velocity := ZoneReferenceVelocity(detail.PreciseVdot, opts.Training)
+	for i, rep := range reps {
+		lap := evaluateLap(rep, laps[i], velocity, zoneProfile)
+		lap.Lap = i + 1
+		switch lap.Status {
+		case LapOnTarget:
+			res.OnTarget++
+		case LapTooFast:
+			res.TooFast++
+		case LapTooSlow:
+			res.TooSlow++
+		default:
+			res.NotComparable++
+		}
+		res.Laps = append(res.Laps, lap)
+	}
+	return res, nil
+}
+
+// workoutRep は練習内容を1本ずつに展開したもの
+type workoutRep struct {
+	segmentIndex int
+	repetition   int
+	segment      workoutparser.Segment
+}
+
+// matchLaps はラップの数に合わせて、ラップに対応する本を返す
+func matchLaps(segments []workoutparser.Segment, laps []float64) ([]workoutRep, error) {
+	var all, quality []workoutRep
+	for i, segment := range segments {
+		for r := 1; r <= segment.Repetitions; r++ {
+			rep := workoutRep{segmentIndex: i + 1, repetition: r, segment: segment}
+			all = append(all, rep)
+			if segment.Zone != "E" {
+				quality = append(quality, rep)
+			}
+		}
+	}
+	switch len(laps) {
+	case len(all):
+		return all, nil
+	case len(quality):
+		return quality, nil
+	}
+	return nil, fmt.Errorf("lap count (%d) must match the number of reps (%d) or non-E reps (%d)", len(laps), len(all), len(quality))
+}
+
+func evaluateLap(rep workoutRep, lapSeconds float64, velocity float64, zoneProfile model.ZoneProfile) model.LapAnalysis {
+	lap := model.LapAnalysis{
+		SegmentIndex:    rep.segmentIndex,
+		Repetition:      rep.repetition,
+		Zone:            rep.segment.Zone,
+		DistanceMeters:  rep.segment.DistanceMeters,
+		DurationSeconds: rep.segment.DurationSeconds,
+		LapSeconds:      lapSeconds,
+		Status:          LapNotComparable,
+	}
+
+	// 時間で指定した本や、ゾーン定義にないゾーンは比較できない
+	zone, ok := findZone(zoneProfile, rep.segment.Zone)
+	if !ok || rep.segment.DistanceMeters == nil {
+		return lap
+	}
+
+	distance := *rep.segment.DistanceMeters
+	// %が低いほどペースは遅い
+	slowest := CalculatePace(velocity, zone.Lower, distance) * 60
+	fastest := slowest
+	if zone.Upper != nil {
+		fastest = CalculatePace(velocity, *zone.Upper, distance) * 60
+	} else {
+		fastest = slowest * (1 - LapPaceTolerance)
+		slowest = slowest * (1 + LapPaceTolerance)
+	}
+	fastest = math.Round(fastest*10) / 10
+	slowest = math.Round(slowest*10) / 10
+	lap.TargetFastestSeconds = &fastest
+	lap.TargetSlowestSeconds = &slowest
+
+	diff := 0.0
+	switch {
+	case lapSeconds < fastest:
+		diff = lapSeconds - fastest
+		lap.Status = LapTooFast
+	case lapSeconds > slowest:
+		diff = lapSeconds - slowest
+		lap.Status = LapTooSlow
+	default:
+		lap.Status = LapOnTarget
+	}
+	diff = math.Round(diff*10) / 10
+	lap.DiffSeconds = &diff
+	return lap
+}
+
+func findZone(zoneProfile model.ZoneProfile, zoneName string) (model.ZoneDefinition, bool) {
+	for _, z := range zoneProfile.Zones {
+		if z.Zone == zoneName {
+			return z, true
+		}
+	}
+	return model.ZoneDefinition{}, false
+}
+
+// WorkoutImportOptions は記録ファイルからの取り込みで、ファイルにない項目を指定する
+type WorkoutImportOptions struct {
+	Confirm  bool           // true の場合は保存する（false の場合は確認用に内容だけを返す）
+	Workout  string         // 練習内容（未指定の場合は距離から E{距離}km とする）
+	Weather  string         // 天候（保存する場合は必須）
+	Location *time.Location // 練習日・開始時刻を決めるタイムゾーン（未指定の場合はファイルの時差、なければサーバーの時刻）
+}
+
+// ImportWorkout は GPX, TCX, FIT ファイルから練習日・開始時刻・距離・ラップ・獲得標高を取り出して練習を作る
+func (wu *workoutUsecase) ImportWorkout(userId uint, filename string, data []byte, opts WorkoutImportOptions) (model.WorkoutImportResponse, error) {
+	activity, err := activityfile.Parse(filename, data)
+	if err != nil {
+		return model.WorkoutImportResponse{}, err
+	}
+
+	start := activity.StartTime
+	if opts.Location != nil {
+		start = start.In(opts.Location)
+	} else if start.Location() == time.UTC {
+		start = start.Local()
+	}
+
+	km := math.Round(activity.DistanceMeters/10) / 100
+	laps := make([]model.WorkoutImportLap, len(activity.Laps))
+	lapTimes := make([]string, len(activity.Laps))
+	for i, lap := range activity.Laps {
+		laps[i] = model.WorkoutImportLap{
+			Lap:             i + 1,
+			DistanceMeters:  math.Round(lap.DistanceMeters*10) / 10,
+			DurationSeconds: math.Round(lap.DurationSeconds*10) / 10,
+		}
+		lapTimes[i] = formatLapTime(lap.DurationSeconds)
+	}
+	elevationGain := activity.ElevationGain
+
+	workout := model.Workout{
+		Date:          pkg.DateOnly{Time: time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)},
+		StartTime:     start.Format("15:04"),
+		Workout:       opts.Workout,
+		Mileage:       km,
+		MileageUnit:   "km",
+		Weather:       opts.Weather,
+		ElevationGain: &elevationGain,
+		UserId:        userId,
+	}
+	if workout.Workout == "" {
+		workout.Workout = fmt.Sprintf("E%skm", strconv.FormatFloat(math.Round(km*10)/10, 'f', -1, 64))
+	}
+	if len(lapTimes) > 0 {
+		lapTime := "[" + strings.Join(lapTimes, ", ") + "]"
+		workout.LapTime = &lapTime
+	}
+
+	res := model.WorkoutImportResponse{
+		Format:          activity.Format,
+		StartTime:       start,
+		DistanceMeters:  math.Round(activity.DistanceMeters*10) / 10,
+		DurationSeconds: math.Round(activity.DurationSeconds*10) / 10,
+		ElevationGain:   elevationGain,
+		Laps:            laps,
+	}
+	if opts.Confirm {
+		if err := wu.wv.WorkoutValidate(workout); err != nil {
+			return model.WorkoutImportResponse{}, err
+		}
+		if err := wu.wr.CreateWorkout(&workout); err != nil {
+			return model.WorkoutImportResponse{}, err
+		}
+		res.Saved = true
+	}
+	res.Workout = toWorkoutResponse(workout)
+	return res, nil
+}
+
+// formatLapTime は秒をラップタイムの表記（例：3:30, 1:05:30, 38.4 秒の場合は 0:38.4）にする
+func formatLapTime(seconds float64) string {
+	tenths := int(math.Round(seconds * 10))
+	h, m, s, f := tenths/36000, tenths/600%60, tenths/10%60, tenths%10
+	var lap string
+	if h > 0 {
+		lap = fmt.Sprintf("%d:%02d:%02d", h, m, s)
+	} else {
+		lap = fmt.Sprintf("%d:%02d", m, s)
+	}
+	if f > 0 {
+		lap += fmt.Sprintf(".%d", f)
+	}
+	return lap
+}
+
+// 練習の CSV の列（model.Workout のフィールドと同じ名前）
+// date: YYYY-MM-DD, start_time: HH:mm, lap_time: [3:30, 3:40] の形式（空でも可）
+// mileage_unit は空の場合 km、elevation_gain（m）は空でも可
+var WorkoutCSVColumns = []string{"date", "start_time", "workout", "lap_time", "mileage", "mileage_unit", "weather", "elevation_gain"}
+
+var workoutCSVRequired = []string{"date", "start_time", "workout", "mileage", "weather"}
+
+// ImportWorkoutsCSV は CSV の行ごとに練習をチェックして保存し、行ごとの結果を返す
+func (wu *workoutUsecase) ImportWorkoutsCSV(userId uint, data []byte, allOrNothing bool) (model.CSVImportResponse, error) {
+	rows, err := csvfile.Read(data, WorkoutCSVColumns, workoutCSVRequired)
+	if err != nil {
+		return model.CSVImportResponse{}, err
+	}
+	parse := func(row csvfile.Row) (model.Workout, error) {
+		workout, err := workoutFromCSV(row)
+		if err != nil {
+			return model.Workout{}, err
+		}
+		workout.UserId = userId
+		if err := wu.wv.WorkoutValidate(workout); err != nil {
+			return model.Workout{}, err
+		}
+		return workout, nil
+	}
+	return importCSVRows(rows, allOrNothing, parse, wu.wr.CreateWorkout, wu.wr.CreateWorkouts)
+}
+
+func workoutFromCSV(row csvfile.Row) (model.Workout, error) {
+	date, err := csvDate(row, "date")
+	if err != nil {
+		return model.Workout{}, err
+	}
+	mileage, err := csvFloat(row, "mileage")
+	if err != nil {
+		return model.Workout{}, err
+	}
+	elevationGain, err := csvOptionalFloat(row, "elevation_gain")
+	if err != nil {
+		return model.Workout{}, err
+	}
+	workout := model.Workout{
+		Date:          date,
+		StartTime:     row.Get("start_time"),
+		Workout:       row.Get("workout"),
+		Mileage:       mileage,
+		MileageUnit:   row.Get("mileage_unit"),
+		Weather:       row.Get("weather"),
+		ElevationGain: elevationGain,
+	}
+	if workout.MileageUnit == "" {
+		workout.MileageUnit = "km"
+	}
+	if lapTime := row.Get("lap_time"); lapTime != "" {
+		workout.LapTime = &lapTime
+	}
+	return workout, nil
+}
+
+// ExportWorkoutsCSV は from〜to の練習を日付・開始時刻順に CSV にする（from, to が zero の場合は制限なし）
+func (wu *workoutUsecase) ExportWorkoutsCSV(userId uint, from time.Time, to time.Time) ([]byte, error) {
+	workouts, err := wu.wr.GetWorkoutsInRange(userId, from, to, nil, false, 0)
+	if err != nil {
+		return nil, err
+	}
+	resWorkouts := make([]model.WorkoutResponse, len(workouts))
+	for i, w := range workouts {
+		resWorkouts[i] = toWorkoutResponse(w)
+	}
+	return csvfile.Write(WorkoutCSVColumns, workoutCSVRecords(resWorkouts))
+}
+
+func workoutCSVRecords(workouts []model.WorkoutResponse) [][]string {
+	records := make([][]string, len(workouts))
+	for i, w := range workouts {
+		lapTime := ""
+		if w.LapTime != nil {
+			lapTime = *w.LapTime
+		}
+		records[i] = []string{
+			formatCSVDate(w.Date),
+			w.StartTime,
+			w.Workout,
+			lapTime,
+			strconv.FormatFloat(w.Mileage, 'f', -1, 64),
+			w.MileageUnit,
+			w.Weather,
+			formatCSVFloat(w.ElevationGain),
+		}
+	}
+	return records
 }
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"errors"
+	"go_vdot_api/model"
+	"go_vdot_api/repository"
+	"go_vdot_api/validator"
+	"strconv"
+)
+
+// DefaultZoneProfileName は組み込みの Daniels のゾーン定義を指す profile の値
+const DefaultZoneProfileName = "daniels"
+
+// DefaultZoneProfile は組み込みのゾーン定義（Daniels のデフォルト）を返す
+func DefaultZoneProfile() model.ZoneProfile {
+	upperE := 77.0
+	return model.ZoneProfile{
+		Name: "Daniels",
+		Zones: model.ZoneDefinitions{
+			{Zone: "E", Lower: 70, Upper: &upperE},
+			{Zone: "M", Lower: 88},
+			{Zone: "T", Lower: 92.5},
+			{Zone: "I", Lower: 100.5},
+			{Zone: "R", Lower: 108.25},
+		},
+		Distances: model.ZoneDistances{
+			{Label: "1mi", Meters: 1609.34},
+			{Label: "1Km", Meters: 1000},
+			{Label: "1200m", Meters: 1200},
+			{Label: "800m", Meters: 800},
+			{Label: "600m", Meters: 600},
+			{Label: "400m", Meters: 400},
+			{Label: "300m", Meters: 300},
+			{Label: "200m", Meters: 200},
+		},
+	}
+}
+
+type IZoneProfileUsecase interface {
+	CreateZoneProfile(zoneProfile model.ZoneProfile) (model.ZoneProfileResponse, error)
+	GetZoneProfiles(userId uint) ([]model.ZoneProfileResponse, error)
+	GetZoneProfileById(userId uint, zoneProfileId uint) (model.ZoneProfileResponse, error)
+	UpdateZoneProfile(zoneProfile model.ZoneProfile, userId uint, zoneProfileId uint) (model.ZoneProfileResponse, error)
+	DeleteZoneProfile(userId uint, zoneProfileId uint) error
+}
+
+type zoneProfileUsecase struct {
+	zpr repository.IZoneProfileRepository
+	zpv validator.IZoneProfileValidator
+}
+
+func NewZoneProfileUsecase(zpr repository.IZoneProfileRepository, zpv validator.IZoneProfileValidator) IZoneProfileUsecase {
+	return &zoneProfileUsecase{zpr, zpv}
+}
+
+func toZoneProfileResponse(zoneProfile model.ZoneProfile) model.ZoneProfileResponse {
+	return model.ZoneProfileResponse{
+		ID:        zoneProfile.ID,
+		Name:      zoneProfile.Name,
+		Zones:     zoneProfile.Zones,
+		Distances: zoneProfile.Distances,
+		IsBuiltin: zoneProfile.ID == 0,
+	}
+}
+
+func (zpu *zoneProfileUsecase) CreateZoneProfile(zoneProfile model.ZoneProfile) (model.ZoneProfileResponse, error) {
+	if err := zpu.zpv.ZoneProfileValidate(zoneProfile); err != nil {
+		return model.ZoneProfileResponse{}, err
+	}
+
+	if err := zpu.zpr.CreateZoneProfile(&zoneProfile); err != nil {
+		return model.ZoneProfileResponse{}, err
+	}
+	return toZoneProfileResponse(zoneProfile), nil
+}
+
+// GetZoneProfiles は組み込みの定義を先頭にして、ユーザーのゾーン定義と承認したコーチのゾーン定義を返す
+func (zpu *zoneProfileUsecase) GetZoneProfiles(userId uint) ([]model.ZoneProfileResponse, error) {
+	zoneProfiles, err := zpu.zpr.GetZoneProfiles(userId)
+	if err != nil {
+		return nil, err
+	}
+	coachZoneProfiles, err := zpu.zpr.GetCoachZoneProfiles(userId)
+	if err != nil {
+		return nil, err
+	}
+	resZoneProfiles := make([]model.ZoneProfileResponse, 0, len(zoneProfiles)+len(coachZoneProfiles)+1)
+	resZoneProfiles = append(resZoneProfiles, toZoneProfileResponse(DefaultZoneProfile()))
+	for _, zp := range append(zoneProfiles, coachZoneProfiles...) {
+		resZoneProfiles = append(resZoneProfiles, toUsableZoneProfileResponse(zp, userId))
+	}
+	return resZoneProfiles, nil
+}
+
+// GetZoneProfileById はユーザーまたは承認したコーチのゾーン定義を返す
+func (zpu *zoneProfileUsecase) GetZoneProfileById(userId uint, zoneProfileId uint) (model.ZoneProfileResponse, error) {
+	zoneProfile := model.ZoneProfile{}
+	if err := zpu.zpr.GetUsableZoneProfileById(&zoneProfile, userId, zoneProfileId); err != nil {
+		return model.ZoneProfileResponse{}, err
+	}
+	return toUsableZoneProfileResponse(zoneProfile, userId), nil
+}
+
+// toUsableZoneProfileResponse は userId 以外（コーチ）のゾーン定義を共有されたものとして返す
+func toUsableZoneProfileResponse(zoneProfile model.ZoneProfile, userId uint) model.ZoneProfileResponse {
+	res := toZoneProfileResponse(zoneProfile)
+	res.IsShared = zoneProfile.UserId != userId
+	return res
+}
+
+func (zpu *zoneProfileUsecase) UpdateZoneProfile(zoneProfile model.ZoneProfile, userId uint, zoneProfileId uint) (model.ZoneProfileResponse, error) {
+	if err := zpu.zpv.ZoneProfileValidate(zoneProfile); err != nil {
+		return model.ZoneProfileResponse{}, err
+	}
+
+	if err := zpu.zpr.UpdateZoneProfile(&zoneProfile, userId, zoneProfileId); err != nil {
+		return model.ZoneProfileResponse{}, err
+	}
+	zoneProfile.ID = zoneProfileId
+	return toZoneProfileResponse(zoneProfile), nil
+}
+
+func (zpu *zoneProfileUsecase) DeleteZoneProfile(userId uint, zoneProfileId uint) error {
+	return zpu.zpr.DeleteZoneProfile(userId, zoneProfileId)
+}
+
+// resolveZoneProfile は profile パラメータ（空または "daniels" は組み込み、数値はユーザーまたは承認したコーチの定義のID）からゾーン定義を取得する
+func resolveZoneProfile(zpr repository.IZoneProfileRepository, userId uint, profile string) (model.ZoneProfile, error) {
+	if profile == "" || profile == DefaultZoneProfileName {
+		return DefaultZoneProfile(), nil
+	}
+	zoneProfileId, err := strconv.Atoi(profile)
+	if err != nil || zoneProfileId <= 0 {
+		return model.ZoneProfile{}, errors.New("invalid profile. Use daniels or a zone profile id")
+	}
+	zoneProfile := model.ZoneProfile{}
+	if err := zpr.GetUsableZoneProfileById(&zoneProfile, userId, uint(zoneProfileId)); err != nil {
+		return model.ZoneProfile{}, err
+	}
+	return zoneProfile, nil
+}
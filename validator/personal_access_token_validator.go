@@ -0,0 +1,40 @@
+package validator
+
+import (
+	"go_vdot_api/model"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// 個人用アクセストークンの有効期限の上限（日）
+const MaxTokenExpiresInDays = 365
+
+type IPersonalAccessTokenValidator interface {
+	PersonalAccessTokenValidate(req model.PersonalAccessTokenRequest) error
+}
+
+type personalAccessTokenValidator struct{}
+
+func NewPersonalAccessTokenValidator() IPersonalAccessTokenValidator {
+	return &personalAccessTokenValidator{}
+}
+
+func (patv *personalAccessTokenValidator) PersonalAccessTokenValidate(req model.PersonalAccessTokenRequest) error {
+	return validation.ValidateStruct(&req,
+		validation.Field(
+			&req.Name,
+			validation.Required.Error("name is required"),
+			validation.RuneLength(1, 50).Error("name must be 1 to 50 characters"),
+		),
+		validation.Field(
+			&req.Scopes,
+			validation.Required.Error("scopes are required"),
+			validation.Each(validation.In(model.TokenScopeRead, model.TokenScopeWrite).Error("scope must be read or write")),
+		),
+		validation.Field(
+			&req.ExpiresInDays,
+			validation.Min(0).Error("expires_in_days must be 0 or more"),
+			validation.Max(MaxTokenExpiresInDays).Error("expires_in_days must be 365 or less"),
+		),
+	)
+}
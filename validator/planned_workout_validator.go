@@ -0,0 +1,51 @@
+package validator
+
+import (
+	"go_vdot_api/model"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type IPlannedWorkoutValidator interface {
+	PlannedWorkoutValidate(plannedWorkout model.PlannedWorkout) error
+}
+
+type plannedWorkoutValidator struct{}
+
+func NewPlannedWorkoutValidator() IPlannedWorkoutValidator {
+	return &plannedWorkoutValidator{}
+}
+
+func (pwv *plannedWorkoutValidator) PlannedWorkoutValidate(plannedWorkout model.PlannedWorkout) error {
+	return validation.ValidateStruct(&plannedWorkout,
+		validation.Field(
+			&plannedWorkout.Date,
+			validation.Required.Error("date is required"),
+		),
+
+		// 練習内容（練習記録と同じ表記）
+		validation.Field(
+			&plannedWorkout.Workout,
+			validation.Required.Error("workout is required"),
+			validation.By(validateWorkoutNotation),
+		),
+
+		validation.Field(
+			&plannedWorkout.Mileage,
+			validation.Min(0.0).Error("mileage must be 0 or more"),
+		),
+
+		validation.Field(
+			&plannedWorkout.MileageUnit,
+			validation.Required,
+			validation.In("km", "mile").Error("mileage_unit must be 'km' or 'mile'"),
+		),
+
+		// メモ（null許容、最大500文字）
+		validation.Field(
+			&plannedWorkout.Note,
+			validation.NilOrNotEmpty,
+			validation.RuneLength(0, 500).Error("note must be 500 characters or less"),
+		),
+	)
+}
@@ -3,7 +3,7 @@ package validator
 import (
 	"errors"
 	"go_vdot_api/model"
-	"regexp"
+	"go_vdot_api/pkg"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
 )
@@ -46,18 +46,8 @@ func (v *specialtyEventValidator) SpecialtyEventValidate(event model.SpecialtyEv
 		return err
 	}
 
-	// best_time の形式チェック
-	formats := []string{
-		`^\d{1,2}:\d{2}:\d{2}$`,      // 例: 2:22:25
-		`^\d{1,2}'\d{2}"(\d{1,2})?$`, // 例: 4'12"11
-	}
-	matched := false
-	for _, f := range formats {
-		if matched, _ = regexp.MatchString(f, event.BestTime); matched {
-			break
-		}
-	}
-	if !matched {
+	// best_time の形式チェック（hh:mm:ss または m'ss"SS）
+	if _, err := pkg.ParseRaceTime(event.BestTime); err != nil {
 		return errors.New("invalid time format. Use hh:mm:ss or m'ss\"SS")
 	}
 
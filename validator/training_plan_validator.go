@@ -0,0 +1,71 @@
+package validator
+
+import (
+	"fmt"
+	"go_vdot_api/model"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+// 練習できる曜日の表記
+var planDays = []interface{}{"mon", "tue", "wed", "thu", "fri", "sat", "sun"}
+
+type ITrainingPlanValidator interface {
+	TrainingPlanValidate(trainingPlan model.TrainingPlan) error
+}
+
+type trainingPlanValidator struct{}
+
+func NewTrainingPlanValidator() ITrainingPlanValidator {
+	return &trainingPlanValidator{}
+}
+
+func (tpv *trainingPlanValidator) TrainingPlanValidate(trainingPlan model.TrainingPlan) error {
+	err := validation.ValidateStruct(&trainingPlan,
+		validation.Field(
+			&trainingPlan.Name,
+			validation.Required.Error("name is required"),
+			validation.RuneLength(1, 50).Error("name must be 1 to 50 characters"),
+		),
+		// 目標レースの距離（1500m〜フルマラソン）
+		validation.Field(
+			&trainingPlan.GoalDistance,
+			validation.Required.Error("goal_distance is required"),
+			validation.Min(1500.0).Error("goal_distance must be 1500 or more"),
+			validation.Max(42195.0).Error("goal_distance must be 42195 or less"),
+		),
+		validation.Field(
+			&trainingPlan.WeeklyMileage,
+			validation.Required.Error("weekly_mileage is required"),
+			validation.Min(5.0).Error("weekly_mileage must be 5 or more"),
+			validation.Max(300.0).Error("weekly_mileage must be 300 or less"),
+		),
+		validation.Field(
+			&trainingPlan.MileageUnit,
+			validation.Required.Error("mileage_unit is required"),
+			validation.In("km", "mile").Error("mileage_unit must be 'km' or 'mile'"),
+		),
+		// 質の高い練習とロング走を入れるため週3日以上
+		validation.Field(
+			&trainingPlan.AvailableDays,
+			validation.Required.Error("available_days is required"),
+			validation.Length(3, 7).Error("available_days must have 3 to 7 items"),
+			validation.Each(validation.In(planDays...).Error("available_days must be mon, tue, wed, thu, fri, sat or sun")),
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	if trainingPlan.GoalDate.IsZero() {
+		return fmt.Errorf("goal_date is required")
+	}
+	seen := map[string]bool{}
+	for _, d := range trainingPlan.AvailableDays {
+		if seen[d] {
+			return fmt.Errorf("available_days must not contain duplicates: %s", d)
+		}
+		seen[d] = true
+	}
+	return nil
+}
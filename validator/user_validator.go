@@ -9,6 +9,7 @@ import (
 
 type IUserValidator interface {
 	UserValidate(user model.User) error
+	PasswordValidate(password string) error
 }
 
 type userValidator struct{}
@@ -33,3 +34,11 @@ func (uv *userValidator) UserValidate(user model.User) error {
 		),
 	)
 }
+
+// PasswordValidate はパスワードの再設定で新しいパスワードのみチェックする（UserValidate と同じ条件）
+func (uv *userValidator) PasswordValidate(password string) error {
+	return validation.Validate(password,
+		validation.Required.Error("password is required"),
+		validation.RuneLength(6, 30).Error("limited min 6 max 30 char"),
+	)
+}
@@ -26,11 +26,23 @@ func (vv *vdotValidator) VdotValidate(vdot model.Vdot) error {
 		validation.Field(
 			&vdot.DistanceUnit,
 			validation.Required.Error("distance unit is required"),
+			validation.In("km", "mile", "m").Error("distance unit must be 'km', 'mile' or 'm'"),
 		),
 		validation.Field(
 			&vdot.Time,
 			validation.Required.Error("time is required"),
 			validation.Match(regexp.MustCompile(`^\d{2}:\d{2}:\d{2}$`)).Error("time must be in HH:MM:SS format"),
 		),
+		// 標高（m）・気温（℃）は任意。指定された場合のみ範囲をチェック
+		validation.Field(
+			&vdot.Elevation,
+			validation.Min(-500.0).Error("elevation must be -500 or more"),
+			validation.Max(5000.0).Error("elevation must be 5000 or less"),
+		),
+		validation.Field(
+			&vdot.Temperature,
+			validation.Min(-30.0).Error("temperature must be -30 or more"),
+			validation.Max(50.0).Error("temperature must be 50 or less"),
+		),
 	)
 }
@@ -2,6 +2,7 @@ package validator
 
 import (
 	"go_vdot_api/model"
+	"go_vdot_api/pkg/workoutparser"
 	"regexp"
 
 	validation "github.com/go-ozzo/ozzo-validation/v4"
@@ -37,6 +38,7 @@ func (wv *workoutValidator) WorkoutValidate(workout model.Workout) error {
 			&workout.Workout,
 			validation.Required.Error("workout is required"),
 			validation.Length(3, 0).Error("workout must be at least 3 characters"),
+			validation.By(validateWorkoutNotation),
 		),
 
 		// ラップタイム（null許容。指定された場合のみバリデーション）
@@ -44,6 +46,7 @@ func (wv *workoutValidator) WorkoutValidate(workout model.Workout) error {
 			&workout.LapTime,
 			validation.NilOrNotEmpty,
 			validation.Match(regexp.MustCompile(`^\[.*\]$`)).Error("lap_time must be a JSON-like array format"),
+			validation.By(validateLapTime),
 		),
 
 		// 練習距離（0以上）
@@ -68,3 +71,24 @@ func (wv *workoutValidator) WorkoutValidate(workout model.Workout) error {
 		),
 	)
 }
+
+// validateWorkoutNotation は練習内容が解析できる表記（例：E3.2km, 6x(I800m・レスト2分), E3.2km）かチェックする
+func validateWorkoutNotation(value interface{}) error {
+	s, _ := value.(string)
+	if _, err := workoutparser.Parse(s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validateLapTime はラップタイムが解析できる表記（例：[3:30, 3:40, 3:50]）かチェックする
+func validateLapTime(value interface{}) error {
+	lapTime, ok := value.(*string)
+	if !ok || lapTime == nil {
+		return nil
+	}
+	if _, err := workoutparser.ParseLaps(*lapTime); err != nil {
+		return err
+	}
+	return nil
+}
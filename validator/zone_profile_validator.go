@@ -0,0 +1,65 @@
+package validator
+
+import (
+	"fmt"
+	"go_vdot_api/model"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+)
+
+type IZoneProfileValidator interface {
+	ZoneProfileValidate(zoneProfile model.ZoneProfile) error
+}
+
+type zoneProfileValidator struct{}
+
+func NewZoneProfileValidator() IZoneProfileValidator {
+	return &zoneProfileValidator{}
+}
+
+func (zpv *zoneProfileValidator) ZoneProfileValidate(zoneProfile model.ZoneProfile) error {
+	err := validation.ValidateStruct(&zoneProfile,
+		validation.Field(
+			&zoneProfile.Name,
+			validation.Required.Error("name is required"),
+			validation.RuneLength(1, 50).Error("name must be 1 to 50 characters"),
+		),
+		validation.Field(
+			&zoneProfile.Zones,
+			validation.Required.Error("zones are required"),
+			validation.Length(1, 20).Error("zones must have 1 to 20 items"),
+		),
+		validation.Field(
+			&zoneProfile.Distances,
+			validation.Required.Error("distances are required"),
+			validation.Length(1, 30).Error("distances must have 1 to 30 items"),
+		),
+	)
+	if err != nil {
+		return err
+	}
+
+	// ゾーンの%は 1〜200 の範囲で、上限がある場合は下限より大きいこと
+	for i, z := range zoneProfile.Zones {
+		if z.Zone == "" || len([]rune(z.Zone)) > 10 {
+			return fmt.Errorf("zones[%d]: zone must be 1 to 10 characters", i)
+		}
+		if z.Lower <= 0 || z.Lower > 200 {
+			return fmt.Errorf("zones[%d]: lower must be greater than 0 and 200 or less", i)
+		}
+		if z.Upper != nil && (*z.Upper <= z.Lower || *z.Upper > 200) {
+			return fmt.Errorf("zones[%d]: upper must be greater than lower and 200 or less", i)
+		}
+	}
+
+	for i, d := range zoneProfile.Distances {
+		if d.Label == "" || len([]rune(d.Label)) > 20 {
+			return fmt.Errorf("distances[%d]: label must be 1 to 20 characters", i)
+		}
+		if d.Meters <= 0 || d.Meters > 50000 {
+			return fmt.Errorf("distances[%d]: meters must be greater than 0 and 50000 or less", i)
+		}
+	}
+
+	return nil
+}